@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"ycg_cloud/internal/database"
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+)
+
+func main() {
+	anchorFile := flag.String("anchors", "", "锚点文件路径，默认使用配置文件中的 log.audit.anchor_file")
+	skipAnchors := flag.Bool("no-anchors", false, "不比对锚点文件")
+	flag.Parse()
+
+	fmt.Println("=== 审计哈希链校验 ===")
+
+	// 1. 初始化配置
+	if err := utils.InitConfig("", ""); err != nil {
+		log.Fatalf("配置初始化失败: %v", err)
+	}
+
+	// 2. 连接数据库
+	config := utils.GetConfig()
+	db, err := database.InitDB(config)
+	if err != nil {
+		log.Fatalf("数据库连接失败: %v", err)
+	}
+
+	// 3. 读取锚点，路径只取命令行参数或配置文件，不读数据库中的系统配置
+	path := *anchorFile
+	if path == "" {
+		path = config.Log.Audit.AnchorFile
+	}
+	trail := service.NewAuditTrail(db, path)
+	var anchors []service.AuditAnchor
+	if !*skipAnchors {
+		path = trail.AnchorFile()
+		anchors, err = service.LoadAuditAnchors(path)
+		if err != nil {
+			log.Fatalf("读取锚点失败: %v", err)
+		}
+		fmt.Printf("锚点文件: %s (%d 个锚点)\n", path, len(anchors))
+	}
+
+	// 4. 遍历校验
+	result, err := trail.Verify(context.Background(), anchors)
+	if err != nil {
+		log.Fatalf("校验失败: %v", err)
+	}
+
+	fmt.Printf("已校验记录: %d, 归档边界: %d, 链尾序号: %d, 链头序号: %d, 已核对锚点: %d\n",
+		result.Checked, result.ArchivedSeq, result.LastSeq, result.HeadSeq, result.AnchorsChecked)
+	if !result.Valid {
+		fmt.Printf("❌ 审计链在第 %d 条断开 (日志ID: %d): %s\n", result.BrokenSeq, result.BrokenLogID, result.Reason)
+		os.Exit(1)
+	}
+	fmt.Println("✓ 审计链完整")
+}
@@ -0,0 +1,63 @@
+// Package database 负责数据库连接的初始化与全局访问
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/utils"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DB 全局数据库实例
+var DB *gorm.DB
+
+// InitDB 初始化数据库连接
+func InitDB(config *model.Config) (*gorm.DB, error) {
+	if config == nil {
+		return nil, fmt.Errorf("配置未初始化")
+	}
+
+	db, err := gorm.Open(mysql.Open(utils.GetDSN()), &gorm.Config{
+		Logger: logger.Default.LogMode(parseLogLevel(config.Database.LogLevel)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+
+	// 设置连接池参数
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接池失败: %w", err)
+	}
+	sqlDB.SetMaxIdleConns(config.Database.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(config.Database.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(config.Database.ConnMaxLifetime)
+
+	DB = db
+	log.Println("数据库连接成功")
+	return db, nil
+}
+
+// GetDB 获取全局数据库实例
+func GetDB() *gorm.DB {
+	return DB
+}
+
+// parseLogLevel 解析GORM日志级别
+func parseLogLevel(level string) logger.LogLevel {
+	switch level {
+	case "silent":
+		return logger.Silent
+	case "error":
+		return logger.Error
+	case "warn":
+		return logger.Warn
+	default:
+		return logger.Info
+	}
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis 全局Redis客户端
+var Redis *redis.Client
+
+// InitRedis 初始化Redis连接
+func InitRedis(config *model.Config) (*redis.Client, error) {
+	if config == nil {
+		return nil, fmt.Errorf("配置未初始化")
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:         utils.GetRedisAddr(),
+		Password:     config.Redis.Password,
+		DB:           config.Redis.DB,
+		PoolSize:     config.Redis.PoolSize,
+		MinIdleConns: config.Redis.MinIdleConns,
+		DialTimeout:  config.Redis.DialTimeout,
+		ReadTimeout:  config.Redis.ReadTimeout,
+		WriteTimeout: config.Redis.WriteTimeout,
+		PoolTimeout:  config.Redis.PoolTimeout,
+		IdleTimeout:  config.Redis.IdleTimeout,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("连接Redis失败: %w", err)
+	}
+
+	Redis = client
+	log.Println("Redis连接成功")
+	return client, nil
+}
+
+// GetRedis 获取全局Redis客户端
+func GetRedis() *redis.Client {
+	return Redis
+}
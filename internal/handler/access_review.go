@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessReviewHandler 访问审查接口处理器
+type AccessReviewHandler struct {
+	accessReviewService *service.AccessReviewService
+}
+
+// NewAccessReviewHandler 创建访问审查接口处理器
+func NewAccessReviewHandler(accessReviewService *service.AccessReviewService) *AccessReviewHandler {
+	return &AccessReviewHandler{accessReviewService: accessReviewService}
+}
+
+// FileAccess 查看谁能访问文件或文件夹，format=csv时导出CSV
+// GET /api/v1/access-review/files/:file_id
+func (h *AccessReviewHandler) FileAccess(ctx *gin.Context) {
+	fileID, ok := parseIDParam(ctx, "file_id")
+	if !ok {
+		return
+	}
+
+	report, err := h.accessReviewService.FileAccess(middleware.GetUserID(ctx), fileID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	respondAccessReport(ctx, report, fmt.Sprintf("file_access_%d.csv", fileID))
+}
+
+// UserAccess 查看用户能访问的全部文件和文件夹，format=csv时导出CSV
+// GET /api/v1/access-review/users/:user_id
+func (h *AccessReviewHandler) UserAccess(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	report, err := h.accessReviewService.UserAccess(middleware.GetUserID(ctx), userID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	respondAccessReport(ctx, report, fmt.Sprintf("user_access_%d.csv", userID))
+}
+
+// respondAccessReport 按format参数返回JSON或CSV格式的访问审查报表
+func respondAccessReport(ctx *gin.Context, report *service.AccessReport, filename string) {
+	if ctx.Query("format") != "csv" {
+		utils.Success(ctx, report)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	if err := service.WriteAccessCSV(ctx.Writer, report.Entries); err != nil {
+		// 已开始写出时无法再返回错误响应，只能中断连接
+		log.Printf("导出访问审查报表失败: %v", err)
+		ctx.Abort()
+	}
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminUserHandler 管理员用户管理接口处理器
+type AdminUserHandler struct {
+	adminUserService *service.AdminUserService
+}
+
+// NewAdminUserHandler 创建管理员用户管理接口处理器
+func NewAdminUserHandler(adminUserService *service.AdminUserService) *AdminUserHandler {
+	return &AdminUserHandler{adminUserService: adminUserService}
+}
+
+// storageQuotaBody 存储配额请求体
+type storageQuotaBody struct {
+	StorageQuota int64 `json:"storage_quota" binding:"required,gt=0"`
+}
+
+// templateBody 权限模板请求体，permission_template_id为null时取消模板
+type templateBody struct {
+	PermissionTemplateID *uint `json:"permission_template_id"`
+}
+
+// ListUsers 搜索和筛选用户
+// GET /api/v1/admin/users
+func (h *AdminUserHandler) ListUsers(ctx *gin.Context) {
+	page, pageSize := getPagination(ctx)
+	query := &service.AdminUserQuery{
+		Keyword:  ctx.Query("keyword"),
+		Status:   model.UserStatus(ctx.Query("status")),
+		UserType: model.UserType(ctx.Query("user_type")),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if raw := ctx.Query("permission_template_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			utils.Fail(ctx, http.StatusBadRequest, "无效的permission_template_id参数")
+			return
+		}
+		templateID := uint(id)
+		query.TemplateID = &templateID
+	}
+
+	users, total, err := h.adminUserService.ListUsers(middleware.GetUserID(ctx), query)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, utils.PageResult{List: users, Total: total, Page: page, PageSize: pageSize})
+}
+
+// CreateUser 创建用户
+// POST /api/v1/admin/users
+func (h *AdminUserHandler) CreateUser(ctx *gin.Context) {
+	var req service.AdminCreateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	user, err := h.adminUserService.CreateUser(middleware.GetUserID(ctx), &req, ctx.ClientIP())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, user)
+}
+
+// UpdateUserStatus 停用或恢复用户
+// PUT /api/v1/admin/users/:user_id/status
+func (h *AdminUserHandler) UpdateUserStatus(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "user_id")
+	if !ok {
+		return
+	}
+	var req service.UpdateUserStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	if err := h.adminUserService.UpdateUserStatus(middleware.GetUserID(ctx), userID, &req, ctx.ClientIP()); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// UpdateStorageQuota 修改用户存储配额
+// PUT /api/v1/admin/users/:user_id/quota
+func (h *AdminUserHandler) UpdateStorageQuota(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "user_id")
+	if !ok {
+		return
+	}
+	var body storageQuotaBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	err := h.adminUserService.UpdateStorageQuota(middleware.GetUserID(ctx), userID, body.StorageQuota, ctx.ClientIP())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// AssignTemplate 为用户分配权限模板
+// PUT /api/v1/admin/users/:user_id/template
+func (h *AdminUserHandler) AssignTemplate(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "user_id")
+	if !ok {
+		return
+	}
+	var body templateBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	err := h.adminUserService.AssignTemplate(middleware.GetUserID(ctx), userID, body.PermissionTemplateID, ctx.ClientIP())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// ResetMFA 重置用户的多因素认证
+// POST /api/v1/admin/users/:user_id/mfa/reset
+func (h *AdminUserHandler) ResetMFA(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := h.adminUserService.ResetMFA(middleware.GetUserID(ctx), userID, ctx.ClientIP()); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// Impersonate 以用户身份登录，用于技术支持
+// POST /api/v1/admin/users/:user_id/impersonate
+func (h *AdminUserHandler) Impersonate(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "user_id")
+	if !ok {
+		return
+	}
+	// 禁止在模拟登录状态下再次发起模拟登录
+	if claims := middleware.GetClaims(ctx); claims != nil && claims.ImpersonatorID != 0 {
+		respondError(ctx, service.ErrForbidden)
+		return
+	}
+
+	result, err := h.adminUserService.Impersonate(middleware.GetUserID(ctx), userID, ctx.ClientIP())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, result)
+}
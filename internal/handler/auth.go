@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"net/http"
+
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthHandler 认证接口处理器
+type AuthHandler struct {
+	authService *service.AuthService
+}
+
+// NewAuthHandler 创建认证接口处理器
+func NewAuthHandler(authService *service.AuthService) *AuthHandler {
+	return &AuthHandler{authService: authService}
+}
+
+// tokenBody 一次性令牌请求体
+type tokenBody struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// emailBody 邮箱请求体
+type emailBody struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// Register 注册账号并发送验证邮件
+// POST /api/v1/auth/register
+func (h *AuthHandler) Register(ctx *gin.Context) {
+	var req service.RegisterRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	operation := &model.OperationLog{
+		Type: model.LogTypeAuth, Action: model.ActionRegister, Module: "auth", Title: "注册账号", Username: req.Username,
+	}
+	middleware.RecordOperation(ctx, operation)
+
+	user, err := h.authService.Register(&req, ctx.ClientIP())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	operation.UserID = &user.ID
+	utils.Success(ctx, user)
+}
+
+// Login 使用用户名或邮箱登录
+// POST /api/v1/auth/login
+func (h *AuthHandler) Login(ctx *gin.Context) {
+	var req service.LoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	operation := &model.OperationLog{
+		Type: model.LogTypeAuth, Action: model.ActionLogin, Module: "auth", Title: "用户登录", Username: req.Account,
+	}
+	middleware.RecordOperation(ctx, operation)
+
+	result, err := h.authService.Login(&req, clientInfo(ctx))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	operation.UserID = &result.User.ID
+	operation.Username = result.User.Username
+	utils.Success(ctx, result)
+}
+
+// Refresh 使用刷新令牌换取新的访问令牌
+// POST /api/v1/auth/refresh
+func (h *AuthHandler) Refresh(ctx *gin.Context) {
+	var req service.RefreshRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	result, err := h.authService.Refresh(req.RefreshToken, clientInfo(ctx))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, result)
+}
+
+// Logout 退出当前会话
+// POST /api/v1/auth/logout
+func (h *AuthHandler) Logout(ctx *gin.Context) {
+	if err := h.authService.Logout(middleware.GetUserID(ctx), middleware.GetSessionID(ctx)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// VerifyEmail 使用邮件中的令牌验证邮箱
+// POST /api/v1/auth/verify-email
+func (h *AuthHandler) VerifyEmail(ctx *gin.Context) {
+	var body tokenBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	if err := h.authService.VerifyEmail(body.Token); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// ResendVerification 重新发送验证邮件
+// POST /api/v1/auth/resend-verification
+func (h *AuthHandler) ResendVerification(ctx *gin.Context) {
+	var body emailBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	if err := h.authService.ResendVerification(body.Email, ctx.ClientIP()); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// ForgotPassword 申请重置密码，无论邮箱是否存在都返回成功
+// POST /api/v1/auth/password/forgot
+func (h *AuthHandler) ForgotPassword(ctx *gin.Context) {
+	var body emailBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	if err := h.authService.RequestPasswordReset(body.Email, ctx.ClientIP()); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// ResetPassword 使用重置令牌设置新密码
+// POST /api/v1/auth/password/reset
+func (h *AuthHandler) ResetPassword(ctx *gin.Context) {
+	var req service.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	if err := h.authService.ResetPassword(&req, ctx.ClientIP()); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// clientInfo 提取请求的客户端信息
+func clientInfo(ctx *gin.Context) *service.ClientInfo {
+	return &service.ClientInfo{IP: ctx.ClientIP(), UserAgent: ctx.Request.UserAgent()}
+}
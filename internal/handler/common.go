@@ -0,0 +1,128 @@
+// Package handler 实现HTTP接口处理器
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 分页默认值
+const (
+	defaultPage     = 1
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// errorStatuses 业务错误与HTTP状态码的映射
+var errorStatuses = []struct {
+	err    error
+	status int
+}{
+	{service.ErrInvalidParam, http.StatusBadRequest},
+	{service.ErrForbidden, http.StatusForbidden},
+	{service.ErrUserNotFound, http.StatusNotFound},
+
+	// 团队
+	{service.ErrTeamNotFound, http.StatusNotFound},
+	{service.ErrTeamNotPublic, http.StatusForbidden},
+	{service.ErrTeamInactive, http.StatusConflict},
+	{service.ErrTeamFull, http.StatusConflict},
+	{service.ErrAlreadyTeamMember, http.StatusConflict},
+	{service.ErrNotTeamMember, http.StatusNotFound},
+	{service.ErrTeamOwnerCannotLeave, http.StatusConflict},
+	{service.ErrJoinRequestExists, http.StatusConflict},
+	{service.ErrJoinRequestNotFound, http.StatusNotFound},
+	{service.ErrJoinRequestProcessed, http.StatusConflict},
+	{service.ErrAutoApproveRuleInvalid, http.StatusBadRequest},
+	{service.ErrAutoApproveRuleMissing, http.StatusNotFound},
+
+	// 会话与消息
+	{service.ErrConversationNotFound, http.StatusNotFound},
+	{service.ErrConversationInactive, http.StatusConflict},
+	{service.ErrConversationFull, http.StatusConflict},
+	{service.ErrNotConversationMember, http.StatusForbidden},
+	{service.ErrInvalidConversation, http.StatusBadRequest},
+	{service.ErrTeamConversationExists, http.StatusConflict},
+	{service.ErrMessageNotFound, http.StatusNotFound},
+	{service.ErrInvalidMessage, http.StatusBadRequest},
+	{service.ErrFileNotFound, http.StatusNotFound},
+	{service.ErrFileShareDisabled, http.StatusForbidden},
+	{service.ErrMessageRecalled, http.StatusConflict},
+	{service.ErrMessageNotEditable, http.StatusBadRequest},
+	{service.ErrRecallExpired, http.StatusForbidden},
+	{service.ErrMentionNotMember, http.StatusBadRequest},
+	{service.ErrCannotLeave, http.StatusBadRequest},
+	{service.ErrStorageQuotaExceeded, http.StatusConflict},
+	{service.ErrRetentionOutOfRange, http.StatusBadRequest},
+
+	// 通知
+	{service.ErrNotificationTemplate, http.StatusBadRequest},
+	{service.ErrEmailTemplate, http.StatusBadRequest},
+	{service.ErrMailNotConfigured, http.StatusConflict},
+	{service.ErrUserExists, http.StatusConflict},
+	{service.ErrWeakPassword, http.StatusBadRequest},
+	{service.ErrInvalidCredentials, http.StatusUnauthorized},
+	{service.ErrAccountLocked, http.StatusLocked},
+	{service.ErrEmailNotVerified, http.StatusForbidden},
+	{service.ErrAccountDisabled, http.StatusForbidden},
+	{service.ErrInvalidToken, http.StatusBadRequest},
+	{service.ErrTokenRevoked, http.StatusUnauthorized},
+	{service.ErrTooManyRequests, http.StatusTooManyRequests},
+	{service.ErrSessionNotFound, http.StatusNotFound},
+	{service.ErrTemplateNotFound, http.StatusNotFound},
+	{service.ErrTemplateExists, http.StatusConflict},
+	{service.ErrRoleNotFound, http.StatusNotFound},
+	{service.ErrRoleLevelTooHigh, http.StatusForbidden},
+	{service.ErrRoleAssignmentNotFound, http.StatusNotFound},
+
+	// 日志
+	{service.ErrLogKindNotFound, http.StatusNotFound},
+	{service.ErrLogFilterUnsupported, http.StatusBadRequest},
+}
+
+// respondError 将业务错误转换为HTTP响应
+func respondError(ctx *gin.Context, err error) {
+	// 记录到请求上下文，供操作日志填写错误信息
+	_ = ctx.Error(err)
+	for _, item := range errorStatuses {
+		if errors.Is(err, item.err) {
+			utils.Fail(ctx, item.status, item.err.Error())
+			return
+		}
+	}
+	log.Printf("请求 %s %s 处理失败: %v", ctx.Request.Method, ctx.FullPath(), err)
+	utils.Fail(ctx, http.StatusInternalServerError, "服务器内部错误")
+}
+
+// parseIDParam 解析路径中的ID参数
+func parseIDParam(ctx *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		utils.Fail(ctx, http.StatusBadRequest, "无效的"+name+"参数")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// getPagination 解析分页参数
+func getPagination(ctx *gin.Context) (page, pageSize int) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = defaultPage
+	}
+	pageSize, err = strconv.Atoi(ctx.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize
+}
@@ -0,0 +1,456 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/realtime"
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConversationHandler 会话与消息接口处理器
+type ConversationHandler struct {
+	conversationService *service.ConversationService
+	messageService      *service.MessageService
+}
+
+// NewConversationHandler 创建会话与消息接口处理器
+func NewConversationHandler(conversationService *service.ConversationService, messageService *service.MessageService) *ConversationHandler {
+	return &ConversationHandler{conversationService: conversationService, messageService: messageService}
+}
+
+// markReadBody 标记已读请求体
+type markReadBody struct {
+	MessageID uint `json:"message_id" binding:"required"`
+}
+
+// retentionBody 消息保留天数请求体
+type retentionBody struct {
+	Days *int `json:"days" binding:"required,min=0"`
+}
+
+// legalHoldBody 法律保留请求体
+type legalHoldBody struct {
+	Hold   bool   `json:"hold"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// saveFileBody 保存到网盘请求体
+type saveFileBody struct {
+	ParentID *uint `json:"parent_id"`
+}
+
+// recallWindowBody 撤回时限请求体
+type recallWindowBody struct {
+	Seconds *int `json:"seconds" binding:"required,min=0"`
+}
+
+// CreateConversation 创建会话
+// POST /api/v1/conversations
+func (h *ConversationHandler) CreateConversation(ctx *gin.Context) {
+	var req service.CreateConversationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	userID := middleware.GetUserID(ctx)
+	conversation, err := h.conversationService.CreateConversation(userID, &req)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	detail, err := h.conversationService.GetConversation(conversation.ID, userID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, detail)
+}
+
+// ListConversations 获取会话列表
+// GET /api/v1/conversations
+func (h *ConversationHandler) ListConversations(ctx *gin.Context) {
+	page, pageSize := getPagination(ctx)
+	items, total, err := h.conversationService.ListConversations(middleware.GetUserID(ctx), page, pageSize)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, utils.PageResult{List: items, Total: total, Page: page, PageSize: pageSize})
+}
+
+// GetConversation 获取会话详情
+// GET /api/v1/conversations/:id
+func (h *ConversationHandler) GetConversation(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	detail, err := h.conversationService.GetConversation(conversationID, middleware.GetUserID(ctx))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, detail)
+}
+
+// ListMessages 按游标获取消息，before_id向前翻历史，after_id拉取新消息
+// GET /api/v1/conversations/:id/messages
+func (h *ConversationHandler) ListMessages(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	query, ok := parseMessageQuery(ctx)
+	if !ok {
+		return
+	}
+
+	page, err := h.messageService.ListMessages(conversationID, middleware.GetUserID(ctx), query)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, page)
+}
+
+// SendMessage 发送消息
+// POST /api/v1/conversations/:id/messages
+func (h *ConversationHandler) SendMessage(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req service.SendMessageRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	message, err := h.messageService.SendMessage(ctx.Request.Context(), conversationID, middleware.GetUserID(ctx), &req)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, realtime.NewMessagePayload(message))
+}
+
+// MarkRead 标记会话已读
+// POST /api/v1/conversations/:id/read
+func (h *ConversationHandler) MarkRead(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var body markReadBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	if err := h.messageService.MarkRead(ctx.Request.Context(), conversationID, middleware.GetUserID(ctx), body.MessageID); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// EditMessage 编辑消息
+// PUT /api/v1/conversations/:id/messages/:message_id
+func (h *ConversationHandler) EditMessage(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	messageID, ok := parseIDParam(ctx, "message_id")
+	if !ok {
+		return
+	}
+	var req service.EditMessageRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	message, err := h.messageService.EditMessage(ctx.Request.Context(), conversationID, messageID, middleware.GetUserID(ctx), &req)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, realtime.NewMessagePayload(message))
+}
+
+// ListRevisions 查看消息编辑历史
+// GET /api/v1/conversations/:id/messages/:message_id/revisions
+func (h *ConversationHandler) ListRevisions(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	messageID, ok := parseIDParam(ctx, "message_id")
+	if !ok {
+		return
+	}
+
+	revisions, err := h.messageService.ListRevisions(conversationID, messageID, middleware.GetUserID(ctx))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, revisions)
+}
+
+// RecallMessage 撤回消息
+// POST /api/v1/conversations/:id/messages/:message_id/recall
+func (h *ConversationHandler) RecallMessage(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	messageID, ok := parseIDParam(ctx, "message_id")
+	if !ok {
+		return
+	}
+
+	middleware.RecordOperation(ctx, &model.OperationLog{
+		Action: model.ActionMessageRecall, Module: "im", Title: "撤回消息", ResourceType: "message", ResourceID: &messageID,
+	})
+
+	message, err := h.messageService.RecallMessage(ctx.Request.Context(), conversationID, messageID, middleware.GetUserID(ctx))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, realtime.NewMessagePayload(message))
+}
+
+// SetRecallWindow 设置会话撤回时限
+// PUT /api/v1/conversations/:id/recall-window
+func (h *ConversationHandler) SetRecallWindow(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var body recallWindowBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	if err := h.messageService.SetRecallWindow(conversationID, middleware.GetUserID(ctx), *body.Seconds); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// LeaveConversation 退出群聊
+// DELETE /api/v1/conversations/:id/members/me
+func (h *ConversationHandler) LeaveConversation(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.conversationService.LeaveConversation(conversationID, middleware.GetUserID(ctx)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// SaveFileToDrive 将会话中的文件保存到我的网盘
+// POST /api/v1/conversations/:id/messages/:message_id/save
+func (h *ConversationHandler) SaveFileToDrive(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	messageID, ok := parseIDParam(ctx, "message_id")
+	if !ok {
+		return
+	}
+	var body saveFileBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	operation := &model.OperationLog{Action: model.ActionFileCopy, Module: "im", Title: "保存会话文件到网盘", ResourceType: "file"}
+	middleware.RecordOperation(ctx, operation)
+
+	file, err := h.messageService.SaveFileToDrive(conversationID, messageID, middleware.GetUserID(ctx), body.ParentID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	operation.ResourceID = &file.ID
+	operation.ResourceName = file.Name
+	utils.Success(ctx, file)
+}
+
+// SetRetention 设置会话消息保留天数
+// PUT /api/v1/conversations/:id/retention
+func (h *ConversationHandler) SetRetention(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var body retentionBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	if err := h.conversationService.SetRetention(conversationID, middleware.GetUserID(ctx), *body.Days); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// SetLegalHold 设置或解除会话法律保留
+// PUT /api/v1/conversations/:id/legal-hold
+func (h *ConversationHandler) SetLegalHold(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var body legalHoldBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	err := h.conversationService.SetLegalHold(conversationID, middleware.GetUserID(ctx), body.Hold, body.Reason)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// ExportConversation 会话管理员导出会话消息为JSON文件
+// GET /api/v1/conversations/:id/export
+func (h *ConversationHandler) ExportConversation(ctx *gin.Context) {
+	h.export(ctx, h.conversationService.ExportConversation)
+}
+
+// AuditExportConversation 合规审查时导出任意会话的消息
+// GET /api/v1/admin/conversations/:id/export
+func (h *ConversationHandler) AuditExportConversation(ctx *gin.Context) {
+	h.export(ctx, h.conversationService.AuditExportConversation)
+}
+
+// export 以附件形式流式写出会话导出JSON
+func (h *ConversationHandler) export(ctx *gin.Context, exportFunc func(w io.Writer, conversationID, operatorID uint) error) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	ctx.Header("Content-Type", "application/json; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="conversation_%d.json"`, conversationID))
+	err := exportFunc(ctx.Writer, conversationID, middleware.GetUserID(ctx))
+	if err != nil {
+		if ctx.Writer.Written() {
+			// 已开始写出时无法再返回错误响应，只能中断连接
+			log.Printf("导出会话 %d 失败: %v", conversationID, err)
+			ctx.Abort()
+			return
+		}
+		ctx.Writer.Header().Del("Content-Disposition")
+		respondError(ctx, err)
+	}
+}
+
+// GetReceipts 批量查询消息的送达、已读统计
+// GET /api/v1/conversations/:id/receipts?message_ids=1,2,3
+func (h *ConversationHandler) GetReceipts(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var messageIDs []uint
+	for _, part := range strings.Split(ctx.Query("message_ids"), ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			utils.Fail(ctx, http.StatusBadRequest, "无效的message_ids参数")
+			return
+		}
+		messageIDs = append(messageIDs, uint(id))
+	}
+
+	receipts, err := h.messageService.GetReceipts(conversationID, middleware.GetUserID(ctx), messageIDs)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, receipts)
+}
+
+// GetReaders 查看消息的已读、未读成员
+// GET /api/v1/conversations/:id/messages/:message_id/readers
+func (h *ConversationHandler) GetReaders(ctx *gin.Context) {
+	conversationID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	messageID, ok := parseIDParam(ctx, "message_id")
+	if !ok {
+		return
+	}
+
+	readers, err := h.messageService.GetReaders(conversationID, messageID, middleware.GetUserID(ctx))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, readers)
+}
+
+// ListMentions 查看提及我的消息
+// GET /api/v1/mentions
+func (h *ConversationHandler) ListMentions(ctx *gin.Context) {
+	page, pageSize := getPagination(ctx)
+	items, total, err := h.messageService.ListMentions(middleware.GetUserID(ctx), page, pageSize)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, utils.PageResult{List: items, Total: total, Page: page, PageSize: pageSize})
+}
+
+// parseMessageQuery 解析消息游标参数
+func parseMessageQuery(ctx *gin.Context) (*service.MessageQuery, bool) {
+	query := &service.MessageQuery{}
+	for name, target := range map[string]*uint{"before_id": &query.BeforeID, "after_id": &query.AfterID} {
+		value := ctx.Query(name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.Fail(ctx, http.StatusBadRequest, "无效的"+name+"参数")
+			return nil, false
+		}
+		*target = uint(id)
+	}
+	if query.BeforeID > 0 && query.AfterID > 0 {
+		utils.Fail(ctx, http.StatusBadRequest, "before_id与after_id不能同时使用")
+		return nil, false
+	}
+	query.Limit, _ = strconv.Atoi(ctx.Query("limit"))
+	return query, true
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailHandler 邮件管理接口处理器
+type EmailHandler struct {
+	mailer *service.Mailer
+}
+
+// NewEmailHandler 创建邮件管理接口处理器
+func NewEmailHandler(mailer *service.Mailer) *EmailHandler {
+	return &EmailHandler{mailer: mailer}
+}
+
+// testEmailBody 测试邮件请求体
+type testEmailBody struct {
+	To string `json:"to" binding:"required,email"`
+}
+
+// SendTestEmail 管理员发送测试邮件
+// POST /api/v1/admin/email/test
+func (h *EmailHandler) SendTestEmail(ctx *gin.Context) {
+	var body testEmailBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	result, err := h.mailer.SendTest(middleware.GetUserID(ctx), body.To)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, result)
+}
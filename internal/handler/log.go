@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogHandler 操作日志、系统日志和安全日志的查询与导出接口处理器
+type LogHandler struct {
+	logQueryService *service.LogQueryService
+}
+
+// NewLogHandler 创建日志查询接口处理器
+func NewLogHandler(logQueryService *service.LogQueryService) *LogHandler {
+	return &LogHandler{logQueryService: logQueryService}
+}
+
+// ListLogs 按条件查询日志，kind为operation、system或security，before_id为上一页返回的next_cursor
+// GET /api/v1/admin/logs/:kind
+func (h *LogHandler) ListLogs(ctx *gin.Context) {
+	filter, ok := parseLogFilter(ctx)
+	if !ok {
+		return
+	}
+	query := &service.LogQuery{LogFilter: *filter}
+	if value := ctx.Query("before_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.Fail(ctx, http.StatusBadRequest, "无效的before_id参数")
+			return
+		}
+		query.BeforeID = uint(id)
+	}
+	query.Limit, _ = strconv.Atoi(ctx.Query("limit"))
+
+	page, err := h.logQueryService.ListLogs(ctx.Request.Context(), ctx.Param("kind"), query)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, page)
+}
+
+// LogStats 统计指定时间范围内的日志数量，按操作和按天分组
+// GET /api/v1/admin/logs/:kind/stats
+func (h *LogHandler) LogStats(ctx *gin.Context) {
+	filter, ok := parseLogFilter(ctx)
+	if !ok {
+		return
+	}
+
+	stats, err := h.logQueryService.LogStats(ctx.Request.Context(), ctx.Param("kind"), filter)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, stats)
+}
+
+// ExportLogs 流式导出日志，format为csv或ndjson
+// GET /api/v1/admin/logs/:kind/export
+func (h *LogHandler) ExportLogs(ctx *gin.Context) {
+	filter, ok := parseLogFilter(ctx)
+	if !ok {
+		return
+	}
+	format := ctx.DefaultQuery("format", service.LogExportCSV)
+	contentType := "text/csv; charset=utf-8"
+	if format == service.LogExportNDJSON {
+		contentType = "application/x-ndjson; charset=utf-8"
+	}
+	kind := ctx.Param("kind")
+
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s_logs_%s.%s"`,
+		kind, time.Now().Format("20060102150405"), format))
+	err := h.logQueryService.ExportLogs(ctx.Request.Context(), ctx.Writer, kind, filter, format)
+	if err != nil {
+		if ctx.Writer.Written() {
+			// 已开始写出时无法再返回错误响应，只能中断连接
+			log.Printf("导出%s日志失败: %v", kind, err)
+			ctx.Abort()
+			return
+		}
+		ctx.Writer.Header().Del("Content-Disposition")
+		respondError(ctx, err)
+	}
+}
+
+// parseLogFilter 解析日志过滤参数，时间支持RFC3339或日期格式，日期格式的结束时间包含当天
+func parseLogFilter(ctx *gin.Context) (*service.LogFilter, bool) {
+	filter := &service.LogFilter{
+		Type:         ctx.Query("type"),
+		Action:       ctx.Query("action"),
+		Level:        ctx.Query("level"),
+		Module:       ctx.Query("module"),
+		IP:           ctx.Query("ip"),
+		ResourceType: ctx.Query("resource_type"),
+	}
+	for name, target := range map[string]**uint{"user_id": &filter.UserID, "resource_id": &filter.ResourceID} {
+		value := ctx.Query(name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			utils.Fail(ctx, http.StatusBadRequest, "无效的"+name+"参数")
+			return nil, false
+		}
+		parsed := uint(id)
+		*target = &parsed
+	}
+	for name, target := range map[string]**time.Time{"start_time": &filter.StartTime, "end_time": &filter.EndTime} {
+		value := ctx.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseLogTime(value, name == "end_time")
+		if err != nil {
+			utils.Fail(ctx, http.StatusBadRequest, "无效的"+name+"参数")
+			return nil, false
+		}
+		*target = &parsed
+	}
+	if filter.StartTime != nil && filter.EndTime != nil && !filter.EndTime.After(*filter.StartTime) {
+		utils.Fail(ctx, http.StatusBadRequest, "end_time必须晚于start_time")
+		return nil, false
+	}
+	return filter, true
+}
+
+// parseLogTime 解析时间参数，日期格式作为结束时间时取次日零点
+func parseLogTime(value string, isEnd bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if isEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler 通知中心接口处理器
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+// NewNotificationHandler 创建通知中心接口处理器
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// ListNotifications 获取通知列表
+// GET /api/v1/notifications
+func (h *NotificationHandler) ListNotifications(ctx *gin.Context) {
+	page, pageSize := getPagination(ctx)
+	items, total, err := h.notificationService.ListNotifications(middleware.GetUserID(ctx),
+		ctx.Query("category"), page, pageSize)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, utils.PageResult{List: items, Total: total, Page: page, PageSize: pageSize})
+}
+
+// GetUnreadBadge 获取未读通知数
+// GET /api/v1/notifications/unread-count
+func (h *NotificationHandler) GetUnreadBadge(ctx *gin.Context) {
+	badge, err := h.notificationService.GetUnreadBadge(middleware.GetUserID(ctx))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, badge)
+}
+
+// MarkAllRead 全部标记为已读
+// POST /api/v1/notifications/read-all
+func (h *NotificationHandler) MarkAllRead(ctx *gin.Context) {
+	if err := h.notificationService.MarkAllRead(middleware.GetUserID(ctx)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// ListPreferences 获取通知偏好
+// GET /api/v1/notifications/preferences
+func (h *NotificationHandler) ListPreferences(ctx *gin.Context) {
+	preferences, err := h.notificationService.ListPreferences(middleware.GetUserID(ctx))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, preferences)
+}
+
+// UpdatePreference 更新某一分类的通知偏好
+// PUT /api/v1/notifications/preferences/:category
+func (h *NotificationHandler) UpdatePreference(ctx *gin.Context) {
+	var req service.UpdatePreferenceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	preference, err := h.notificationService.UpdatePreference(middleware.GetUserID(ctx), ctx.Param("category"), &req)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, preference)
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OperationLogHandler 操作日志接口处理器
+type OperationLogHandler struct {
+	logger *service.OperationLogger
+}
+
+// NewOperationLogHandler 创建操作日志接口处理器
+func NewOperationLogHandler(logger *service.OperationLogger) *OperationLogHandler {
+	return &OperationLogHandler{logger: logger}
+}
+
+// PipelineStats 获取操作日志写入管道的缓冲、丢弃和写入指标
+// GET /api/v1/admin/operation-logs/pipeline
+func (h *OperationLogHandler) PipelineStats(ctx *gin.Context) {
+	utils.Success(ctx, h.logger.Stats())
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http"
+
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionTemplateHandler 权限模板接口处理器
+type PermissionTemplateHandler struct {
+	templateService *service.PermissionTemplateService
+}
+
+// NewPermissionTemplateHandler 创建权限模板接口处理器
+func NewPermissionTemplateHandler(templateService *service.PermissionTemplateService) *PermissionTemplateHandler {
+	return &PermissionTemplateHandler{templateService: templateService}
+}
+
+// assignTemplateBody 批量分配模板请求体
+type assignTemplateBody struct {
+	UserIDs []uint `json:"user_ids" binding:"required,min=1,max=500"`
+}
+
+// ListTemplates 获取权限模板列表
+// GET /api/v1/admin/permission-templates
+func (h *PermissionTemplateHandler) ListTemplates(ctx *gin.Context) {
+	templates, err := h.templateService.ListTemplates(middleware.GetUserID(ctx))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, templates)
+}
+
+// CreateTemplate 创建权限模板
+// POST /api/v1/admin/permission-templates
+func (h *PermissionTemplateHandler) CreateTemplate(ctx *gin.Context) {
+	var req service.SaveTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	template, err := h.templateService.CreateTemplate(middleware.GetUserID(ctx), &req, ctx.ClientIP())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, template)
+}
+
+// UpdateTemplate 编辑权限模板并同步到使用该模板的用户
+// PUT /api/v1/admin/permission-templates/:id
+func (h *PermissionTemplateHandler) UpdateTemplate(ctx *gin.Context) {
+	templateID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var req service.SaveTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	result, err := h.templateService.UpdateTemplate(middleware.GetUserID(ctx), templateID, &req, ctx.ClientIP())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, result)
+}
+
+// AssignTemplate 批量为用户分配权限模板
+// POST /api/v1/admin/permission-templates/:id/assign
+func (h *PermissionTemplateHandler) AssignTemplate(ctx *gin.Context) {
+	templateID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var body assignTemplateBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	result, err := h.templateService.AssignTemplate(middleware.GetUserID(ctx), templateID, body.UserIDs, ctx.ClientIP())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, result)
+}
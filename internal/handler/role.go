@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleHandler 角色接口处理器
+type RoleHandler struct {
+	roleService *service.RoleService
+}
+
+// NewRoleHandler 创建角色接口处理器
+func NewRoleHandler(roleService *service.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// ListRoles 获取角色列表
+// GET /api/v1/roles
+func (h *RoleHandler) ListRoles(ctx *gin.Context) {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, roles)
+}
+
+// ListAssignments 获取用户生效的角色，未指定user_id时查看自己
+// GET /api/v1/roles/assignments
+func (h *RoleHandler) ListAssignments(ctx *gin.Context) {
+	operatorID := middleware.GetUserID(ctx)
+	userID := operatorID
+	if raw := ctx.Query("user_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || id == 0 {
+			utils.Fail(ctx, http.StatusBadRequest, "无效的user_id参数")
+			return
+		}
+		userID = uint(id)
+	}
+
+	assignments, err := h.roleService.ListUserRoles(operatorID, userID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, assignments)
+}
+
+// AssignRole 在指定范围内授予角色
+// POST /api/v1/roles/assignments
+func (h *RoleHandler) AssignRole(ctx *gin.Context) {
+	var req service.AssignRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	assignment, err := h.roleService.AssignRole(middleware.GetUserID(ctx), &req, ctx.ClientIP())
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, assignment)
+}
+
+// RevokeRole 撤销角色授予
+// DELETE /api/v1/roles/assignments/:id
+func (h *RoleHandler) RevokeRole(ctx *gin.Context) {
+	assignmentID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.roleService.RevokeRole(middleware.GetUserID(ctx), assignmentID, ctx.ClientIP()); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"sort"
+
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteInventoryHandler 路由清单接口处理器
+type RouteInventoryHandler struct {
+	routes func() gin.RoutesInfo
+	guard  *middleware.PermissionGuard
+}
+
+// NewRouteInventoryHandler 创建路由清单接口处理器，routes在请求时调用以包含全部已注册路由
+func NewRouteInventoryHandler(routes func() gin.RoutesInfo, guard *middleware.PermissionGuard) *RouteInventoryHandler {
+	return &RouteInventoryHandler{routes: routes, guard: guard}
+}
+
+// routeItem 路由清单项，required_permission为空表示没有声明系统权限
+type routeItem struct {
+	Method             string                         `json:"method"`
+	Path               string                         `json:"path"`
+	Handler            string                         `json:"handler"`
+	RequiredPermission *middleware.RequiredPermission `json:"required_permission"`
+}
+
+// ListRoutes 列出全部路由及其要求的权限
+// GET /api/v1/admin/routes
+func (h *RouteInventoryHandler) ListRoutes(ctx *gin.Context) {
+	routes := h.routes()
+	items := make([]routeItem, 0, len(routes))
+	for _, route := range routes {
+		items = append(items, routeItem{
+			Method:             route.Method,
+			Path:               route.Path,
+			Handler:            route.Handler,
+			RequiredPermission: h.guard.Lookup(route.Method, route.Path),
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Path != items[j].Path {
+			return items[i].Path < items[j].Path
+		}
+		return items[i].Method < items[j].Method
+	})
+	utils.Success(ctx, items)
+}
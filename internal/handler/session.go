@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler 登录会话接口处理器
+type SessionHandler struct {
+	authService *service.AuthService
+}
+
+// NewSessionHandler 创建登录会话接口处理器
+func NewSessionHandler(authService *service.AuthService) *SessionHandler {
+	return &SessionHandler{authService: authService}
+}
+
+// ListSessions 获取当前用户的登录会话
+// GET /api/v1/sessions
+func (h *SessionHandler) ListSessions(ctx *gin.Context) {
+	sessions, err := h.authService.ListSessions(middleware.GetUserID(ctx), middleware.GetSessionID(ctx))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, sessions)
+}
+
+// RevokeSession 注销指定的登录会话
+// DELETE /api/v1/sessions/:id
+func (h *SessionHandler) RevokeSession(ctx *gin.Context) {
+	sessionID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.authService.RevokeSession(middleware.GetUserID(ctx), sessionID); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// LogoutAll 退出全部设备
+// POST /api/v1/sessions/logout-all
+func (h *SessionHandler) LogoutAll(ctx *gin.Context) {
+	if err := h.authService.LogoutAll(middleware.GetUserID(ctx), ctx.ClientIP()); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// ForceLogout 管理员强制用户下线
+// POST /api/v1/admin/users/:user_id/logout
+func (h *SessionHandler) ForceLogout(ctx *gin.Context) {
+	userID, ok := parseIDParam(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := h.authService.ForceLogout(middleware.GetUserID(ctx), userID, ctx.ClientIP()); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/service"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TeamHandler 团队接口处理器
+type TeamHandler struct {
+	teamService *service.TeamService
+}
+
+// NewTeamHandler 创建团队接口处理器
+func NewTeamHandler(teamService *service.TeamService) *TeamHandler {
+	return &TeamHandler{teamService: teamService}
+}
+
+// joinRequestBody 入队申请请求体
+type joinRequestBody struct {
+	Message string `json:"message" binding:"max=500"`
+}
+
+// reviewJoinRequestBody 入队审批请求体
+type reviewJoinRequestBody struct {
+	Action string `json:"action" binding:"required,oneof=approve reject"`
+	Note   string `json:"note" binding:"max=500"`
+}
+
+// autoApproveRuleBody 自动审批规则请求体
+type autoApproveRuleBody struct {
+	RuleType  string `json:"rule_type" binding:"required"`
+	RuleValue string `json:"rule_value" binding:"required,max=255"`
+}
+
+// SearchPublicTeams 搜索公开团队
+// GET /api/v1/teams/public
+func (h *TeamHandler) SearchPublicTeams(ctx *gin.Context) {
+	page, pageSize := getPagination(ctx)
+	teams, total, err := h.teamService.SearchPublicTeams(service.PublicTeamQuery{
+		Keyword:  ctx.Query("keyword"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, utils.PageResult{List: teams, Total: total, Page: page, PageSize: pageSize})
+}
+
+// CreateJoinRequest 申请加入团队
+// POST /api/v1/teams/:id/join-requests
+func (h *TeamHandler) CreateJoinRequest(ctx *gin.Context) {
+	teamID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var body joinRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	request, err := h.teamService.CreateJoinRequest(teamID, middleware.GetUserID(ctx), body.Message)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, request)
+}
+
+// ListJoinRequests 查看入队申请
+// GET /api/v1/teams/:id/join-requests
+func (h *TeamHandler) ListJoinRequests(ctx *gin.Context) {
+	teamID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	requests, err := h.teamService.ListJoinRequests(teamID, middleware.GetUserID(ctx), ctx.Query("status"))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, requests)
+}
+
+// ReviewJoinRequest 审批入队申请
+// PUT /api/v1/teams/:id/join-requests/:request_id
+func (h *TeamHandler) ReviewJoinRequest(ctx *gin.Context) {
+	teamID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	requestID, ok := parseIDParam(ctx, "request_id")
+	if !ok {
+		return
+	}
+	var body reviewJoinRequestBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	request, err := h.teamService.ReviewJoinRequest(teamID, requestID, middleware.GetUserID(ctx),
+		body.Action == "approve", body.Note)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, request)
+}
+
+// CancelJoinRequest 撤回入队申请
+// DELETE /api/v1/teams/:id/join-requests/:request_id
+func (h *TeamHandler) CancelJoinRequest(ctx *gin.Context) {
+	teamID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	requestID, ok := parseIDParam(ctx, "request_id")
+	if !ok {
+		return
+	}
+
+	if err := h.teamService.CancelJoinRequest(teamID, requestID, middleware.GetUserID(ctx)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// ListAutoApproveRules 查看自动审批规则
+// GET /api/v1/teams/:id/auto-approve-rules
+func (h *TeamHandler) ListAutoApproveRules(ctx *gin.Context) {
+	teamID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	rules, err := h.teamService.ListAutoApproveRules(teamID, middleware.GetUserID(ctx))
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, rules)
+}
+
+// CreateAutoApproveRule 新增自动审批规则
+// POST /api/v1/teams/:id/auto-approve-rules
+func (h *TeamHandler) CreateAutoApproveRule(ctx *gin.Context) {
+	teamID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	var body autoApproveRuleBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	rule, err := h.teamService.CreateAutoApproveRule(teamID, middleware.GetUserID(ctx), body.RuleType, body.RuleValue)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, rule)
+}
+
+// DeleteAutoApproveRule 删除自动审批规则
+// DELETE /api/v1/teams/:id/auto-approve-rules/:rule_id
+func (h *TeamHandler) DeleteAutoApproveRule(ctx *gin.Context) {
+	teamID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	ruleID, ok := parseIDParam(ctx, "rule_id")
+	if !ok {
+		return
+	}
+
+	if err := h.teamService.DeleteAutoApproveRule(teamID, ruleID, middleware.GetUserID(ctx)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// GetStorageReport 获取团队存储报表
+// GET /api/v1/teams/:id/storage/report
+func (h *TeamHandler) GetStorageReport(ctx *gin.Context) {
+	teamID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	days, err := strconv.Atoi(ctx.DefaultQuery("days", "30"))
+	if err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "无效的days参数")
+		return
+	}
+
+	report, err := h.teamService.GetStorageReport(teamID, middleware.GetUserID(ctx), days)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, report)
+}
+
+// CreateTeam 创建团队
+// POST /api/v1/teams
+func (h *TeamHandler) CreateTeam(ctx *gin.Context) {
+	var req service.CreateTeamRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.Fail(ctx, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	team, err := h.teamService.CreateTeam(middleware.GetUserID(ctx), &req)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, team)
+}
+
+// LeaveTeam 退出团队
+// DELETE /api/v1/teams/:id/members/me
+func (h *TeamHandler) LeaveTeam(ctx *gin.Context) {
+	teamID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.teamService.LeaveTeam(teamID, middleware.GetUserID(ctx)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// RemoveTeamMember 移除团队成员
+// DELETE /api/v1/teams/:id/members/:user_id
+func (h *TeamHandler) RemoveTeamMember(ctx *gin.Context) {
+	teamID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+	userID, ok := parseIDParam(ctx, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := h.teamService.RemoveTeamMember(teamID, middleware.GetUserID(ctx), userID); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
+
+// ArchiveTeam 归档团队
+// POST /api/v1/teams/:id/archive
+func (h *TeamHandler) ArchiveTeam(ctx *gin.Context) {
+	teamID, ok := parseIDParam(ctx, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.teamService.ArchiveTeam(teamID, middleware.GetUserID(ctx)); err != nil {
+		respondError(ctx, err)
+		return
+	}
+	utils.Success(ctx, nil)
+}
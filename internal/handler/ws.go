@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/realtime"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// WSHandler WebSocket接口处理器
+type WSHandler struct {
+	hub      *realtime.Hub
+	upgrader websocket.Upgrader
+}
+
+// NewWSHandler 创建WebSocket接口处理器
+func NewWSHandler(hub *realtime.Hub) *WSHandler {
+	return &WSHandler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     checkOrigin,
+		},
+	}
+}
+
+// Connect 建立即时通讯WebSocket连接
+// GET /api/v1/ws?token=xxx&last_message_id=123
+func (h *WSHandler) Connect(ctx *gin.Context) {
+	var lastMessageID uint64
+	if raw := ctx.Query("last_message_id"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			utils.Fail(ctx, http.StatusBadRequest, "无效的last_message_id参数")
+			return
+		}
+		lastMessageID = parsed
+	}
+
+	conn, err := h.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		log.Printf("WebSocket升级失败: %v", err)
+		return
+	}
+
+	client := realtime.NewClient(h.hub, conn, middleware.GetUserID(ctx))
+	go client.Serve(uint(lastMessageID))
+}
+
+// checkOrigin 校验WebSocket请求来源是否在CORS白名单中
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	config := utils.GetConfig()
+	if origin == "" || config == nil {
+		return true
+	}
+	for _, allowed := range config.CORS.AllowOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+// Package mail 实现基于SMTP的邮件发送
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTimeout 默认的SMTP连接超时时间
+const defaultTimeout = 10 * time.Second
+
+// ErrNotConfigured 未配置SMTP服务器
+var ErrNotConfigured = errors.New("未配置SMTP服务器")
+
+// Config SMTP服务器配置
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	FromName string
+	Timeout  time.Duration
+}
+
+// Message 邮件内容，HTML为空时只发送纯文本
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// IsConfigured 检查配置是否可用于发信
+func (c *Config) IsConfigured() bool {
+	return c.Host != "" && c.Port > 0 && c.From != ""
+}
+
+// Send 通过SMTP发送邮件，服务器支持时使用STARTTLS
+func Send(config Config, message *Message) error {
+	if !config.IsConfigured() {
+		return ErrNotConfigured
+	}
+	if len(message.To) == 0 {
+		return errors.New("收件人不能为空")
+	}
+	data, err := message.build(config)
+	if err != nil {
+		return err
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return fmt.Errorf("设置SMTP超时失败: %w", err)
+	}
+
+	client, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("建立SMTP会话失败: %w", err)
+	}
+	defer client.Close()
+
+	if err := prepare(client, config); err != nil {
+		return err
+	}
+	if err := deliver(client, config.From, message.To, data); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// prepare 协商TLS并完成认证
+func prepare(client *smtp.Client, config Config) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: config.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("启用STARTTLS失败: %w", err)
+		}
+	}
+	if config.Username == "" {
+		return nil
+	}
+	if ok, _ := client.Extension("AUTH"); !ok {
+		return errors.New("SMTP服务器不支持认证")
+	}
+	if err := client.Auth(smtp.PlainAuth("", config.Username, config.Password, config.Host)); err != nil {
+		return fmt.Errorf("SMTP认证失败: %w", err)
+	}
+	return nil
+}
+
+// deliver 发送信封和邮件正文
+func deliver(client *smtp.Client, from string, to []string, data []byte) error {
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("设置发件人失败: %w", err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("设置收件人 %s 失败: %w", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("发送邮件内容失败: %w", err)
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return fmt.Errorf("发送邮件内容失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("发送邮件内容失败: %w", err)
+	}
+	return nil
+}
+
+// build 生成MIME格式的邮件内容，同时包含HTML时使用multipart/alternative
+func (m *Message) build(config Config) ([]byte, error) {
+	var buf bytes.Buffer
+	from := config.From
+	if config.FromName != "" {
+		from = fmt.Sprintf("%s <%s>", mime.BEncoding.Encode("UTF-8", config.FromName), config.From)
+	}
+	writeHeader(&buf, "From", from)
+	writeHeader(&buf, "To", strings.Join(m.To, ", "))
+	writeHeader(&buf, "Subject", mime.BEncoding.Encode("UTF-8", m.Subject))
+	writeHeader(&buf, "Date", time.Now().Format(time.RFC1123Z))
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	if m.HTML == "" {
+		writeHeader(&buf, "Content-Type", "text/plain; charset=UTF-8")
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuoted(&buf, m.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	boundary, err := newBoundary()
+	if err != nil {
+		return nil, err
+	}
+	writeHeader(&buf, "Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", boundary))
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", m.Text},
+		{"text/html; charset=UTF-8", m.HTML},
+	} {
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		writeHeader(&buf, "Content-Type", part.contentType)
+		writeHeader(&buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuoted(&buf, part.body); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes(), nil
+}
+
+// writeHeader 写入一行邮件头
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
+
+// writeQuoted 以quoted-printable编码写入正文
+func writeQuoted(buf *bytes.Buffer, body string) error {
+	writer := quotedprintable.NewWriter(buf)
+	if _, err := writer.Write([]byte(body)); err != nil {
+		return fmt.Errorf("编码邮件正文失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("编码邮件正文失败: %w", err)
+	}
+	return nil
+}
+
+// newBoundary 生成随机的multipart分隔符
+func newBoundary() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成邮件分隔符失败: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+
+	"ycg_cloud/internal/mail/mailtest"
+)
+
+// TestSend 测试通过本地SMTP替身发送邮件
+func TestSend(t *testing.T) {
+	server, err := mailtest.NewServer()
+	if err != nil {
+		t.Fatalf("启动SMTP替身失败: %v", err)
+	}
+	defer server.Close()
+
+	config := Config{
+		Host:     server.Host(),
+		Port:     server.Port(),
+		Username: "noreply",
+		Password: "secret",
+		From:     "noreply@example.com",
+		FromName: "云存储",
+	}
+	err = Send(config, &Message{
+		To:      []string{"alice@example.com"},
+		Subject: "存储空间提醒",
+		Text:    "你的存储空间已使用90%",
+		HTML:    "<p>你的存储空间已使用90%</p>",
+	})
+	if err != nil {
+		t.Fatalf("发送邮件失败: %v", err)
+	}
+
+	mails := server.Mails()
+	if len(mails) != 1 {
+		t.Fatalf("期望收到1封邮件, 实际 %d", len(mails))
+	}
+	mail := mails[0]
+	if mail.From != config.From || len(mail.To) != 1 || mail.To[0] != "alice@example.com" {
+		t.Errorf("信封不正确: %+v", mail)
+	}
+	for _, want := range []string{"multipart/alternative", "text/plain", "text/html", "Subject: =?UTF-8?b?"} {
+		if !strings.Contains(mail.Data, want) {
+			t.Errorf("邮件内容缺少 %q", want)
+		}
+	}
+}
+
+// TestSendNotConfigured 测试未配置服务器时拒绝发送
+func TestSendNotConfigured(t *testing.T) {
+	err := Send(Config{}, &Message{To: []string{"alice@example.com"}})
+	if err != ErrNotConfigured {
+		t.Errorf("期望 ErrNotConfigured, 实际 %v", err)
+	}
+}
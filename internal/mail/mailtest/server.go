@@ -0,0 +1,167 @@
+// Package mailtest 提供用于测试的本地SMTP服务器，接收的邮件只保存在内存中
+package mailtest
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Mail 服务器收到的一封邮件
+type Mail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// Server 本地SMTP替身服务器，支持AUTH PLAIN但不校验凭据
+type Server struct {
+	listener net.Listener
+	mu       sync.Mutex
+	mails    []Mail
+	wg       sync.WaitGroup
+}
+
+// NewServer 在随机端口启动SMTP替身服务器
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{listener: listener}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Host 服务器监听地址
+func (s *Server) Host() string {
+	host, _, _ := net.SplitHostPort(s.listener.Addr().String())
+	return host
+}
+
+// Port 服务器监听端口
+func (s *Server) Port() int {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	value, _ := strconv.Atoi(port)
+	return value
+}
+
+// Mails 返回已收到的邮件
+func (s *Server) Mails() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mail(nil), s.mails...)
+}
+
+// Close 关闭服务器并等待连接处理结束
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// serve 接受连接
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle 处理一个SMTP会话
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) bool {
+		_, err := conn.Write([]byte(line + "\r\n"))
+		return err == nil
+	}
+
+	var current Mail
+	reply("220 mailtest ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		var ok bool
+		switch verb {
+		case "EHLO":
+			ok = reply("250-mailtest") && reply("250 AUTH PLAIN")
+		case "HELO", "NOOP":
+			ok = reply("250 OK")
+		case "AUTH":
+			ok = reply("235 Authentication successful")
+		case "MAIL":
+			current = Mail{From: addressOf(line)}
+			ok = reply("250 OK")
+		case "RCPT":
+			current.To = append(current.To, addressOf(line))
+			ok = reply("250 OK")
+		case "DATA":
+			if !reply("354 End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			data, err := readData(reader)
+			if err != nil {
+				return
+			}
+			current.Data = data
+			s.mu.Lock()
+			s.mails = append(s.mails, current)
+			s.mu.Unlock()
+			current = Mail{}
+			ok = reply("250 OK")
+		case "RSET":
+			current = Mail{}
+			ok = reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			ok = reply("502 Command not implemented")
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// readData 读取DATA阶段的内容直到单独的"."行
+func readData(reader *bufio.Reader) (string, error) {
+	var builder strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		if line == ".\r\n" {
+			return builder.String(), nil
+		}
+		builder.WriteString(strings.TrimPrefix(line, "."))
+	}
+}
+
+// addressOf 提取MAIL FROM/RCPT TO中的邮箱地址
+func addressOf(line string) string {
+	start := strings.Index(line, "<")
+	end := strings.LastIndex(line, ">")
+	if start < 0 || end <= start {
+		return ""
+	}
+	return line[start+1 : end]
+}
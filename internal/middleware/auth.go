@@ -0,0 +1,131 @@
+// Package middleware 提供Gin中间件
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 上下文键
+const (
+	ContextUserID   = "user_id"
+	ContextUsername = "username"
+	ContextUserType = "user_type"
+	ContextClaims   = "claims"
+
+	// HeaderImpersonator 模拟登录时返回的响应头，前端据此展示模拟登录横幅
+	HeaderImpersonator = "X-Impersonator-ID"
+)
+
+// impersonationForbiddenMessage 模拟登录令牌访问受限接口时的提示
+const impersonationForbiddenMessage = "模拟登录状态下不能执行该操作"
+
+// TokenValidator 令牌的附加校验，例如令牌版本是否已失效
+type TokenValidator func(claims *utils.Claims) error
+
+// JWTAuth JWT认证中间件，只接受Authorization请求头中的令牌，签名校验通过后依次执行validators
+func JWTAuth(validators ...TokenValidator) gin.HandlerFunc {
+	return jwtAuth(false, validators)
+}
+
+// WebSocketAuth WebSocket连接的认证中间件
+// 浏览器建立WebSocket连接时无法设置请求头，因此额外接受查询参数token，其他路由不能使用
+func WebSocketAuth(validators ...TokenValidator) gin.HandlerFunc {
+	return jwtAuth(true, validators)
+}
+
+// jwtAuth 校验令牌并把用户信息写入上下文，allowQuery表示是否接受查询参数中的令牌
+func jwtAuth(allowQuery bool, validators []TokenValidator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tokenString := extractToken(ctx, allowQuery)
+		if tokenString == "" {
+			utils.AbortWithError(ctx, http.StatusUnauthorized, "缺少认证令牌")
+			return
+		}
+
+		claims, err := utils.ParseToken(tokenString)
+		if err != nil {
+			utils.AbortWithError(ctx, http.StatusUnauthorized, "认证令牌无效或已过期")
+			return
+		}
+		for _, validate := range validators {
+			if err := validate(claims); err != nil {
+				utils.AbortWithError(ctx, http.StatusUnauthorized, "认证令牌已失效，请重新登录")
+				return
+			}
+		}
+
+		ctx.Set(ContextUserID, claims.UserID)
+		ctx.Set(ContextUsername, claims.Username)
+		ctx.Set(ContextUserType, claims.UserType)
+		ctx.Set(ContextClaims, claims)
+		if claims.ImpersonatorID != 0 {
+			ctx.Header(HeaderImpersonator, strconv.FormatUint(uint64(claims.ImpersonatorID), 10))
+		}
+		ctx.Next()
+	}
+}
+
+// extractToken 从请求头中提取令牌，allowQuery为true时请求头缺失再读取查询参数
+func extractToken(ctx *gin.Context, allowQuery bool) string {
+	header := ctx.GetHeader("Authorization")
+	if strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	if !allowQuery {
+		return ""
+	}
+	return ctx.Query("token")
+}
+
+// GetUserID 获取当前登录用户ID
+func GetUserID(ctx *gin.Context) uint {
+	return ctx.GetUint(ContextUserID)
+}
+
+// GetUsername 获取当前登录用户名
+func GetUsername(ctx *gin.Context) string {
+	return ctx.GetString(ContextUsername)
+}
+
+// GetSessionID 获取当前请求所属的登录会话ID
+func GetSessionID(ctx *gin.Context) uint {
+	if claims := GetClaims(ctx); claims != nil {
+		return claims.SessionID
+	}
+	return 0
+}
+
+// GetImpersonatorID 获取模拟登录的发起人ID，不是模拟登录时返回0
+func GetImpersonatorID(ctx *gin.Context) uint {
+	if claims := GetClaims(ctx); claims != nil {
+		return claims.ImpersonatorID
+	}
+	return 0
+}
+
+// RejectImpersonation 拒绝模拟登录令牌，用于管理登录会话等只能由用户本人执行的操作
+func RejectImpersonation() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if GetImpersonatorID(ctx) != 0 {
+			utils.AbortWithError(ctx, http.StatusForbidden, impersonationForbiddenMessage)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// GetClaims 获取当前请求的令牌声明
+func GetClaims(ctx *gin.Context) *utils.Claims {
+	value, exists := ctx.Get(ContextClaims)
+	if !exists {
+		return nil
+	}
+	claims, _ := value.(*utils.Claims)
+	return claims
+}
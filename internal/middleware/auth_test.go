@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestExtractToken 测试只有WebSocket认证接受查询参数中的令牌
+func TestExtractToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		header     string
+		allowQuery bool
+		want       string
+	}{
+		{"请求头", "Bearer header-token", false, "header-token"},
+		{"请求头优先", "Bearer header-token", true, "header-token"},
+		{"普通路由忽略查询参数", "", false, ""},
+		{"WebSocket读取查询参数", "", true, "query-token"},
+	}
+	for _, tt := range tests {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/api/v1/ws?token=query-token", nil)
+		if tt.header != "" {
+			ctx.Request.Header.Set("Authorization", tt.header)
+		}
+		if got := extractToken(ctx, tt.allowQuery); got != tt.want {
+			t.Errorf("%s: 令牌 = %q, 期望 %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestRejectImpersonation 测试模拟登录令牌不能访问会话管理和需要系统权限的接口
+func TestRejectImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	guard := NewPermissionGuard(func(uint, model.ResourceType, model.PermissionAction) (bool, error) {
+		return true, nil
+	})
+
+	router := gin.New()
+	router.Use(func(ctx *gin.Context) {
+		claims := &utils.Claims{UserID: 2}
+		if ctx.GetHeader("X-Impersonator") != "" {
+			claims.ImpersonatorID = 1
+		}
+		ctx.Set(ContextUserID, claims.UserID)
+		ctx.Set(ContextClaims, claims)
+	})
+	router.GET("/sessions", RejectImpersonation(), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	guard.Handle(&router.RouterGroup, http.MethodGet, "/admin",
+		RequiredPermission{ResourceType: model.ResourceTypeSystem, Action: model.PermissionUserManage},
+		func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	for _, path := range []string{"/sessions", "/admin"} {
+		for _, impersonated := range []bool{false, true} {
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			want := http.StatusOK
+			if impersonated {
+				req.Header.Set("X-Impersonator", "1")
+				want = http.StatusForbidden
+			}
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != want {
+				t.Errorf("%s 模拟登录=%v: 状态码 = %d, 期望 %d", path, impersonated, recorder.Code, want)
+			}
+		}
+	}
+}
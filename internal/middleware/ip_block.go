@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPBlockChecker 判断IP是否被封禁
+type IPBlockChecker func(ip string) (bool, error)
+
+// IPBlock 拒绝被封禁IP的请求，查询失败时放行，避免封禁状态不可用导致整个服务不可用
+func IPBlock(check IPBlockChecker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		blocked, err := check(ctx.ClientIP())
+		if err != nil {
+			log.Printf("检查IP %s 封禁状态失败: %v", ctx.ClientIP(), err)
+			ctx.Next()
+			return
+		}
+		if blocked {
+			utils.AbortWithError(ctx, http.StatusForbidden, "当前IP已被暂时封禁，请稍后再试")
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestIPBlock 测试封禁IP被拒绝，查询失败时放行
+func TestIPBlock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name  string
+		check IPBlockChecker
+		want  int
+	}{
+		{"未封禁", func(string) (bool, error) { return false, nil }, http.StatusOK},
+		{"已封禁", func(ip string) (bool, error) { return ip == "192.0.2.1", nil }, http.StatusForbidden},
+		{"查询失败", func(string) (bool, error) { return false, errors.New("db down") }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		router := gin.New()
+		router.Use(IPBlock(tt.check))
+		router.GET("/ping", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		router.ServeHTTP(recorder, req)
+		if recorder.Code != tt.want {
+			t.Errorf("%s: 状态码 = %d, 期望 %d", tt.name, recorder.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextOperation 当前请求的操作日志条目
+const ContextOperation = "operation_log"
+
+// 操作日志中请求信息的最大长度，与字段定义一致
+const (
+	maxLogURLLength       = 500
+	maxLogUserAgentLength = 500
+	maxLogRefererLength   = 500
+)
+
+// OperationRecorder 操作日志的异步写入接口，Record不能阻塞
+type OperationRecorder interface {
+	Record(entry *model.OperationLog) bool
+}
+
+// OperationLog 操作日志中间件
+// 处理器通过RecordOperation声明本次请求的操作后，请求结束时补充请求、设备、性能和结果信息并异步写入
+// 模拟登录状态下的请求即使没有声明操作也会记录
+func OperationLog(recorder OperationRecorder) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		entry := getOperation(ctx)
+		if entry == nil {
+			if GetImpersonatorID(ctx) == 0 {
+				return
+			}
+			entry = &model.OperationLog{
+				Type:   model.LogTypeSecurity,
+				Action: model.ActionImpersonated,
+				Title:  "模拟登录状态下的请求",
+			}
+		}
+		fillRequestInfo(ctx, entry, time.Since(start))
+		fillResult(ctx, entry)
+		recorder.Record(entry)
+	}
+}
+
+// RecordOperation 声明当前请求的操作日志，entry至少需要填写Action和Title
+// 处理器可以在返回前继续修改entry，例如登录成功后补充UserID
+func RecordOperation(ctx *gin.Context, entry *model.OperationLog) {
+	ctx.Set(ContextOperation, entry)
+}
+
+// getOperation 获取当前请求声明的操作日志
+func getOperation(ctx *gin.Context) *model.OperationLog {
+	value, exists := ctx.Get(ContextOperation)
+	if !exists {
+		return nil
+	}
+	entry, _ := value.(*model.OperationLog)
+	return entry
+}
+
+// fillRequestInfo 填写请求、用户、设备和性能信息
+func fillRequestInfo(ctx *gin.Context, entry *model.OperationLog, duration time.Duration) {
+	if entry.UserID == nil {
+		if userID := GetUserID(ctx); userID != 0 {
+			entry.UserID = &userID
+			entry.Username = GetUsername(ctx)
+		}
+	}
+	if entry.Type == "" {
+		entry.Type = model.LogTypeUser
+	}
+	if impersonatorID := GetImpersonatorID(ctx); impersonatorID != 0 {
+		entry.ImpersonatorID = &impersonatorID
+		entry.ImportantFlag = true
+	}
+
+	userAgent := ctx.Request.UserAgent()
+	device := utils.ParseUserAgent(userAgent)
+	entry.Method = ctx.Request.Method
+	entry.URL = truncate(ctx.Request.URL.RequestURI(), maxLogURLLength)
+	entry.IPAddress = ctx.ClientIP()
+	entry.UserAgent = truncate(userAgent, maxLogUserAgentLength)
+	entry.Referer = truncate(ctx.Request.Referer(), maxLogRefererLength)
+	entry.Device = device.Device
+	entry.OS = device.OS
+	entry.Browser = device.Browser
+
+	entry.Duration = duration.Milliseconds()
+	entry.RequestSize = max(ctx.Request.ContentLength, 0)
+	entry.ResponseSize = int64(max(ctx.Writer.Size(), 0))
+}
+
+// fillResult 根据响应状态填写操作结果，处理器已填写的状态保持不变
+func fillResult(ctx *gin.Context, entry *model.OperationLog) {
+	if entry.Status != "" {
+		return
+	}
+	status := ctx.Writer.Status()
+	if status < 400 {
+		entry.Status = "success"
+		return
+	}
+
+	entry.Status = "failed"
+	entry.ErrorCode = strconv.Itoa(status)
+	if entry.Level == "" {
+		entry.Level = model.LogLevelWarn
+	}
+	if last := ctx.Errors.Last(); last != nil && entry.ErrorMessage == "" {
+		entry.ErrorMessage = last.Error()
+	}
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit])
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recorderStub 记录投递的操作日志
+type recorderStub struct {
+	entries []*model.OperationLog
+}
+
+// Record 保存日志
+func (r *recorderStub) Record(entry *model.OperationLog) bool {
+	r.entries = append(r.entries, entry)
+	return true
+}
+
+// TestOperationLog 测试只记录声明了操作的请求，并补充请求信息和结果
+func TestOperationLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := &recorderStub{}
+	router := gin.New()
+	router.Use(OperationLog(recorder))
+	router.GET("/plain", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	router.POST("/login", func(ctx *gin.Context) {
+		RecordOperation(ctx, &model.OperationLog{Action: model.ActionLogin, Title: "用户登录"})
+		_ = ctx.Error(errors.New("用户名或密码错误"))
+		ctx.String(http.StatusUnauthorized, "denied")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/plain", nil))
+	req := httptest.NewRequest(http.MethodPost, "/login?from=web", strings.NewReader(`{"account":"alice"}`))
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0 Safari/537.36")
+	req.Header.Set("Referer", "https://example.com/login")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(recorder.entries) != 1 {
+		t.Fatalf("期望记录 1 条日志, 实际 %d", len(recorder.entries))
+	}
+	entry := recorder.entries[0]
+	if entry.Method != http.MethodPost || entry.URL != "/login?from=web" || entry.Referer != "https://example.com/login" {
+		t.Errorf("请求信息不正确: %+v", entry)
+	}
+	if entry.RequestSize != int64(len(`{"account":"alice"}`)) || entry.ResponseSize != int64(len("denied")) {
+		t.Errorf("请求或响应大小不正确: %d, %d", entry.RequestSize, entry.ResponseSize)
+	}
+	if entry.Type != model.LogTypeUser || entry.Browser == "" {
+		t.Errorf("日志类型或设备信息不正确: %+v", entry)
+	}
+	if entry.Status != "failed" || entry.ErrorCode != "401" || entry.ErrorMessage != "用户名或密码错误" {
+		t.Errorf("操作结果不正确: %s %s %s", entry.Status, entry.ErrorCode, entry.ErrorMessage)
+	}
+}
+
+// TestOperationLogImpersonation 测试模拟登录状态下的请求都会记录发起人
+func TestOperationLogImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := &recorderStub{}
+	router := gin.New()
+	router.Use(OperationLog(recorder))
+	router.Use(func(ctx *gin.Context) {
+		ctx.Set(ContextUserID, uint(2))
+		ctx.Set(ContextUsername, "alice")
+		ctx.Set(ContextClaims, &utils.Claims{UserID: 2, ImpersonatorID: 1})
+	})
+	router.GET("/files", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	router.POST("/files", func(ctx *gin.Context) {
+		RecordOperation(ctx, &model.OperationLog{Action: model.ActionFileUpload, Title: "上传文件"})
+		ctx.Status(http.StatusOK)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/files", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/files", nil))
+	if len(recorder.entries) != 2 {
+		t.Fatalf("期望记录 2 条日志, 实际 %d", len(recorder.entries))
+	}
+	if recorder.entries[0].Action != model.ActionImpersonated || recorder.entries[1].Action != model.ActionFileUpload {
+		t.Errorf("日志操作 = %s, %s", recorder.entries[0].Action, recorder.entries[1].Action)
+	}
+	for _, entry := range recorder.entries {
+		if entry.ImpersonatorID == nil || *entry.ImpersonatorID != 1 || !entry.ImportantFlag {
+			t.Errorf("未记录模拟登录发起人: %+v", entry)
+		}
+		if entry.UserID == nil || *entry.UserID != 2 {
+			t.Errorf("操作用户 = %v, 期望 2", entry.UserID)
+		}
+	}
+}
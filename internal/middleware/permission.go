@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// forbiddenMessage 缺少权限时的提示，与业务层的无权限错误保持一致
+const forbiddenMessage = "无权执行该操作"
+
+// PermissionChecker 判断用户是否拥有某类资源上的全局权限
+type PermissionChecker func(userID uint, resourceType model.ResourceType, action model.PermissionAction) (bool, error)
+
+// RequiredPermission 路由要求的权限
+type RequiredPermission struct {
+	ResourceType model.ResourceType     `json:"resource_type"`
+	Action       model.PermissionAction `json:"action"`
+}
+
+// PermissionGuard 路由权限守卫，记录每个受保护路由或路由组要求的权限，用于生成路由清单
+type PermissionGuard struct {
+	check PermissionChecker
+
+	mu     sync.RWMutex
+	routes map[string]RequiredPermission // 键为 "METHOD 完整路径"
+	groups map[string]RequiredPermission // 键为路由组前缀
+}
+
+// NewPermissionGuard 创建路由权限守卫
+func NewPermissionGuard(check PermissionChecker) *PermissionGuard {
+	return &PermissionGuard{
+		check:  check,
+		routes: make(map[string]RequiredPermission),
+		groups: make(map[string]RequiredPermission),
+	}
+}
+
+// RequirePermission 权限校验中间件，必须在JWTAuth之后使用
+// 缺少权限时返回403，响应的data中给出所需的权限；模拟登录令牌只用于排查用户问题，不能执行管理操作
+func (g *PermissionGuard) RequirePermission(resourceType model.ResourceType, action model.PermissionAction) gin.HandlerFunc {
+	required := RequiredPermission{ResourceType: resourceType, Action: action}
+	return func(ctx *gin.Context) {
+		userID := GetUserID(ctx)
+		if userID == 0 {
+			utils.AbortWithError(ctx, http.StatusUnauthorized, "缺少认证令牌")
+			return
+		}
+		if GetImpersonatorID(ctx) != 0 {
+			utils.AbortWithError(ctx, http.StatusForbidden, impersonationForbiddenMessage)
+			return
+		}
+
+		allowed, err := g.check(userID, resourceType, action)
+		if err != nil {
+			log.Printf("请求 %s %s 权限校验失败: %v", ctx.Request.Method, ctx.FullPath(), err)
+			utils.AbortWithError(ctx, http.StatusInternalServerError, "服务器内部错误")
+			return
+		}
+		if !allowed {
+			abortForbidden(ctx, required)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// Protect 为路由组添加权限校验，之后注册到该组的路由都需要该权限
+func (g *PermissionGuard) Protect(group *gin.RouterGroup, resourceType model.ResourceType, action model.PermissionAction) {
+	g.mu.Lock()
+	g.groups[strings.TrimSuffix(group.BasePath(), "/")] = RequiredPermission{ResourceType: resourceType, Action: action}
+	g.mu.Unlock()
+	group.Use(g.RequirePermission(resourceType, action))
+}
+
+// Handle 注册需要指定权限的单个路由
+func (g *PermissionGuard) Handle(group *gin.RouterGroup, method, relativePath string, required RequiredPermission,
+	handlers ...gin.HandlerFunc) {
+	fullPath := strings.TrimSuffix(group.BasePath(), "/") + relativePath
+	g.mu.Lock()
+	g.routes[method+" "+fullPath] = required
+	g.mu.Unlock()
+	group.Handle(method, relativePath, append([]gin.HandlerFunc{g.RequirePermission(required.ResourceType, required.Action)},
+		handlers...)...)
+}
+
+// Lookup 查询路由要求的权限，单个路由的声明优先，其次取最长匹配的路由组前缀
+func (g *PermissionGuard) Lookup(method, fullPath string) *RequiredPermission {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if required, ok := g.routes[method+" "+fullPath]; ok {
+		return &required
+	}
+	var (
+		found   *RequiredPermission
+		longest = -1
+	)
+	for prefix, required := range g.groups {
+		if len(prefix) <= longest || (fullPath != prefix && !strings.HasPrefix(fullPath, prefix+"/")) {
+			continue
+		}
+		found, longest = &required, len(prefix)
+	}
+	return found
+}
+
+// abortForbidden 中止缺少权限的请求，data中给出所需的权限
+func abortForbidden(ctx *gin.Context, required RequiredPermission) {
+	ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"code":    http.StatusForbidden,
+		"message": forbiddenMessage,
+		"data":    gin.H{"required_permission": required},
+	})
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ycg_cloud/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRequirePermission 测试缺少权限时返回统一的403响应
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	guard := NewPermissionGuard(func(userID uint, _ model.ResourceType, action model.PermissionAction) (bool, error) {
+		return userID == 1 && action == model.PermissionLogView, nil
+	})
+
+	router := gin.New()
+	api := router.Group("/api")
+	api.Use(func(ctx *gin.Context) {
+		if ctx.GetHeader("X-User") == "1" {
+			ctx.Set(ContextUserID, uint(1))
+		} else {
+			ctx.Set(ContextUserID, uint(2))
+		}
+	})
+	logs := api.Group("/logs")
+	guard.Protect(logs, model.ResourceTypeSystem, model.PermissionLogView)
+	logs.GET("", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+	allowed := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/logs", nil)
+	req.Header.Set("X-User", "1")
+	router.ServeHTTP(allowed, req)
+	if allowed.Code != http.StatusOK {
+		t.Fatalf("拥有权限时状态码 = %d, 期望 200", allowed.Code)
+	}
+
+	denied := httptest.NewRecorder()
+	router.ServeHTTP(denied, httptest.NewRequest(http.MethodGet, "/api/logs", nil))
+	if denied.Code != http.StatusForbidden {
+		t.Fatalf("缺少权限时状态码 = %d, 期望 403", denied.Code)
+	}
+	var body struct {
+		Code int `json:"code"`
+		Data struct {
+			RequiredPermission RequiredPermission `json:"required_permission"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(denied.Body.Bytes(), &body); err != nil {
+		t.Fatalf("解析响应失败: %v", err)
+	}
+	if body.Code != http.StatusForbidden || body.Data.RequiredPermission.Action != model.PermissionLogView {
+		t.Errorf("403响应格式不正确: %s", denied.Body.String())
+	}
+}
+
+// TestPermissionGuardLookup 测试路由清单的权限查询
+func TestPermissionGuardLookup(t *testing.T) {
+	guard := NewPermissionGuard(nil)
+	api := gin.New().Group("/api")
+	guard.Protect(api.Group("/admin"), model.ResourceTypeSystem, model.PermissionSystemConfig)
+	guard.Protect(api.Group("/admin/users"), model.ResourceTypeSystem, model.PermissionUserManage)
+	guard.Handle(api, http.MethodGet, "/admin/users/export",
+		RequiredPermission{ResourceType: model.ResourceTypeSystem, Action: model.PermissionLogView},
+		func(*gin.Context) {})
+
+	tests := []struct {
+		method string
+		path   string
+		want   model.PermissionAction
+	}{
+		{http.MethodGet, "/api/admin/users/:user_id", model.PermissionUserManage},
+		{http.MethodGet, "/api/admin/users/export", model.PermissionLogView},
+		{http.MethodGet, "/api/admin/routes", model.PermissionSystemConfig},
+		{http.MethodGet, "/api/administrators", ""},
+		{http.MethodGet, "/api/teams", ""},
+	}
+	for _, tt := range tests {
+		got := guard.Lookup(tt.method, tt.path)
+		if tt.want == "" {
+			if got != nil {
+				t.Errorf("Lookup(%s) = %+v, 期望无权限要求", tt.path, got)
+			}
+			continue
+		}
+		if got == nil || got.Action != tt.want {
+			t.Errorf("Lookup(%s) = %+v, 期望 %s", tt.path, got, tt.want)
+		}
+	}
+}
@@ -345,6 +345,9 @@ type serverConfig struct {
 	ReadTimeout    time.Duration `json:"read_timeout" yaml:"read_timeout"`
 	WriteTimeout   time.Duration `json:"write_timeout" yaml:"write_timeout"`
 	MaxHeaderBytes int           `json:"max_header_bytes" yaml:"max_header_bytes"`
+	// TrustedProxies 可信的反向代理地址或网段，只有来自这些地址的请求才采信X-Forwarded-For
+	// viper按mapstructure标签匹配键名，带下划线的键需要显式声明才能读取
+	TrustedProxies []string `json:"trusted_proxies" yaml:"trusted_proxies" mapstructure:"trusted_proxies"`
 }
 
 // databaseConfig 数据库配置 (私有)
@@ -397,6 +400,22 @@ type logConfig struct {
 	MaxAge     int    `json:"max_age" yaml:"max_age"`
 	MaxBackups int    `json:"max_backups" yaml:"max_backups"`
 	Compress   bool   `json:"compress" yaml:"compress"`
+
+	Operation operationLogConfig `json:"operation" yaml:"operation"`
+	Audit     auditLogConfig     `json:"audit" yaml:"audit"`
+}
+
+// auditLogConfig 审计哈希链配置 (私有)
+// 锚点文件路径只能来自配置文件，不能放在数据库的系统配置中，否则能改库的人可以让校验读取空文件后改写哈希链
+type auditLogConfig struct {
+	AnchorFile string `json:"anchor_file" yaml:"anchor_file" mapstructure:"anchor_file"`
+}
+
+// operationLogConfig 操作日志异步写入配置 (私有)
+type operationLogConfig struct {
+	BufferSize    int           `json:"buffer_size" yaml:"buffer_size"`
+	BatchSize     int           `json:"batch_size" yaml:"batch_size"`
+	FlushInterval time.Duration `json:"flush_interval" yaml:"flush_interval"`
 }
 
 // uploadConfig 上传配置 (私有)
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// emailStatus 邮件发件箱状态枚举 (私有)
+type emailStatus string
+
+const (
+	EmailStatusPending emailStatus = "pending" // 待发送
+	EmailStatusSending emailStatus = "sending" // 发送中
+	EmailStatusSent    emailStatus = "sent"    // 已发送
+	EmailStatusFailed  emailStatus = "failed"  // 发送失败(已达最大重试次数)
+)
+
+// DefaultEmailMaxAttempts 邮件默认最大发送次数
+const DefaultEmailMaxAttempts = 6
+
+// emailOutbox 邮件发件箱，邮件先持久化再由后台任务投递，服务重启后不会丢失 (私有)
+type emailOutbox struct {
+	// 时间戳字段 (24 bytes each)
+	CreatedAt     time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_email_outbox_status_next;comment:下次发送时间" json:"next_attempt_at"`
+
+	// 指针字段 (8 bytes each)
+	UserID *uint      `gorm:"index;comment:收件用户ID" json:"user_id"`
+	User   *User      `gorm:"foreignKey:UserID;constraint:OnDelete:SET NULL" json:"-"`
+	SentAt *time.Time `gorm:"comment:发送成功时间" json:"sent_at"`
+
+	// 字符串字段 (24 bytes each)
+	ToAddress string      `gorm:"type:varchar(255);not null;index;comment:收件地址" json:"to_address"`
+	Subject   string      `gorm:"type:varchar(500);not null;comment:邮件主题" json:"subject"`
+	TextBody  string      `gorm:"type:mediumtext;comment:纯文本正文" json:"-"`
+	HTMLBody  string      `gorm:"type:mediumtext;comment:HTML正文" json:"-"`
+	Template  string      `gorm:"type:varchar(50);index;comment:邮件模板" json:"template"`
+	LastError string      `gorm:"type:varchar(1000);comment:最近一次失败原因" json:"last_error"`
+	Status    emailStatus `gorm:"type:varchar(20);default:'pending';index:idx_email_outbox_status_next;comment:状态" json:"status"`
+
+	// uint字段 (8 bytes each)
+	ID uint `gorm:"primaryKey;autoIncrement" json:"id"`
+
+	// int字段 (8 bytes each)
+	Attempts    int `gorm:"default:0;comment:已尝试次数" json:"attempts"`
+	MaxAttempts int `gorm:"default:6;comment:最大尝试次数" json:"max_attempts"`
+}
+
+// TableName 指定表名
+func (emailOutbox) TableName() string {
+	return "email_outbox"
+}
+
+// EmailOutbox 公共类型别名
+type EmailOutbox = emailOutbox
+
+// CanRetry 检查失败后是否还能重试
+func (e *emailOutbox) CanRetry() bool {
+	return e.Attempts < e.MaxAttempts
+}
@@ -60,7 +60,8 @@ type File struct {
 	Size int64 `gorm:"default:0;comment:文件大小(字节)" json:"size"`
 
 	// uint字段 (4 bytes)
-	ID uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	ID      uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	OwnerID uint `gorm:"not null;index;comment:所有者ID" json:"owner_id"`
 
 	// int字段 (4 bytes each)
 	Version       int `gorm:"default:1;comment:文件版本号" json:"version"`
@@ -1,11 +1,15 @@
 package model
 
 import (
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// ErrAuditLogImmutable 审计日志只能追加
+var ErrAuditLogImmutable = errors.New("审计日志不可修改或删除")
+
 // LogLevel 日志级别枚举
 type LogLevel string
 
@@ -51,6 +55,7 @@ const (
 	ActionFileCopy     actionType = "file_copy"     // 文件复制
 	ActionFileShare    actionType = "file_share"    // 文件分享
 	ActionFilePreview  actionType = "file_preview"  // 文件预览
+	ActionShareAccess  actionType = "share_access"  // 访问分享(校验提取码)
 
 	// 文件夹操作
 	ActionFolderCreate actionType = "folder_create" // 创建文件夹
@@ -58,6 +63,9 @@ const (
 	ActionFolderMove   actionType = "folder_move"   // 移动文件夹
 	ActionFolderRename actionType = "folder_rename" // 重命名文件夹
 
+	// 消息操作
+	ActionMessageRecall actionType = "message_recall" // 撤回消息
+
 	// 权限操作
 	ActionPermissionGrant  actionType = "permission_grant"  // 授权
 	ActionPermissionRevoke actionType = "permission_revoke" // 撤销权限
@@ -76,10 +84,12 @@ const (
 	ActionConfigUpdate  actionType = "config_update"  // 配置更新
 
 	// 管理员操作
-	ActionAdminUserCreate actionType = "admin_user_create" // 管理员创建用户
-	ActionAdminUserUpdate actionType = "admin_user_update" // 管理员更新用户
-	ActionAdminUserDelete actionType = "admin_user_delete" // 管理员删除用户
-	ActionAdminUserBlock  actionType = "admin_user_block"  // 管理员封禁用户
+	ActionAdminUserCreate  actionType = "admin_user_create" // 管理员创建用户
+	ActionAdminUserUpdate  actionType = "admin_user_update" // 管理员更新用户
+	ActionAdminUserDelete  actionType = "admin_user_delete" // 管理员删除用户
+	ActionAdminUserBlock   actionType = "admin_user_block"  // 管理员封禁用户
+	ActionAdminImpersonate actionType = "admin_impersonate" // 管理员模拟登录
+	ActionImpersonated     actionType = "impersonated"      // 模拟登录状态下的请求
 )
 
 // OperationLog 操作日志模型
@@ -90,6 +100,8 @@ type OperationLog struct {
 	UserID   *uint  `gorm:"index;comment:操作用户ID" json:"user_id"`
 	User     *User  `gorm:"foreignKey:UserID;constraint:OnDelete:SET NULL" json:"user,omitempty"`
 	Username string `gorm:"type:varchar(100);index;comment:用户名" json:"username"`
+	// 模拟登录时实际发起操作的管理员
+	ImpersonatorID *uint `gorm:"index;comment:模拟登录的管理员ID" json:"impersonator_id,omitempty"`
 
 	// 日志基本信息
 	Type   LogType    `gorm:"type:varchar(20);not null;index" json:"type"`
@@ -143,6 +155,11 @@ type OperationLog struct {
 	Metadata string `gorm:"type:text;comment:元数据(JSON)" json:"metadata"`
 	Tags     string `gorm:"type:varchar(500);comment:标签" json:"tags"`
 
+	// 审计哈希链，只有通过审计写入路径追加的日志才有，写入后不可修改
+	ChainSeq *uint64 `gorm:"uniqueIndex;comment:审计链序号" json:"chain_seq,omitempty"`
+	PrevHash string  `gorm:"type:char(64);comment:上一条审计日志哈希" json:"prev_hash,omitempty"`
+	Hash     string  `gorm:"type:char(64);comment:审计日志哈希" json:"hash,omitempty"`
+
 	// 时间戳
 	CreatedAt time.Time      `gorm:"autoCreateTime;index" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
@@ -154,6 +171,33 @@ func (OperationLog) TableName() string {
 	return "operation_logs"
 }
 
+// IsChained 检查是否为哈希链中的审计日志
+func (ol *OperationLog) IsChained() bool {
+	return ol.ChainSeq != nil
+}
+
+// auditChainHead 审计哈希链头，只有一行，追加审计日志时加锁以保证链按顺序增长 (私有)
+type auditChainHead struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Seq  uint64 `gorm:"not null;default:0;comment:最新序号" json:"seq"`
+	Hash string `gorm:"type:char(64);comment:最新哈希" json:"hash"`
+
+	// 归档边界，该序号及之前的审计日志已归档并从表中删除
+	ArchivedSeq  uint64 `gorm:"not null;default:0;comment:已归档的最大序号" json:"archived_seq"`
+	ArchivedHash string `gorm:"type:char(64);comment:已归档的最后一条哈希" json:"archived_hash"`
+
+	// 时间戳
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName 指定表名
+func (auditChainHead) TableName() string {
+	return "audit_chain_heads"
+}
+
+// AuditChainHead 审计哈希链头 (公共类型别名)
+type AuditChainHead = auditChainHead
+
 // systemLog 系统日志模型 (私有)
 type systemLog struct {
 	ID uint `gorm:"primaryKey;autoIncrement" json:"id"`
@@ -275,6 +319,22 @@ func (ol *OperationLog) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// BeforeUpdate GORM钩子：哈希链中的审计日志不可修改
+func (ol *OperationLog) BeforeUpdate(tx *gorm.DB) error {
+	if ol.IsChained() {
+		return ErrAuditLogImmutable
+	}
+	return nil
+}
+
+// BeforeDelete GORM钩子：哈希链中的审计日志不可删除
+func (ol *OperationLog) BeforeDelete(tx *gorm.DB) error {
+	if ol.IsChained() {
+		return ErrAuditLogImmutable
+	}
+	return nil
+}
+
 // BeforeCreate GORM钩子：创建前
 func (sl *systemLog) BeforeCreate(tx *gorm.DB) error {
 	// 设置默认值
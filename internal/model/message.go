@@ -32,6 +32,9 @@ const (
 	MessageStatusRecalled  messageStatus = "recalled"  // 已撤回
 )
 
+// DefaultRecallWindow 默认消息撤回时限
+const DefaultRecallWindow = 2 * time.Minute
+
 // conversationType 会话类型枚举
 type conversationType string
 
@@ -78,6 +81,12 @@ type Conversation struct {
 	MessageRetentionDays int  `gorm:"default:0;comment:消息保留天数(0表示永久)" json:"message_retention_days"`
 	AllowFileShare       bool `gorm:"default:true;comment:允许文件分享" json:"allow_file_share"`
 
+	// 法律保留：开启后消息不受保留期限清理
+	LegalHold       bool       `gorm:"default:false;index;comment:是否法律保留" json:"legal_hold"`
+	LegalHoldReason string     `gorm:"type:varchar(500);comment:法律保留原因" json:"legal_hold_reason"`
+	LegalHoldBy     *uint      `gorm:"comment:设置法律保留的用户ID" json:"legal_hold_by"`
+	LegalHoldAt     *time.Time `gorm:"comment:法律保留设置时间" json:"legal_hold_at"`
+
 	// 最后消息信息
 	LastMessageID *uint      `gorm:"index;comment:最后一条消息ID" json:"last_message_id"`
 	LastMessage   *Message   `gorm:"foreignKey:LastMessageID;constraint:OnDelete:SET NULL" json:"last_message,omitempty"`
@@ -121,6 +130,10 @@ type conversationMember struct {
 	InvitedBy         *uint      `gorm:"index;comment:邀请人ID" json:"invited_by"`
 	LastReadMessageID *uint      `gorm:"index;comment:最后已读消息ID" json:"last_read_message_id"`
 
+	// 送达位置，客户端通过WebSocket确认收到消息时更新
+	LastDeliveredMessageID *uint      `gorm:"comment:最后送达消息ID" json:"last_delivered_message_id"`
+	LastDeliveredAt        *time.Time `gorm:"comment:最后送达时间" json:"last_delivered_at"`
+
 	// uint字段 (8 bytes each)
 	ID             uint `gorm:"primaryKey;autoIncrement" json:"id"`
 	ConversationID uint `gorm:"not null;index;comment:会话ID" json:"conversation_id"`
@@ -173,6 +186,9 @@ type Message struct {
 	Type       messageType   `gorm:"type:varchar(20);not null;index" json:"type"`
 	Status     messageStatus `gorm:"type:varchar(20);default:'sent';index" json:"status"`
 
+	// 通知分类，仅系统会话中的通知消息使用
+	Category NotificationCategory `gorm:"type:varchar(30);index;comment:通知分类" json:"category,omitempty"`
+
 	// uint字段 (8 bytes each)
 	ID             uint `gorm:"primaryKey;autoIncrement" json:"id"`
 	ConversationID uint `gorm:"not null;index" json:"conversation_id"`
@@ -217,6 +233,60 @@ func (messageReadReceipt) TableName() string {
 // MessageReadReceipt 公共类型别名
 type MessageReadReceipt = messageReadReceipt
 
+// messageMention 消息提及模型，@all会展开为每个成员一条记录 (私有)
+type messageMention struct {
+	ID             uint    `gorm:"primaryKey;autoIncrement" json:"id"`
+	MessageID      uint    `gorm:"not null;uniqueIndex:idx_message_mentions_message_user;comment:消息ID" json:"message_id"`
+	Message        Message `gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE" json:"-"`
+	UserID         uint    `gorm:"not null;uniqueIndex:idx_message_mentions_message_user;index:idx_message_mentions_user;comment:被提及用户ID" json:"user_id"`
+	User           User    `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	ConversationID uint    `gorm:"not null;index;comment:会话ID" json:"conversation_id"`
+	SenderID       uint    `gorm:"not null;index;comment:发送者ID" json:"sender_id"`
+
+	// 提及方式
+	IsDirect   bool `gorm:"default:false;comment:是否@用户名直接提及" json:"is_direct"`
+	MentionAll bool `gorm:"default:false;comment:是否通过@all提及" json:"mention_all"`
+
+	// 时间戳
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_message_mentions_user" json:"created_at"`
+}
+
+// TableName 指定表名
+func (messageMention) TableName() string {
+	return "message_mentions"
+}
+
+// MessageMention 公共类型别名
+type MessageMention = messageMention
+
+// messageRevision 消息编辑历史模型，每个版本一条记录，版本0为原始内容 (私有)
+type messageRevision struct {
+	ID        uint    `gorm:"primaryKey;autoIncrement" json:"id"`
+	MessageID uint    `gorm:"not null;uniqueIndex:idx_message_revisions_message_revision;comment:消息ID" json:"message_id"`
+	Message   Message `gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE" json:"-"`
+	Revision  int     `gorm:"not null;uniqueIndex:idx_message_revisions_message_revision;comment:版本号" json:"revision"`
+
+	// 版本内容
+	Content  string `gorm:"type:text;not null;comment:消息内容" json:"content"`
+	Metadata string `gorm:"type:text;comment:消息元数据(JSON)" json:"metadata"`
+
+	// 编辑信息
+	EditedBy uint      `gorm:"not null;index;comment:编辑人ID" json:"edited_by"`
+	Editor   User      `gorm:"foreignKey:EditedBy" json:"-"`
+	EditedAt time.Time `gorm:"not null;comment:编辑时间" json:"edited_at"`
+
+	// 时间戳
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName 指定表名
+func (messageRevision) TableName() string {
+	return "message_revisions"
+}
+
+// MessageRevision 公共类型别名
+type MessageRevision = messageRevision
+
 // BeforeCreate GORM钩子：创建前
 func (c *Conversation) BeforeCreate(tx *gorm.DB) error {
 	// 设置默认值
@@ -290,5 +360,15 @@ func (m *Message) IsRecalled() bool {
 
 // CanRecall 检查消息是否可以撤回(2分钟内)
 func (m *Message) CanRecall() bool {
-	return !m.RecalledFlag && time.Since(m.CreatedAt) <= 2*time.Minute
+	return m.CanRecallWithin(DefaultRecallWindow)
+}
+
+// CanRecallWithin 检查消息是否可以在指定时限内撤回
+func (m *Message) CanRecallWithin(window time.Duration) bool {
+	return !m.RecalledFlag && time.Since(m.CreatedAt) <= window
+}
+
+// CanEdit 检查消息是否可以编辑(仅未撤回的文本和回复消息)
+func (m *Message) CanEdit() bool {
+	return !m.RecalledFlag && (m.Type == MessageTypeText || m.Type == MessageTypeReply)
 }
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// TestMessageCanRecallWithin 测试消息撤回时限判断
+func TestMessageCanRecallWithin(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name    string
+		message Message
+		window  time.Duration
+		want    bool
+	}{
+		{"时限内", Message{CreatedAt: now.Add(-time.Minute)}, 2 * time.Minute, true},
+		{"超过时限", Message{CreatedAt: now.Add(-3 * time.Minute)}, 2 * time.Minute, false},
+		{"会话自定义时限", Message{CreatedAt: now.Add(-3 * time.Minute)}, 10 * time.Minute, true},
+		{"禁止撤回", Message{CreatedAt: now.Add(-time.Second)}, 0, false},
+		{"已撤回", Message{CreatedAt: now, RecalledFlag: true}, 2 * time.Minute, false},
+	}
+
+	for _, tc := range cases {
+		if got := tc.message.CanRecallWithin(tc.window); got != tc.want {
+			t.Errorf("%s: 期望 %t, 实际 %t", tc.name, tc.want, got)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package model
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -27,6 +31,10 @@ func AutoMigrate(db *gorm.DB) error {
 		&TeamMember{},
 		&TeamFile{},
 		&TeamRole{},
+		&TeamJoinRequest{},
+		&TeamAutoApproveRule{},
+		&TeamStorageSnapshot{},
+		&TeamStorageAlert{},
 		&Conversation{},
 		&RecycleItem{},
 		&RecycleBin{},
@@ -41,9 +49,16 @@ func AutoMigrate(db *gorm.DB) error {
 		&ConversationMember{},
 		&Message{},
 		&MessageReadReceipt{},
+		&MessageRevision{},
+		&MessageMention{},
+		&NotificationPreference{},
+		&EmailOutbox{},
+		&UserToken{},
+		&UserSession{},
 
 		// 日志相关模型
 		&OperationLog{},
+		&AuditChainHead{},
 		&SystemLog{},
 		&SecurityLog{},
 
@@ -59,10 +74,237 @@ func AutoMigrate(db *gorm.DB) error {
 		log.Printf("成功迁移模型: %T", model)
 	}
 
+	if err := backfillJoinRequestPending(db); err != nil {
+		return err
+	}
+	if err := migrateRolledLogTables(db); err != nil {
+		return err
+	}
+	if err := CreateAuditTriggers(db); err != nil {
+		return err
+	}
+
 	log.Println("数据库迁移完成")
 	return nil
 }
 
+// backfillJoinRequestPending 为添加待审批标记之前的待审批入队申请补充标记
+// 同一用户在同一团队的多条待审批申请只保留最新的一条，其余标记为已撤回
+func backfillJoinRequestPending(db *gorm.DB) error {
+	if err := db.Exec("UPDATE team_join_requests AS r JOIN (SELECT MAX(id) AS id FROM team_join_requests "+
+		"WHERE status = ? AND pending_flag IS NULL AND deleted_at IS NULL GROUP BY team_id, user_id) AS latest "+
+		"ON latest.id = r.id SET r.pending_flag = TRUE", TeamJoinRequestStatusPending).Error; err != nil {
+		return fmt.Errorf("补充入队申请待审批标记失败: %w", err)
+	}
+	if err := db.Exec("UPDATE team_join_requests SET status = ? WHERE status = ? AND pending_flag IS NULL",
+		TeamJoinRequestStatusCancelled, TeamJoinRequestStatusPending).Error; err != nil {
+		return fmt.Errorf("撤回重复的入队申请失败: %w", err)
+	}
+	return nil
+}
+
+// tableTrigger 在违反条件时拒绝写入的触发器
+type tableTrigger struct {
+	name      string // 名称后缀，完整名称为 <前缀>_<后缀>
+	event     string
+	condition string
+	message   string
+}
+
+// operationLogTriggers 阻止修改和删除哈希链中审计日志的触发器，软删除同样是UPDATE会被阻止
+// 只有已归档(序号不超过链头记录的归档边界)的审计日志可以删除
+var operationLogTriggers = []tableTrigger{
+	{"audit_no_update", "UPDATE", "OLD.chain_seq IS NOT NULL", "audit log is append-only"},
+	{"audit_no_delete", "DELETE", "OLD.chain_seq IS NOT NULL AND " +
+		"OLD.chain_seq > IFNULL((SELECT archived_seq FROM audit_chain_heads WHERE id = 1), 0)",
+		"audit log is append-only"},
+}
+
+// auditChainHeadTriggers 删除审计日志时信任链头记录的归档边界，因此归档边界只能前移且不能超过链尾，
+// 新建的链头记录不能自带归档边界
+var auditChainHeadTriggers = []tableTrigger{
+	{"archive_forward", "UPDATE", "NEW.archived_seq < OLD.archived_seq OR NEW.archived_seq > NEW.seq OR " +
+		"(NEW.archived_seq = OLD.archived_seq AND NOT (NEW.archived_hash <=> OLD.archived_hash))",
+		"audit archive boundary can only move forward"},
+	{"archive_insert", "INSERT", "NEW.archived_seq <> 0", "audit archive boundary can only move forward"},
+}
+
+// CreateAuditTriggers 创建审计日志只追加、归档边界只前移的数据库触发器，在绕过应用直接改库时同样生效
+// 触发器随表改名移到按月滚动出的历史表上并保留原名，这里只重建当前操作日志表上的触发器
+func CreateAuditTriggers(db *gorm.DB) error {
+	prefix, err := operationLogTriggerPrefix(db)
+	if err != nil {
+		return err
+	}
+	if err := createTriggers(db, operationLogsTable, prefix, operationLogTriggers); err != nil {
+		return err
+	}
+	return createTriggers(db, "audit_chain_heads", "trg_audit_chain_heads", auditChainHeadTriggers)
+}
+
+// operationLogTriggerPrefix 当前操作日志表上审计触发器的名称前缀，还没有触发器时使用默认前缀
+func operationLogTriggerPrefix(db *gorm.DB) (string, error) {
+	var names []string
+	if err := db.Raw("SELECT TRIGGER_NAME FROM information_schema.TRIGGERS "+
+		"WHERE TRIGGER_SCHEMA = DATABASE() AND EVENT_OBJECT_TABLE = ? AND TRIGGER_NAME LIKE ?",
+		operationLogsTable, `%\_audit\_no\_update`).Scan(&names).Error; err != nil {
+		return "", fmt.Errorf("查询审计触发器失败: %w", err)
+	}
+	if len(names) == 0 {
+		return "trg_" + operationLogsTable, nil
+	}
+	return strings.TrimSuffix(names[0], "_audit_no_update"), nil
+}
+
+// createTriggers 删除同名触发器后重新创建
+func createTriggers(db *gorm.DB, table, prefix string, triggers []tableTrigger) error {
+	for _, trigger := range triggers {
+		name := prefix + "_" + trigger.name
+		if err := db.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS %s", name)).Error; err != nil {
+			return fmt.Errorf("删除触发器 %s 失败: %w", name, err)
+		}
+		triggerSQL := fmt.Sprintf("CREATE TRIGGER %s BEFORE %s ON %s FOR EACH ROW "+
+			"IF %s THEN "+
+			"SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = '%s'; "+
+			"END IF", name, trigger.event, table, trigger.condition, trigger.message)
+		if err := db.Exec(triggerSQL).Error; err != nil {
+			return fmt.Errorf("创建触发器 %s 失败: %w", name, err)
+		}
+		log.Printf("成功创建触发器: %s", name)
+	}
+	return nil
+}
+
+// 按月滚动的日志表
+const (
+	operationLogsTable = "operation_logs"
+	// LogTableMonthFormat 历史表名的月份后缀格式，历史表名为 <表名>_YYYYMM
+	LogTableMonthFormat = "200601"
+	// logTableIDGap 新表的自增ID在旧表最大ID之后留出的余量，覆盖查询最大ID到改名之间仍写入旧表的日志
+	logTableIDGap = 100000
+)
+
+// rolloverLogModels 按月滚动的日志表模型
+var rolloverLogModels = []interface{}{&OperationLog{}, &SystemLog{}, &SecurityLog{}}
+
+// RolledLogTables 列出日志表按月滚动出的历史表，按月份升序
+func RolledLogTables(db *gorm.DB, table string) ([]string, error) {
+	var names []string
+	if err := db.Raw("SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME LIKE ?",
+		strings.ReplaceAll(table, "_", `\_`)+`\_%`).Scan(&names).Error; err != nil {
+		return nil, fmt.Errorf("查询 %s 的历史表失败: %w", table, err)
+	}
+	rolled := make([]string, 0, len(names))
+	for _, name := range names {
+		suffix := strings.TrimPrefix(name, table+"_")
+		if len(suffix) != len(LogTableMonthFormat) {
+			continue
+		}
+		if _, err := time.Parse(LogTableMonthFormat, suffix); err == nil {
+			rolled = append(rolled, name)
+		}
+	}
+	sort.Strings(rolled)
+	return rolled, nil
+}
+
+// RollLogTable 把日志表改名为上个月的历史表 <表名>_YYYYMM，并换上结构相同的空表，写入方不需要感知
+// until为本月月初，日志表中没有更早的日志或历史表已存在时不做任何操作
+// 历史表去掉外键，外键和审计触发器在新表上重建，触发器名称带上新表开始使用的月份以免与历史表上的触发器重名
+func RollLogTable(db *gorm.DB, value interface{}, until time.Time) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(value); err != nil {
+		return fmt.Errorf("解析日志模型失败: %w", err)
+	}
+	table := stmt.Schema.Table
+	rolled := table + "_" + until.AddDate(0, -1, 0).Format(LogTableMonthFormat)
+	next := table + "_next"
+	if db.Migrator().HasTable(rolled) {
+		return nil
+	}
+	var expired []uint
+	if err := db.Table(table).Where("created_at < ?", until).Limit(1).Pluck("id", &expired).Error; err != nil {
+		return fmt.Errorf("查询 %s 待滚动的日志失败: %w", table, err)
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	// 上次滚动中断时可能留下未启用的新表
+	if err := db.Migrator().DropTable(next); err != nil {
+		return fmt.Errorf("删除 %s 失败: %w", next, err)
+	}
+	if err := db.Exec(fmt.Sprintf("CREATE TABLE %s LIKE %s", next, table)).Error; err != nil {
+		return fmt.Errorf("创建 %s 失败: %w", next, err)
+	}
+	var maxID sql.NullInt64
+	if err := db.Table(table).Select("MAX(id)").Row().Scan(&maxID); err != nil {
+		return fmt.Errorf("查询 %s 最大ID失败: %w", table, err)
+	}
+	if err := db.Exec(fmt.Sprintf("ALTER TABLE %s AUTO_INCREMENT = %d", next, maxID.Int64+logTableIDGap)).Error; err != nil {
+		return fmt.Errorf("设置 %s 自增ID失败: %w", next, err)
+	}
+	if table == operationLogsTable {
+		prefix := "trg_" + table + "_" + until.Format(LogTableMonthFormat)
+		if err := createTriggers(db, next, prefix, operationLogTriggers); err != nil {
+			return err
+		}
+	}
+	if err := db.Exec(fmt.Sprintf("RENAME TABLE %s TO %s, %s TO %s", table, rolled, next, table)).Error; err != nil {
+		return fmt.Errorf("滚动 %s 失败: %w", table, err)
+	}
+	log.Printf("日志表 %s 已滚动为 %s", table, rolled)
+
+	if err := dropForeignKeys(db, rolled); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(value); err != nil {
+		return fmt.Errorf("重建 %s 外键失败: %w", table, err)
+	}
+	return nil
+}
+
+// dropForeignKeys 删除表上的全部外键，外键名在库内唯一，留在历史表上会使当前表无法重建同名外键
+func dropForeignKeys(db *gorm.DB, table string) error {
+	var names []string
+	if err := db.Raw("SELECT CONSTRAINT_NAME FROM information_schema.REFERENTIAL_CONSTRAINTS "+
+		"WHERE CONSTRAINT_SCHEMA = DATABASE() AND TABLE_NAME = ?", table).Scan(&names).Error; err != nil {
+		return fmt.Errorf("查询 %s 外键失败: %w", table, err)
+	}
+	for _, name := range names {
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", table, name)).Error; err != nil {
+			return fmt.Errorf("删除 %s 外键 %s 失败: %w", table, name, err)
+		}
+	}
+	return nil
+}
+
+// migrateRolledLogTables 为历史表补充模型新增的列，跨表查询要求各表的列一致，历史表不需要补充外键和索引
+func migrateRolledLogTables(db *gorm.DB) error {
+	for _, value := range rolloverLogModels {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(value); err != nil {
+			return fmt.Errorf("解析日志模型失败: %w", err)
+		}
+		tables, err := RolledLogTables(db, stmt.Schema.Table)
+		if err != nil {
+			return err
+		}
+		for _, table := range tables {
+			migrator := db.Table(table).Migrator()
+			for _, column := range stmt.Schema.DBNames {
+				if migrator.HasColumn(value, column) {
+					continue
+				}
+				if err := migrator.AddColumn(value, column); err != nil {
+					return fmt.Errorf("历史表 %s 添加列 %s 失败: %w", table, column, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // CreateIndexes 创建额外的索引
 func CreateIndexes(db *gorm.DB) error {
 	log.Println("开始创建额外索引...")
@@ -130,7 +372,14 @@ func DropAllTables(db *gorm.DB) error {
 		"recycle_logs",
 		"security_logs",
 		"system_logs",
+		"audit_chain_heads",
 		"operation_logs",
+		"user_sessions",
+		"user_tokens",
+		"email_outbox",
+		"notification_preferences",
+		"message_mentions",
+		"message_revisions",
 		"message_read_receipts",
 		"messages",
 		"conversation_members",
@@ -141,6 +390,10 @@ func DropAllTables(db *gorm.DB) error {
 		"recycle_bins",
 		"recycle_items",
 		"conversations",
+		"team_storage_alerts",
+		"team_storage_snapshots",
+		"team_auto_approve_rules",
+		"team_join_requests",
 		"team_roles",
 		"team_files",
 		"team_members",
@@ -223,3 +223,24 @@ func BenchmarkModelCreation(b *testing.B) {
 		}
 	}
 }
+
+// TestAuditTriggers 测试审计触发器定义完整，链头的归档边界受触发器保护
+func TestAuditTriggers(t *testing.T) {
+	guarded := make(map[string]bool)
+	for table, triggers := range map[string][]tableTrigger{
+		operationLogsTable: operationLogTriggers, "audit_chain_heads": auditChainHeadTriggers,
+	} {
+		for _, trigger := range triggers {
+			if trigger.name == "" || trigger.condition == "" || trigger.message == "" {
+				t.Errorf("触发器 %q 定义不完整", trigger.name)
+			}
+			guarded[table+" "+trigger.event] = true
+		}
+	}
+	for _, key := range []string{"operation_logs UPDATE", "operation_logs DELETE",
+		"audit_chain_heads UPDATE", "audit_chain_heads INSERT"} {
+		if !guarded[key] {
+			t.Errorf("缺少 %s 触发器", key)
+		}
+	}
+}
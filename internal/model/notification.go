@@ -0,0 +1,104 @@
+package model
+
+import "time"
+
+// NotificationCategory 通知分类枚举
+type NotificationCategory string
+
+const (
+	NotificationCategorySystem   NotificationCategory = "system"   // 系统通知
+	NotificationCategoryStorage  NotificationCategory = "storage"  // 存储配额
+	NotificationCategoryShare    NotificationCategory = "share"    // 分享访问
+	NotificationCategoryRecycle  NotificationCategory = "recycle"  // 回收站到期
+	NotificationCategoryTeam     NotificationCategory = "team"     // 团队动态
+	NotificationCategorySecurity NotificationCategory = "security" // 账号安全
+)
+
+// NotificationCategories 全部通知分类，用于展示偏好设置
+var NotificationCategories = []NotificationCategory{
+	NotificationCategorySystem,
+	NotificationCategoryStorage,
+	NotificationCategoryShare,
+	NotificationCategoryRecycle,
+	NotificationCategoryTeam,
+	NotificationCategorySecurity,
+}
+
+// ParseNotificationCategory 解析通知分类，未知分类返回false
+func ParseNotificationCategory(value string) (NotificationCategory, bool) {
+	for _, category := range NotificationCategories {
+		if string(category) == value {
+			return category, true
+		}
+	}
+	return "", false
+}
+
+// 通知投递渠道
+const (
+	NotificationChannelInApp   = "in_app"  // 站内通知
+	NotificationChannelEmail   = "email"   // 邮件
+	NotificationChannelWebhook = "webhook" // Webhook
+)
+
+// 通知语言
+const (
+	LocaleZhCN    = "zh-CN" // 简体中文
+	LocaleEnUS    = "en-US" // 英文
+	DefaultLocale = LocaleZhCN
+)
+
+// notificationPreference 用户按分类设置的通知偏好 (私有)
+type notificationPreference struct {
+	// 时间戳字段 (24 bytes each)
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// 结构体字段
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+
+	// 字符串字段 (24 bytes each)
+	Category   NotificationCategory `gorm:"type:varchar(30);not null;uniqueIndex:idx_notification_preferences_user_category;comment:通知分类" json:"category"`
+	WebhookURL string               `gorm:"type:varchar(500);comment:Webhook地址" json:"webhook_url"`
+
+	// uint字段 (8 bytes each)
+	ID     uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID uint `gorm:"not null;uniqueIndex:idx_notification_preferences_user_category;comment:用户ID" json:"user_id"`
+
+	// bool字段 (1 byte each)
+	InApp   bool `gorm:"default:true;comment:是否站内通知" json:"in_app"`
+	Email   bool `gorm:"default:false;comment:是否邮件通知" json:"email"`
+	Webhook bool `gorm:"default:false;comment:是否Webhook通知" json:"webhook"`
+}
+
+// TableName 指定表名
+func (notificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+// NotificationPreference 公共类型别名
+type NotificationPreference = notificationPreference
+
+// DefaultNotificationPreference 未设置偏好时的默认值，安全类通知默认同时发送邮件
+func DefaultNotificationPreference(userID uint, category NotificationCategory) NotificationPreference {
+	return NotificationPreference{
+		UserID:   userID,
+		Category: category,
+		InApp:    true,
+		Email:    category == NotificationCategorySecurity,
+	}
+}
+
+// Allows 检查偏好是否允许通过指定渠道投递
+func (p *notificationPreference) Allows(channel string) bool {
+	switch channel {
+	case NotificationChannelInApp:
+		return p.InApp
+	case NotificationChannelEmail:
+		return p.Email
+	case NotificationChannelWebhook:
+		return p.Webhook && p.WebhookURL != ""
+	default:
+		return false
+	}
+}
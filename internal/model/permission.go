@@ -1,6 +1,9 @@
 package model
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -85,6 +88,9 @@ func (templatePermission) TableName() string {
 	return "template_permissions"
 }
 
+// TemplatePermission 公共类型别名
+type TemplatePermission = templatePermission
+
 // userPermission 用户权限 (私有)
 type userPermission struct {
 	ID           uint             `gorm:"primaryKey;autoIncrement" json:"id"`
@@ -96,12 +102,14 @@ type userPermission struct {
 	Allowed      bool             `gorm:"default:false" json:"allowed"`
 
 	// 权限来源
-	GrantedBy *uint     `gorm:"index;comment:授权人ID" json:"granted_by"`
-	Granter   *User     `gorm:"foreignKey:GrantedBy" json:"granter,omitempty"`
-	GrantedAt time.Time `gorm:"autoCreateTime" json:"granted_at"`
+	GrantedBy  *uint     `gorm:"index;comment:授权人ID" json:"granted_by"`
+	Granter    *User     `gorm:"foreignKey:GrantedBy" json:"granter,omitempty"`
+	GrantedAt  time.Time `gorm:"autoCreateTime" json:"granted_at"`
+	TemplateID *uint     `gorm:"index;comment:来源权限模板ID(为空表示单独授权)" json:"template_id"`
 
 	// 过期时间
-	ExpiresAt *time.Time `gorm:"index;comment:权限过期时间" json:"expires_at"`
+	ExpiresAt        *time.Time `gorm:"index;comment:权限过期时间" json:"expires_at"`
+	ExpiryNotifiedAt *time.Time `gorm:"comment:到期提醒发送时间" json:"expiry_notified_at"`
 
 	// 时间戳
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
@@ -114,6 +122,9 @@ func (userPermission) TableName() string {
 	return "user_permissions"
 }
 
+// UserPermission 公共类型别名
+type UserPermission = userPermission
+
 // filePermission 文件权限 (私有)
 type filePermission struct {
 	ID      uint             `gorm:"primaryKey;autoIncrement" json:"id"`
@@ -132,7 +143,11 @@ type filePermission struct {
 	GrantedAt time.Time `gorm:"autoCreateTime" json:"granted_at"`
 
 	// 过期时间
-	ExpiresAt *time.Time `gorm:"index;comment:权限过期时间" json:"expires_at"`
+	ExpiresAt        *time.Time `gorm:"index;comment:权限过期时间" json:"expires_at"`
+	ExpiryNotifiedAt *time.Time `gorm:"comment:到期提醒发送时间" json:"expiry_notified_at"`
+
+	// 授权来源消息(会话中分享文件时自动授予)
+	SourceMessageID *uint `gorm:"index;comment:来源消息ID" json:"source_message_id"`
 
 	// 时间戳
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
@@ -145,6 +160,9 @@ func (filePermission) TableName() string {
 	return "file_permissions"
 }
 
+// FilePermission 公共类型别名
+type FilePermission = filePermission
+
 // Role 角色定义
 type Role struct {
 	ID          uint   `gorm:"primaryKey;autoIncrement" json:"id"`
@@ -179,13 +197,18 @@ type userRole struct {
 	RoleID uint `gorm:"not null;index" json:"role_id"`
 	Role   Role `gorm:"foreignKey:RoleID;constraint:OnDelete:CASCADE" json:"role,omitempty"`
 
+	// 生效范围，system表示全局，team和folder分别限定在团队和文件夹子树内
+	ScopeType ResourceType `gorm:"type:varchar(20);default:'system';index:idx_user_roles_scope;comment:生效范围类型" json:"scope_type"`
+	ScopeID   *uint        `gorm:"index:idx_user_roles_scope;comment:生效范围ID" json:"scope_id"`
+
 	// 权限来源
 	GrantedBy *uint     `gorm:"index;comment:授权人ID" json:"granted_by"`
 	Granter   *User     `gorm:"foreignKey:GrantedBy;constraint:OnDelete:RESTRICT" json:"granter,omitempty"`
 	GrantedAt time.Time `gorm:"autoCreateTime" json:"granted_at"`
 
 	// 过期时间
-	ExpiresAt *time.Time `gorm:"index;comment:角色过期时间" json:"expires_at"`
+	ExpiresAt        *time.Time `gorm:"index;comment:角色过期时间" json:"expires_at"`
+	ExpiryNotifiedAt *time.Time `gorm:"comment:到期提醒发送时间" json:"expiry_notified_at"`
 
 	// 时间戳
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
@@ -198,11 +221,37 @@ func (userRole) TableName() string {
 	return "user_roles"
 }
 
+// UserRole 公共类型别名
+type UserRole = userRole
+
+// RolePermission 角色包含的一项权限，Role.Permissions为其JSON数组
+type RolePermission struct {
+	ResourceType ResourceType     `json:"resource_type"`
+	Action       PermissionAction `json:"action"`
+}
+
+// ParsePermissions 解析角色的权限配置
+func (r *Role) ParsePermissions() ([]RolePermission, error) {
+	if strings.TrimSpace(r.Permissions) == "" {
+		return nil, nil
+	}
+	var permissions []RolePermission
+	if err := json.Unmarshal([]byte(r.Permissions), &permissions); err != nil {
+		return nil, fmt.Errorf("解析角色权限失败: %w", err)
+	}
+	return permissions, nil
+}
+
 // IsExpired 检查权限是否过期
 func (up *userPermission) IsExpired() bool {
 	return up.ExpiresAt != nil && up.ExpiresAt.Before(time.Now())
 }
 
+// IsOverride 检查是否为单独授权，单独授权在模板同步时保留
+func (up *userPermission) IsOverride() bool {
+	return up.TemplateID == nil
+}
+
 // IsExpired 检查文件权限是否过期
 func (fp *filePermission) IsExpired() bool {
 	return fp.ExpiresAt != nil && fp.ExpiresAt.Before(time.Now())
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -13,6 +14,7 @@ const (
 	TeamStatusActive    TeamStatus = "active"    // 活跃
 	TeamStatusInactive  TeamStatus = "inactive"  // 非活跃
 	TeamStatusSuspended TeamStatus = "suspended" // 暂停
+	TeamStatusArchived  TeamStatus = "archived"  // 已归档
 	TeamStatusDeleted   TeamStatus = "deleted"   // 删除
 )
 
@@ -36,6 +38,23 @@ const (
 	TeamMemberStatusLeft     teamMemberStatus = "left"     // 已离开
 )
 
+// teamJoinRequestStatus 入队申请状态枚举 (私有)
+type teamJoinRequestStatus string
+
+const (
+	TeamJoinRequestStatusPending   teamJoinRequestStatus = "pending"   // 待审批
+	TeamJoinRequestStatusApproved  teamJoinRequestStatus = "approved"  // 已通过
+	TeamJoinRequestStatusRejected  teamJoinRequestStatus = "rejected"  // 已拒绝
+	TeamJoinRequestStatusCancelled teamJoinRequestStatus = "cancelled" // 已取消
+)
+
+// autoApproveRuleType 自动审批规则类型枚举 (私有)
+type autoApproveRuleType string
+
+const (
+	AutoApproveRuleEmailDomain autoApproveRuleType = "email_domain" // 邮箱域名匹配
+)
+
 // Team 团队模型
 type Team struct {
 	// time.Time 字段放在最前面 (8字节对齐)
@@ -174,6 +193,123 @@ func (teamRole) TableName() string {
 	return "team_roles"
 }
 
+// teamJoinRequest 入队申请模型 (私有)
+type teamJoinRequest struct {
+	ID     uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	TeamID uint `gorm:"not null;index;uniqueIndex:idx_team_join_requests_pending" json:"team_id"`
+	Team   Team `gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE" json:"team,omitempty"`
+	UserID uint `gorm:"not null;index;uniqueIndex:idx_team_join_requests_pending" json:"user_id"`
+	User   User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+
+	// 申请信息
+	Message      string                `gorm:"type:varchar(500);comment:申请留言" json:"message"`
+	Status       teamJoinRequestStatus `gorm:"type:varchar(20);default:'pending';index" json:"status"`
+	AutoApproved bool                  `gorm:"default:false;comment:是否自动审批通过" json:"auto_approved"`
+	// PendingFlag 待审批时为true，处理后为NULL，与团队和用户组成唯一索引，同一用户在同一团队只能有一条待审批申请
+	PendingFlag *bool `gorm:"uniqueIndex:idx_team_join_requests_pending;comment:待审批标记" json:"-"`
+
+	// 审批信息
+	ReviewedBy *uint      `gorm:"index;comment:审批人ID" json:"reviewed_by"`
+	Reviewer   *User      `gorm:"foreignKey:ReviewedBy;constraint:OnDelete:SET NULL" json:"reviewer,omitempty"`
+	ReviewedAt *time.Time `gorm:"comment:审批时间" json:"reviewed_at"`
+	ReviewNote string     `gorm:"type:varchar(500);comment:审批备注" json:"review_note"`
+
+	// 时间戳
+	CreatedAt time.Time      `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// TeamJoinRequest 入队申请模型 (公共类型别名)
+type TeamJoinRequest = teamJoinRequest
+
+// TableName 指定表名
+func (teamJoinRequest) TableName() string {
+	return "team_join_requests"
+}
+
+// teamAutoApproveRule 入队自动审批规则 (私有)
+type teamAutoApproveRule struct {
+	ID     uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	TeamID uint `gorm:"not null;index" json:"team_id"`
+	Team   Team `gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE" json:"team,omitempty"`
+
+	// 规则内容
+	RuleType  autoApproveRuleType `gorm:"type:varchar(30);not null;index" json:"rule_type"`
+	RuleValue string              `gorm:"type:varchar(255);not null;comment:规则值" json:"rule_value"`
+	Enabled   bool                `gorm:"default:true;comment:是否启用" json:"enabled"`
+
+	// 创建人
+	CreatedBy uint `gorm:"not null;index;comment:创建人ID" json:"created_by"`
+	Creator   User `gorm:"foreignKey:CreatedBy;constraint:OnDelete:RESTRICT" json:"creator,omitempty"`
+
+	// 时间戳
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// TeamAutoApproveRule 入队自动审批规则 (公共类型别名)
+type TeamAutoApproveRule = teamAutoApproveRule
+
+// TableName 指定表名
+func (teamAutoApproveRule) TableName() string {
+	return "team_auto_approve_rules"
+}
+
+// teamStorageSnapshot 团队存储每日快照 (私有)
+type teamStorageSnapshot struct {
+	ID     uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	TeamID uint `gorm:"not null;uniqueIndex:idx_team_storage_snapshots_team_date" json:"team_id"`
+	Team   Team `gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE" json:"team,omitempty"`
+
+	// 快照日期
+	SnapshotDate time.Time `gorm:"type:date;not null;uniqueIndex:idx_team_storage_snapshots_team_date;comment:快照日期" json:"snapshot_date"`
+
+	// 用量数据
+	StorageUsed  int64 `gorm:"default:0;comment:已使用存储(字节)" json:"storage_used"`
+	StorageLimit int64 `gorm:"default:0;comment:存储上限(字节)" json:"storage_limit"`
+	FileCount    int   `gorm:"default:0;comment:文件数" json:"file_count"`
+	MemberCount  int   `gorm:"default:0;comment:成员数" json:"member_count"`
+
+	// 时间戳
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TeamStorageSnapshot 团队存储每日快照 (公共类型别名)
+type TeamStorageSnapshot = teamStorageSnapshot
+
+// TableName 指定表名
+func (teamStorageSnapshot) TableName() string {
+	return "team_storage_snapshots"
+}
+
+// teamStorageAlert 团队存储用量告警记录 (私有)
+type teamStorageAlert struct {
+	ID     uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	TeamID uint `gorm:"not null;index" json:"team_id"`
+	Team   Team `gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE" json:"team,omitempty"`
+
+	// 告警信息
+	Threshold    int   `gorm:"not null;index;comment:告警阈值(百分比)" json:"threshold"`
+	StorageUsed  int64 `gorm:"comment:告警时已使用存储(字节)" json:"storage_used"`
+	StorageLimit int64 `gorm:"comment:告警时存储上限(字节)" json:"storage_limit"`
+
+	// 用量回落到阈值以下后告警解除，再次超过时重新通知
+	ResolvedAt *time.Time `gorm:"index;comment:解除时间" json:"resolved_at"`
+
+	// 时间戳
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TeamStorageAlert 团队存储用量告警记录 (公共类型别名)
+type TeamStorageAlert = teamStorageAlert
+
+// TableName 指定表名
+func (teamStorageAlert) TableName() string {
+	return "team_storage_alerts"
+}
+
 // BeforeCreate GORM钩子：创建前
 func (t *Team) BeforeCreate(tx *gorm.DB) error {
 	// 设置默认值
@@ -235,3 +371,39 @@ func (tm *TeamMember) IsActive() bool {
 func (tm *TeamMember) CanManageTeam() bool {
 	return tm.IsActive() && (tm.Role == TeamMemberRoleOwner || tm.Role == TeamMemberRoleAdmin)
 }
+
+// GetStorageUsagePercent 获取团队存储使用百分比
+func (t *Team) GetStorageUsagePercent() float64 {
+	if t.StorageLimit == 0 {
+		return 0
+	}
+	return float64(t.StorageUsed) / float64(t.StorageLimit) * 100
+}
+
+// IsFull 检查团队成员是否已满
+func (t *Team) IsFull() bool {
+	return t.MaxMembers > 0 && t.MemberCount >= t.MaxMembers
+}
+
+// IsPending 检查入队申请是否待审批
+func (jr *teamJoinRequest) IsPending() bool {
+	return jr.Status == TeamJoinRequestStatusPending
+}
+
+// Match 检查用户邮箱是否命中自动审批规则
+func (r *teamAutoApproveRule) Match(email string) bool {
+	if !r.Enabled {
+		return false
+	}
+	switch r.RuleType {
+	case AutoApproveRuleEmailDomain:
+		at := strings.LastIndex(email, "@")
+		if at < 0 {
+			return false
+		}
+		domain := strings.TrimPrefix(strings.TrimSpace(r.RuleValue), "@")
+		return strings.EqualFold(email[at+1:], domain)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,25 @@
+package model
+
+import "testing"
+
+// TestAutoApproveRuleMatch 测试自动审批规则匹配
+func TestAutoApproveRuleMatch(t *testing.T) {
+	cases := []struct {
+		name  string
+		rule  TeamAutoApproveRule
+		email string
+		want  bool
+	}{
+		{"域名匹配", TeamAutoApproveRule{RuleType: AutoApproveRuleEmailDomain, RuleValue: "example.com", Enabled: true}, "alice@example.com", true},
+		{"忽略大小写", TeamAutoApproveRule{RuleType: AutoApproveRuleEmailDomain, RuleValue: "@Example.COM", Enabled: true}, "bob@example.com", true},
+		{"子域名不匹配", TeamAutoApproveRule{RuleType: AutoApproveRuleEmailDomain, RuleValue: "example.com", Enabled: true}, "carol@mail.example.com", false},
+		{"规则未启用", TeamAutoApproveRule{RuleType: AutoApproveRuleEmailDomain, RuleValue: "example.com"}, "dave@example.com", false},
+		{"邮箱格式错误", TeamAutoApproveRule{RuleType: AutoApproveRuleEmailDomain, RuleValue: "example.com", Enabled: true}, "example.com", false},
+	}
+
+	for _, tc := range cases {
+		if got := tc.rule.Match(tc.email); got != tc.want {
+			t.Errorf("%s: 期望 %t, 实际 %t", tc.name, tc.want, got)
+		}
+	}
+}
@@ -35,10 +35,12 @@ type User struct {
 	Phone        string     `gorm:"type:varchar(20);index" json:"phone"`
 	UserType     UserType   `gorm:"type:varchar(20);default:'normal';index" json:"user_type"`
 	Status       UserStatus `gorm:"type:varchar(20);default:'active';index" json:"status"`
+	Language     string     `gorm:"type:varchar(10);default:'zh-CN';comment:界面及通知语言" json:"language"`
 
 	// 存储配额相关
-	StorageQuota int64 `gorm:"default:5368709120;comment:存储配额(字节)" json:"storage_quota"` // 默认5GB
-	UsedStorage  int64 `gorm:"default:0;comment:已使用存储(字节)" json:"used_storage"`
+	StorageQuota       int64 `gorm:"default:5368709120;comment:存储配额(字节)" json:"storage_quota"` // 默认5GB
+	UsedStorage        int64 `gorm:"default:0;comment:已使用存储(字节)" json:"used_storage"`
+	StorageQuotaCustom bool  `gorm:"default:false;comment:存储配额是否单独设置" json:"storage_quota_custom"`
 
 	// 权限模板关联
 	PermissionTemplateID *uint               `gorm:"index;comment:权限模板ID" json:"permission_template_id"`
@@ -50,6 +52,14 @@ type User struct {
 	LoginFailCount int        `gorm:"default:0;comment:登录失败次数" json:"login_fail_count"`
 	LockedUntil    *time.Time `gorm:"comment:锁定到期时间" json:"locked_until"`
 
+	// 邮箱验证
+	EmailVerified   bool       `gorm:"default:false;index;comment:邮箱是否已验证" json:"email_verified"`
+	EmailVerifiedAt *time.Time `gorm:"comment:邮箱验证时间" json:"email_verified_at"`
+
+	// 令牌版本，重置密码后递增使此前签发的令牌全部失效
+	TokenVersion      int        `gorm:"default:0;comment:令牌版本" json:"-"`
+	PasswordChangedAt *time.Time `gorm:"comment:密码修改时间" json:"password_changed_at"`
+
 	// MFA相关
 	MFAEnabled bool   `gorm:"default:false;comment:是否启用MFA" json:"mfa_enabled"`
 	MFASecret  string `gorm:"type:varchar(255);comment:MFA密钥" json:"-"`
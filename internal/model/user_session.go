@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// userSession 用户登录会话，每个会话对应一个刷新令牌，只保存令牌的SHA-256摘要 (私有)
+type userSession struct {
+	// 时间戳字段 (24 bytes each)
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	LastActiveAt time.Time `gorm:"not null;comment:最后活跃时间" json:"last_active_at"`
+	ExpiresAt    time.Time `gorm:"not null;index;comment:刷新令牌过期时间" json:"expires_at"`
+
+	// 指针字段 (8 bytes each)
+	RevokedAt *time.Time `gorm:"index;comment:注销时间" json:"revoked_at"`
+
+	// 结构体字段
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+
+	// 字符串字段 (24 bytes each)
+	RefreshTokenHash string `gorm:"type:char(64);not null;uniqueIndex;comment:刷新令牌摘要" json:"-"`
+	RevokeReason     string `gorm:"type:varchar(50);comment:注销原因" json:"revoke_reason,omitempty"`
+
+	// 请求信息
+	IPAddress string `gorm:"type:varchar(45);comment:登录IP" json:"ip_address"`
+	UserAgent string `gorm:"type:varchar(500);comment:用户代理" json:"user_agent"`
+
+	// 地理位置信息
+	Country string `gorm:"type:varchar(100);comment:国家" json:"country"`
+	Region  string `gorm:"type:varchar(100);comment:地区" json:"region"`
+	City    string `gorm:"type:varchar(100);comment:城市" json:"city"`
+
+	// 设备信息
+	Device  string `gorm:"type:varchar(100);comment:设备类型" json:"device"`
+	OS      string `gorm:"type:varchar(100);comment:操作系统" json:"os"`
+	Browser string `gorm:"type:varchar(100);comment:浏览器" json:"browser"`
+
+	// uint字段 (8 bytes each)
+	ID     uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID uint `gorm:"not null;index;comment:用户ID" json:"user_id"`
+}
+
+// TableName 指定表名
+func (userSession) TableName() string {
+	return "user_sessions"
+}
+
+// UserSession 公共类型别名
+type UserSession = userSession
+
+// 会话注销原因
+const (
+	SessionRevokeLogout        = "logout"         // 用户主动退出
+	SessionRevokeByUser        = "revoked"        // 用户在其他设备上注销
+	SessionRevokeLogoutAll     = "logout_all"     // 退出全部设备
+	SessionRevokePasswordReset = "password_reset" // 重置密码
+	SessionRevokeByAdmin       = "admin"          // 管理员强制下线
+)
+
+// IsActive 检查会话是否未注销且未过期
+func (s *userSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
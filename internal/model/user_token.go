@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// UserTokenPurpose 用户令牌用途枚举
+type UserTokenPurpose string
+
+const (
+	UserTokenEmailVerify   UserTokenPurpose = "email_verify"   // 邮箱验证
+	UserTokenPasswordReset UserTokenPurpose = "password_reset" // 密码重置
+)
+
+// userToken 一次性用户令牌，只保存令牌的SHA-256摘要 (私有)
+type userToken struct {
+	// 时间戳字段 (24 bytes each)
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ExpiresAt time.Time `gorm:"not null;index;comment:过期时间" json:"expires_at"`
+
+	// 指针字段 (8 bytes each)
+	UsedAt *time.Time `gorm:"comment:使用时间" json:"used_at"`
+
+	// 结构体字段
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+
+	// 字符串字段 (24 bytes each)
+	TokenHash string           `gorm:"type:char(64);not null;uniqueIndex;comment:令牌摘要" json:"-"`
+	Purpose   UserTokenPurpose `gorm:"type:varchar(30);not null;index:idx_user_tokens_user_purpose;comment:令牌用途" json:"purpose"`
+	IPAddress string           `gorm:"type:varchar(45);comment:申请IP" json:"ip_address"`
+
+	// uint字段 (8 bytes each)
+	ID     uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID uint `gorm:"not null;index:idx_user_tokens_user_purpose;comment:用户ID" json:"user_id"`
+}
+
+// TableName 指定表名
+func (userToken) TableName() string {
+	return "user_tokens"
+}
+
+// UserToken 公共类型别名
+type UserToken = userToken
+
+// IsUsable 检查令牌是否未使用且未过期
+func (t *userToken) IsUsable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,359 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+)
+
+// 连接参数
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = (pongWait * 9) / 10
+	maxFrameSize   = 8 * 1024
+	sendBufferSize = 256
+	resumeBatch    = 200
+)
+
+// clientFrame 客户端上行帧 (私有)
+type clientFrame struct {
+	Type           EventType `json:"type"`
+	ConversationID uint      `json:"conversation_id"`
+	MessageID      uint      `json:"message_id"`
+}
+
+// Client 单个WebSocket连接
+type Client struct {
+	hub    *Hub
+	conn   *websocket.Conn
+	userID uint
+	send   chan []byte
+
+	// 断线重连补发期间到达的实时事件先暂存，补发完成后再按顺序投递
+	// replayed记录补发过的消息ID，暂存事件中已补发的消息据此跳过
+	mu        sync.Mutex
+	replaying bool
+	pending   []Event
+	replayed  map[uint]struct{}
+	closed    bool
+}
+
+// NewClient 创建客户端连接
+func NewClient(hub *Hub, conn *websocket.Conn, userID uint) *Client {
+	return &Client{
+		hub:    hub,
+		conn:   conn,
+		userID: userID,
+		send:   make(chan []byte, sendBufferSize),
+	}
+}
+
+// Serve 启动连接的读写循环，lastMessageID不为0时先补发该ID之后的消息
+func (c *Client) Serve(lastMessageID uint) {
+	c.mu.Lock()
+	c.replaying = lastMessageID > 0
+	c.mu.Unlock()
+
+	// 先注册再补发，保证补发期间产生的新消息不会丢失
+	c.hub.register(c)
+	go c.writePump()
+	if lastMessageID > 0 {
+		if err := c.resume(lastMessageID); err != nil {
+			log.Printf("用户 %d 消息补发失败: %v", c.userID, err)
+		}
+	}
+	c.readPump()
+}
+
+// deliver 投递事件到发送队列
+func (c *Client) deliver(event Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.replaying {
+		c.pending = append(c.pending, event)
+		return
+	}
+	c.enqueue(event)
+}
+
+// enqueue 写入发送队列，调用方需持有锁
+func (c *Client) enqueue(event Event) {
+	if c.closed {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("序列化实时事件失败: %v", err)
+		return
+	}
+	select {
+	case c.send <- payload:
+	default:
+		// 发送队列已满说明客户端消费过慢，断开后由客户端重连补发
+		c.closed = true
+		close(c.send)
+	}
+}
+
+// resume 补发lastMessageID之后用户所在会话的全部消息
+func (c *Client) resume(lastMessageID uint) error {
+	cursor := lastMessageID
+	for {
+		var messages []model.Message
+		err := c.hub.db.
+			Where("id > ? AND conversation_id IN (?)", cursor,
+				c.hub.db.Model(&model.ConversationMember{}).Select("conversation_id").Where("user_id = ?", c.userID)).
+			Order("id").Limit(resumeBatch).Find(&messages).Error
+		if err != nil {
+			c.finishReplay()
+			return fmt.Errorf("查询补发消息失败: %w", err)
+		}
+
+		c.mu.Lock()
+		for i := range messages {
+			event, err := NewMessageEvent(EventMessageNew, &messages[i], messages[i].SenderID)
+			if err != nil {
+				continue
+			}
+			c.enqueueReplayed(event)
+		}
+		c.mu.Unlock()
+
+		if len(messages) < resumeBatch {
+			break
+		}
+		cursor = messages[len(messages)-1].ID
+	}
+	c.finishReplay()
+	return nil
+}
+
+// enqueueReplayed 写入补发的消息并记录其ID，调用方需持有锁
+func (c *Client) enqueueReplayed(event Event) {
+	if c.replayed == nil {
+		c.replayed = make(map[uint]struct{})
+	}
+	c.replayed[event.MessageID] = struct{}{}
+	c.enqueue(event)
+}
+
+// finishReplay 结束补发并投递补发期间暂存的事件，跳过已经补发过的消息
+// 不同会话的消息提交顺序与ID顺序可能不一致，因此只按补发过的ID去重，补发结束后不再去重
+func (c *Client) finishReplay() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, event := range c.pending {
+		if _, ok := c.replayed[event.MessageID]; ok && event.Type == EventMessageNew {
+			continue
+		}
+		c.enqueue(event)
+	}
+	c.pending = nil
+	c.replayed = nil
+	c.replaying = false
+}
+
+// readPump 读取客户端上行帧
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.unregister(c)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxFrameSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var frame clientFrame
+		if err := c.conn.ReadJSON(&frame); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("用户 %d 连接异常断开: %v", c.userID, err)
+			}
+			return
+		}
+		if err := c.handleFrame(&frame); err != nil {
+			c.replyError(err)
+		}
+	}
+}
+
+// writePump 将发送队列写入连接并定时发送心跳
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case payload, ok := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				_ = c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleFrame 处理客户端上行帧
+func (c *Client) handleFrame(frame *clientFrame) error {
+	if err := c.requireMember(frame.ConversationID); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch frame.Type {
+	case EventTyping:
+		event, err := NewEvent(EventTyping, frame.ConversationID, c.userID, nil)
+		if err != nil {
+			return err
+		}
+		return c.hub.PublishToConversation(ctx, frame.ConversationID, c.userID, event)
+	case EventRead:
+		if frame.MessageID == 0 {
+			return fmt.Errorf("缺少消息ID")
+		}
+		if err := MarkRead(c.hub.db, frame.ConversationID, c.userID, frame.MessageID); err != nil {
+			return err
+		}
+		event, err := NewEvent(EventRead, frame.ConversationID, c.userID, nil)
+		if err != nil {
+			return err
+		}
+		event.MessageID = frame.MessageID
+		return c.hub.PublishToConversation(ctx, frame.ConversationID, c.userID, event)
+	case EventDelivered:
+		if frame.MessageID == 0 {
+			return fmt.Errorf("缺少消息ID")
+		}
+		advanced, err := MarkDelivered(c.hub.db, frame.ConversationID, c.userID, frame.MessageID)
+		if err != nil || !advanced {
+			return err
+		}
+		event, err := NewEvent(EventDelivered, frame.ConversationID, c.userID, nil)
+		if err != nil {
+			return err
+		}
+		event.MessageID = frame.MessageID
+		return c.hub.PublishToConversation(ctx, frame.ConversationID, c.userID, event)
+	default:
+		return fmt.Errorf("不支持的帧类型: %s", frame.Type)
+	}
+}
+
+// requireMember 校验当前用户是否为会话成员
+func (c *Client) requireMember(conversationID uint) error {
+	var count int64
+	if err := c.hub.db.Model(&model.ConversationMember{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, c.userID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("查询会话成员失败: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("不是会话 %d 的成员", conversationID)
+	}
+	return nil
+}
+
+// replyError 向客户端返回错误事件
+func (c *Client) replyError(cause error) {
+	event, err := NewEvent(EventError, 0, 0, map[string]string{"message": cause.Error()})
+	if err != nil {
+		return
+	}
+	c.deliver(event)
+}
+
+// MarkRead 更新成员的已读位置并重新计算未读数，消息必须属于该会话
+func MarkRead(db *gorm.DB, conversationID, userID, messageID uint) error {
+	if err := requireConversationMessage(db, conversationID, messageID); err != nil {
+		return err
+	}
+
+	var unread int64
+	if err := db.Model(&model.Message{}).
+		Where("conversation_id = ? AND id > ? AND sender_id <> ?", conversationID, messageID, userID).
+		Count(&unread).Error; err != nil {
+		return fmt.Errorf("统计未读消息失败: %w", err)
+	}
+
+	err := db.Model(&model.ConversationMember{}).
+		Where("conversation_id = ? AND user_id = ? AND (last_read_message_id IS NULL OR last_read_message_id < ?)",
+			conversationID, userID, messageID).
+		Updates(map[string]interface{}{
+			"last_read_message_id": messageID,
+			"last_read_at":         time.Now(),
+			"unread_count":         unread,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("更新已读位置失败: %w", err)
+	}
+
+	// 已读的消息必然已送达
+	if _, err := markDelivered(db, conversationID, userID, messageID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarkDelivered 推进成员的送达位置，返回位置是否前移，消息必须属于该会话
+func MarkDelivered(db *gorm.DB, conversationID, userID, messageID uint) (bool, error) {
+	if err := requireConversationMessage(db, conversationID, messageID); err != nil {
+		return false, err
+	}
+	return markDelivered(db, conversationID, userID, messageID)
+}
+
+// markDelivered 只向前推进送达位置
+func markDelivered(db *gorm.DB, conversationID, userID, messageID uint) (bool, error) {
+	result := db.Model(&model.ConversationMember{}).
+		Where("conversation_id = ? AND user_id = ? AND (last_delivered_message_id IS NULL OR last_delivered_message_id < ?)",
+			conversationID, userID, messageID).
+		Updates(map[string]interface{}{
+			"last_delivered_message_id": messageID,
+			"last_delivered_at":         time.Now(),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("更新送达位置失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// requireConversationMessage 校验消息属于该会话
+// 已读和送达位置只会前移，不校验时客户端提交一个很大的ID就能把位置永久固定在所有消息之后
+func requireConversationMessage(db *gorm.DB, conversationID, messageID uint) error {
+	var count int64
+	if err := db.Model(&model.Message{}).
+		Where("id = ? AND conversation_id = ?", messageID, conversationID).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("查询消息失败: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("消息 %d 不属于会话 %d", messageID, conversationID)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package realtime
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestClientResumeWithoutGaps 测试断线补发期间的实时事件既不丢失也不重复
+func TestClientResumeWithoutGaps(t *testing.T) {
+	client := &Client{send: make(chan []byte, 16), replaying: true}
+
+	// 补发期间收到的实时事件先暂存
+	client.deliver(Event{Type: EventMessageNew, MessageID: 12})
+	client.deliver(Event{Type: EventTyping, ConversationID: 1})
+	client.deliver(Event{Type: EventMessageNew, MessageID: 13})
+	if len(client.send) != 0 {
+		t.Fatalf("补发完成前不应下发实时事件")
+	}
+
+	// 补发查询到的离线消息(包含已在暂存队列中的12)
+	client.mu.Lock()
+	client.enqueueReplayed(Event{Type: EventMessageNew, MessageID: 11})
+	client.enqueueReplayed(Event{Type: EventMessageNew, MessageID: 12})
+	client.mu.Unlock()
+	client.finishReplay()
+
+	got := drainEvents(t, client)
+
+	want := []struct {
+		eventType EventType
+		messageID uint
+	}{
+		{EventMessageNew, 11},
+		{EventMessageNew, 12},
+		{EventTyping, 0},
+		{EventMessageNew, 13},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("事件数量错误: 期望 %d, 实际 %d", len(want), len(got))
+	}
+	for i, w := range want {
+		if got[i].Type != w.eventType || got[i].MessageID != w.messageID {
+			t.Errorf("第 %d 个事件错误: 期望 %s/%d, 实际 %s/%d", i, w.eventType, w.messageID, got[i].Type, got[i].MessageID)
+		}
+	}
+}
+
+// TestClientDeliversOutOfOrderAfterReplay 测试补发结束后ID较小但较晚提交的消息仍然下发
+func TestClientDeliversOutOfOrderAfterReplay(t *testing.T) {
+	client := &Client{send: make(chan []byte, 16), replaying: true}
+	client.mu.Lock()
+	client.enqueueReplayed(Event{Type: EventMessageNew, MessageID: 20})
+	client.mu.Unlock()
+	client.finishReplay()
+
+	// 两个会话的消息提交顺序与ID顺序相反
+	client.deliver(Event{Type: EventMessageNew, ConversationID: 1, MessageID: 22})
+	client.deliver(Event{Type: EventMessageNew, ConversationID: 2, MessageID: 21})
+
+	got := drainEvents(t, client)
+	if len(got) != 3 || got[1].MessageID != 22 || got[2].MessageID != 21 {
+		t.Fatalf("下发的事件 = %+v, 期望 20, 22, 21", got)
+	}
+}
+
+// drainEvents 读出发送队列中的全部事件
+func drainEvents(t *testing.T, client *Client) []Event {
+	t.Helper()
+	var got []Event
+	for len(client.send) > 0 {
+		var event Event
+		if err := json.Unmarshal(<-client.send, &event); err != nil {
+			t.Fatalf("解析事件失败: %v", err)
+		}
+		got = append(got, event)
+	}
+	return got
+}
@@ -0,0 +1,118 @@
+// Package realtime 实现基于WebSocket的即时通讯网关
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"ycg_cloud/internal/model"
+)
+
+// EventType 实时事件类型
+type EventType string
+
+const (
+	EventMessageNew    EventType = "message.new"    // 新消息
+	EventMessageEdit   EventType = "message.edit"   // 消息编辑
+	EventMessageRecall EventType = "message.recall" // 消息撤回
+	EventTyping        EventType = "typing"         // 正在输入
+	EventRead          EventType = "read"           // 已读回执
+	EventDelivered     EventType = "delivered"      // 送达确认
+	EventMention       EventType = "mention"        // 被提及
+	EventError         EventType = "error"          // 错误提示
+)
+
+// Event 推送给客户端的实时事件
+type Event struct {
+	Type           EventType       `json:"type"`
+	ConversationID uint            `json:"conversation_id,omitempty"`
+	MessageID      uint            `json:"message_id,omitempty"`
+	UserID         uint            `json:"user_id,omitempty"`
+	Data           json.RawMessage `json:"data,omitempty"`
+	Timestamp      int64           `json:"timestamp"`
+}
+
+// envelope 跨实例分发的事件信封 (私有)
+type envelope struct {
+	Recipients []uint `json:"recipients"`
+	Event      Event  `json:"event"`
+}
+
+// NewEvent 创建实时事件
+func NewEvent(eventType EventType, conversationID, userID uint, data interface{}) (Event, error) {
+	event := Event{
+		Type:           eventType,
+		ConversationID: conversationID,
+		UserID:         userID,
+		Timestamp:      time.Now().UnixMilli(),
+	}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return event, fmt.Errorf("序列化事件数据失败: %w", err)
+		}
+		event.Data = raw
+	}
+	return event, nil
+}
+
+// MessagePayload 推送的消息内容
+type MessagePayload struct {
+	ID             uint       `json:"id"`
+	ConversationID uint       `json:"conversation_id"`
+	SenderID       uint       `json:"sender_id"`
+	Type           string     `json:"type"`
+	Status         string     `json:"status"`
+	Content        string     `json:"content"`
+	Metadata       string     `json:"metadata,omitempty"`
+	Mentions       string     `json:"mentions,omitempty"`
+	ReplyToID      *uint      `json:"reply_to_id,omitempty"`
+	ForwardFromID  *uint      `json:"forward_from_id,omitempty"`
+	FileID         *uint      `json:"file_id,omitempty"`
+	IsEdited       bool       `json:"is_edited"`
+	EditedAt       *time.Time `json:"edited_at,omitempty"`
+	IsRecalled     bool       `json:"is_recalled"`
+	RecalledAt     *time.Time `json:"recalled_at,omitempty"`
+	RecalledBy     *uint      `json:"recalled_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// NewMessagePayload 将消息模型转换为推送内容
+func NewMessagePayload(message *model.Message) MessagePayload {
+	payload := MessagePayload{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		Type:           string(message.Type),
+		Status:         string(message.Status),
+		Content:        message.Content,
+		Metadata:       message.Metadata,
+		Mentions:       message.Mentions,
+		ReplyToID:      message.ReplyToID,
+		ForwardFromID:  message.ForwardFromID,
+		FileID:         message.FileID,
+		IsEdited:       message.IsEdited,
+		EditedAt:       message.EditedAt,
+		IsRecalled:     message.IsRecalled(),
+		RecalledAt:     message.RecalledAt,
+		RecalledBy:     message.RecalledBy,
+		CreatedAt:      message.CreatedAt,
+	}
+	// 已撤回的消息不再下发原始内容
+	if payload.IsRecalled {
+		payload.Content = ""
+		payload.Metadata = ""
+	}
+	return payload
+}
+
+// NewMessageEvent 创建消息类事件(新消息、编辑、撤回)
+func NewMessageEvent(eventType EventType, message *model.Message, actorID uint) (Event, error) {
+	event, err := NewEvent(eventType, message.ConversationID, actorID, NewMessagePayload(message))
+	if err != nil {
+		return event, err
+	}
+	event.MessageID = message.ID
+	return event, nil
+}
@@ -0,0 +1,151 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"ycg_cloud/internal/model"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// eventChannel 跨实例事件分发的Redis频道
+const eventChannel = "ycg:im:events"
+
+// Hub 管理本实例的WebSocket连接并负责事件分发
+// 配置了Redis时事件经由pub/sub广播到所有实例，由持有连接的实例负责投递
+type Hub struct {
+	db    *gorm.DB
+	redis *redis.Client
+
+	mu      sync.RWMutex
+	clients map[uint]map[*Client]struct{}
+}
+
+// NewHub 创建连接中心，rdb为nil时仅在本实例内分发
+func NewHub(db *gorm.DB, rdb *redis.Client) *Hub {
+	return &Hub{
+		db:      db,
+		redis:   rdb,
+		clients: make(map[uint]map[*Client]struct{}),
+	}
+}
+
+// Run 订阅Redis频道并投递其他实例发布的事件，ctx取消后退出
+func (h *Hub) Run(ctx context.Context) {
+	if h.redis == nil {
+		return
+	}
+
+	pubsub := h.redis.Subscribe(ctx, eventChannel)
+	defer pubsub.Close()
+
+	channel := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-channel:
+			if !ok {
+				return
+			}
+			var env envelope
+			if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+				log.Printf("解析实时事件失败: %v", err)
+				continue
+			}
+			h.dispatch(&env)
+		}
+	}
+}
+
+// Publish 向指定用户发布事件
+func (h *Hub) Publish(ctx context.Context, recipients []uint, event Event) error {
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	env := envelope{Recipients: recipients, Event: event}
+	if h.redis == nil {
+		h.dispatch(&env)
+		return nil
+	}
+
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("序列化实时事件失败: %w", err)
+	}
+	if err := h.redis.Publish(ctx, eventChannel, payload).Err(); err != nil {
+		return fmt.Errorf("发布实时事件失败: %w", err)
+	}
+	return nil
+}
+
+// PublishToConversation 向会话的全部成员发布事件，excludeUserID不为0时跳过该用户
+func (h *Hub) PublishToConversation(ctx context.Context, conversationID, excludeUserID uint, event Event) error {
+	var memberIDs []uint
+	if err := h.db.WithContext(ctx).Model(&model.ConversationMember{}).
+		Where("conversation_id = ?", conversationID).
+		Pluck("user_id", &memberIDs).Error; err != nil {
+		return fmt.Errorf("查询会话成员失败: %w", err)
+	}
+
+	recipients := memberIDs[:0]
+	for _, memberID := range memberIDs {
+		if memberID != excludeUserID {
+			recipients = append(recipients, memberID)
+		}
+	}
+	return h.Publish(ctx, recipients, event)
+}
+
+// IsOnline 检查用户是否在本实例在线
+func (h *Hub) IsOnline(userID uint) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients[userID]) > 0
+}
+
+// register 注册客户端连接
+func (h *Hub) register(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.clients[client.userID] == nil {
+		h.clients[client.userID] = make(map[*Client]struct{})
+	}
+	h.clients[client.userID][client] = struct{}{}
+}
+
+// unregister 注销客户端连接
+func (h *Hub) unregister(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if clients, ok := h.clients[client.userID]; ok {
+		delete(clients, client)
+		if len(clients) == 0 {
+			delete(h.clients, client.userID)
+		}
+	}
+}
+
+// dispatch 将事件投递给本实例上的接收者连接
+func (h *Hub) dispatch(env *envelope) {
+	h.mu.RLock()
+	var targets []*Client
+	for _, userID := range env.Recipients {
+		for client := range h.clients[userID] {
+			targets = append(targets, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range targets {
+		client.deliver(env.Event)
+	}
+}
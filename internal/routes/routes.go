@@ -0,0 +1,232 @@
+// Package routes 负责注册HTTP路由
+package routes
+
+import (
+	"net/http"
+
+	"ycg_cloud/internal/handler"
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/realtime"
+	"ycg_cloud/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Register 注册业务路由，routesInfo用于生成路由清单
+func Register(api *gin.RouterGroup, db *gorm.DB, hub *realtime.Hub, routesInfo func() gin.RoutesInfo,
+	operationLogger *service.OperationLogger) {
+	authService := service.NewAuthService(db, nil)
+	guard := middleware.NewPermissionGuard(service.NewPermissionService(db).HasPermission)
+	authHandler := handler.NewAuthHandler(authService)
+	sessionHandler := handler.NewSessionHandler(authService)
+	notificationService := service.NewNotificationService(db,
+		service.NewInAppChannel(db, hub),
+		service.NewEmailChannel(db),
+		service.NewWebhookChannel(nil),
+	)
+	teamHandler := handler.NewTeamHandler(service.NewTeamService(db, hub, notificationService))
+	conversationHandler := handler.NewConversationHandler(
+		service.NewConversationService(db, hub),
+		service.NewMessageService(db, hub, notificationService),
+	)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	emailHandler := handler.NewEmailHandler(service.NewMailer(db))
+	adminUserHandler := handler.NewAdminUserHandler(service.NewAdminUserService(db))
+	templateHandler := handler.NewPermissionTemplateHandler(service.NewPermissionTemplateService(db))
+	roleHandler := handler.NewRoleHandler(service.NewRoleService(db))
+	accessReviewHandler := handler.NewAccessReviewHandler(service.NewAccessReviewService(db))
+	inventoryHandler := handler.NewRouteInventoryHandler(routesInfo, guard)
+	operationLogHandler := handler.NewOperationLogHandler(operationLogger)
+	logHandler := handler.NewLogHandler(service.NewLogQueryService(db))
+	wsHandler := handler.NewWSHandler(hub)
+
+	// 无需登录的路由
+	registerAuthRoutes(api, authHandler)
+
+	// 需要登录的路由
+	auth := api.Group("")
+	auth.Use(middleware.JWTAuth(authService.ValidateToken))
+
+	registerTeamRoutes(auth, teamHandler)
+	registerConversationRoutes(auth, guard, conversationHandler)
+	registerNotificationRoutes(auth, notificationHandler)
+	registerSessionRoutes(auth, authHandler, sessionHandler)
+	registerAdminRoutes(auth, guard, emailHandler, sessionHandler, inventoryHandler)
+	registerAdminUserRoutes(auth, guard, adminUserHandler)
+	registerPermissionTemplateRoutes(auth, guard, templateHandler)
+	registerRoleRoutes(auth, guard, roleHandler)
+	registerAccessReviewRoutes(auth, guard, accessReviewHandler)
+	registerOperationLogRoutes(auth, guard, operationLogHandler)
+	registerLogRoutes(auth, guard, logHandler)
+
+	// WebSocket连接单独认证，只有该路由接受查询参数中的令牌
+	api.GET("/ws", middleware.WebSocketAuth(authService.ValidateToken), wsHandler.Connect)
+}
+
+// registerAuthRoutes 注册认证路由
+func registerAuthRoutes(group *gin.RouterGroup, h *handler.AuthHandler) {
+	auth := group.Group("/auth")
+	auth.POST("/register", h.Register)
+	auth.POST("/login", h.Login)
+	auth.POST("/refresh", h.Refresh)
+	auth.POST("/verify-email", h.VerifyEmail)
+	auth.POST("/resend-verification", h.ResendVerification)
+	auth.POST("/password/forgot", h.ForgotPassword)
+	auth.POST("/password/reset", h.ResetPassword)
+}
+
+// registerSessionRoutes 注册登录会话路由
+func registerSessionRoutes(group *gin.RouterGroup, authHandler *handler.AuthHandler, h *handler.SessionHandler) {
+	group.POST("/auth/logout", authHandler.Logout)
+
+	// 模拟登录时不能查看或注销用户的登录会话
+	sessions := group.Group("/sessions", middleware.RejectImpersonation())
+	sessions.GET("", h.ListSessions)
+	sessions.DELETE("/:id", h.RevokeSession)
+	sessions.POST("/logout-all", h.LogoutAll)
+}
+
+// registerTeamRoutes 注册团队路由
+func registerTeamRoutes(group *gin.RouterGroup, h *handler.TeamHandler) {
+	teams := group.Group("/teams")
+	teams.POST("", h.CreateTeam)
+	teams.GET("/public", h.SearchPublicTeams)
+	teams.POST("/:id/archive", h.ArchiveTeam)
+	teams.DELETE("/:id/members/me", h.LeaveTeam)
+	teams.DELETE("/:id/members/:user_id", h.RemoveTeamMember)
+	teams.POST("/:id/join-requests", h.CreateJoinRequest)
+	teams.GET("/:id/join-requests", h.ListJoinRequests)
+	teams.PUT("/:id/join-requests/:request_id", h.ReviewJoinRequest)
+	teams.DELETE("/:id/join-requests/:request_id", h.CancelJoinRequest)
+	teams.GET("/:id/auto-approve-rules", h.ListAutoApproveRules)
+	teams.POST("/:id/auto-approve-rules", h.CreateAutoApproveRule)
+	teams.DELETE("/:id/auto-approve-rules/:rule_id", h.DeleteAutoApproveRule)
+	teams.GET("/:id/storage/report", h.GetStorageReport)
+}
+
+// registerConversationRoutes 注册会话与消息路由，法律保留和合规导出需要系统权限
+func registerConversationRoutes(group *gin.RouterGroup, guard *middleware.PermissionGuard, h *handler.ConversationHandler) {
+	conversations := group.Group("/conversations")
+	conversations.POST("", h.CreateConversation)
+	conversations.GET("", h.ListConversations)
+	conversations.GET("/:id", h.GetConversation)
+	conversations.GET("/:id/messages", h.ListMessages)
+	conversations.POST("/:id/messages", h.SendMessage)
+	conversations.POST("/:id/read", h.MarkRead)
+	conversations.GET("/:id/receipts", h.GetReceipts)
+	conversations.GET("/:id/messages/:message_id/readers", h.GetReaders)
+	conversations.PUT("/:id/recall-window", h.SetRecallWindow)
+	conversations.PUT("/:id/messages/:message_id", h.EditMessage)
+	conversations.GET("/:id/messages/:message_id/revisions", h.ListRevisions)
+	conversations.POST("/:id/messages/:message_id/recall", h.RecallMessage)
+	conversations.POST("/:id/messages/:message_id/save", h.SaveFileToDrive)
+	conversations.DELETE("/:id/members/me", h.LeaveConversation)
+	conversations.PUT("/:id/retention", h.SetRetention)
+	guard.Handle(conversations, http.MethodPut, "/:id/legal-hold",
+		middleware.RequiredPermission{ResourceType: model.ResourceTypeSystem, Action: model.PermissionSystemConfig},
+		h.SetLegalHold)
+	conversations.GET("/:id/export", h.ExportConversation)
+	guard.Handle(group, http.MethodGet, "/admin/conversations/:id/export",
+		middleware.RequiredPermission{ResourceType: model.ResourceTypeSystem, Action: model.PermissionLogView},
+		h.AuditExportConversation)
+	group.GET("/mentions", h.ListMentions)
+}
+
+// registerNotificationRoutes 注册通知中心路由
+func registerNotificationRoutes(group *gin.RouterGroup, h *handler.NotificationHandler) {
+	notifications := group.Group("/notifications")
+	notifications.GET("", h.ListNotifications)
+	notifications.GET("/unread-count", h.GetUnreadBadge)
+	notifications.POST("/read-all", h.MarkAllRead)
+	notifications.GET("/preferences", h.ListPreferences)
+	notifications.PUT("/preferences/:category", h.UpdatePreference)
+}
+
+// registerAdminRoutes 注册系统管理路由
+func registerAdminRoutes(group *gin.RouterGroup, guard *middleware.PermissionGuard, emailHandler *handler.EmailHandler,
+	sessionHandler *handler.SessionHandler, inventoryHandler *handler.RouteInventoryHandler) {
+	admin := group.Group("/admin")
+	guard.Handle(admin, http.MethodPost, "/email/test",
+		middleware.RequiredPermission{ResourceType: model.ResourceTypeSystem, Action: model.PermissionSystemConfig},
+		emailHandler.SendTestEmail)
+	guard.Handle(admin, http.MethodPost, "/users/:user_id/logout",
+		middleware.RequiredPermission{ResourceType: model.ResourceTypeSystem, Action: model.PermissionUserManage},
+		sessionHandler.ForceLogout)
+	guard.Handle(admin, http.MethodGet, "/routes",
+		middleware.RequiredPermission{ResourceType: model.ResourceTypeSystem, Action: model.PermissionSystemConfig},
+		inventoryHandler.ListRoutes)
+}
+
+// registerAdminUserRoutes 注册管理员用户管理路由，需要用户管理权限
+func registerAdminUserRoutes(group *gin.RouterGroup, guard *middleware.PermissionGuard, h *handler.AdminUserHandler) {
+	users := group.Group("/admin/users")
+	guard.Protect(users, model.ResourceTypeSystem, model.PermissionUserManage)
+	users.GET("", h.ListUsers)
+	users.POST("", h.CreateUser)
+	users.PUT("/:user_id/status", h.UpdateUserStatus)
+	users.PUT("/:user_id/quota", h.UpdateStorageQuota)
+	users.PUT("/:user_id/template", h.AssignTemplate)
+	users.POST("/:user_id/mfa/reset", h.ResetMFA)
+	users.POST("/:user_id/impersonate", h.Impersonate)
+}
+
+// registerPermissionTemplateRoutes 注册权限模板路由，需要用户管理权限
+func registerPermissionTemplateRoutes(group *gin.RouterGroup, guard *middleware.PermissionGuard,
+	h *handler.PermissionTemplateHandler) {
+	templates := group.Group("/admin/permission-templates")
+	guard.Protect(templates, model.ResourceTypeSystem, model.PermissionUserManage)
+	templates.GET("", h.ListTemplates)
+	templates.POST("", h.CreateTemplate)
+	templates.PUT("/:id", h.UpdateTemplate)
+	templates.POST("/:id/assign", h.AssignTemplate)
+}
+
+// registerRoleRoutes 注册角色路由
+// /roles 供用户查看自己的角色、团队管理员和文件夹所有者在其范围内授予角色，/admin/roles 供用户管理员管理全部授予
+func registerRoleRoutes(group *gin.RouterGroup, guard *middleware.PermissionGuard, h *handler.RoleHandler) {
+	roles := group.Group("/roles")
+	roles.GET("", h.ListRoles)
+	roles.GET("/assignments", h.ListAssignments)
+	roles.POST("/assignments", h.AssignRole)
+	roles.DELETE("/assignments/:id", h.RevokeRole)
+
+	admin := group.Group("/admin/roles")
+	guard.Protect(admin, model.ResourceTypeSystem, model.PermissionUserManage)
+	admin.GET("/assignments", h.ListAssignments)
+	admin.POST("/assignments", h.AssignRole)
+	admin.DELETE("/assignments/:id", h.RevokeRole)
+}
+
+// registerAccessReviewRoutes 注册访问审查路由
+// /access-review 供文件所有者和用户本人查看，/admin/access-review 供拥有相应系统权限的用户审查任意文件和用户
+func registerAccessReviewRoutes(group *gin.RouterGroup, guard *middleware.PermissionGuard, h *handler.AccessReviewHandler) {
+	review := group.Group("/access-review")
+	review.GET("/files/:file_id", h.FileAccess)
+	review.GET("/users/:user_id", h.UserAccess)
+
+	admin := group.Group("/admin/access-review")
+	guard.Handle(admin, http.MethodGet, "/files/:file_id",
+		middleware.RequiredPermission{ResourceType: model.ResourceTypeSystem, Action: model.PermissionLogView},
+		h.FileAccess)
+	guard.Handle(admin, http.MethodGet, "/users/:user_id",
+		middleware.RequiredPermission{ResourceType: model.ResourceTypeSystem, Action: model.PermissionUserManage},
+		h.UserAccess)
+}
+
+// registerOperationLogRoutes 注册操作日志路由，需要日志查看权限
+func registerOperationLogRoutes(group *gin.RouterGroup, guard *middleware.PermissionGuard, h *handler.OperationLogHandler) {
+	logs := group.Group("/admin/operation-logs")
+	guard.Protect(logs, model.ResourceTypeSystem, model.PermissionLogView)
+	logs.GET("/pipeline", h.PipelineStats)
+}
+
+// registerLogRoutes 注册日志查询与导出路由，需要日志查看权限
+func registerLogRoutes(group *gin.RouterGroup, guard *middleware.PermissionGuard, h *handler.LogHandler) {
+	logs := group.Group("/admin/logs")
+	guard.Protect(logs, model.ResourceTypeSystem, model.PermissionLogView)
+	logs.GET("/:kind", h.ListLogs)
+	logs.GET("/:kind/stats", h.LogStats)
+	logs.GET("/:kind/export", h.ExportLogs)
+}
@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"time"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// RegisterJobs 注册应用的后台定时任务
+func RegisterJobs(s *Scheduler, db *gorm.DB, config *model.Config) {
+	notificationService := service.NewNotificationService(db,
+		service.NewInAppChannel(db, nil),
+		service.NewEmailChannel(db),
+		service.NewWebhookChannel(nil),
+	)
+
+	teamService := service.NewTeamService(db, nil, notificationService)
+	s.Every("团队存储快照", time.Hour, teamService.SnapshotTeamStorage)
+
+	conversationService := service.NewConversationService(db, nil)
+	s.Every("消息保留清理", 6*time.Hour, conversationService.PurgeExpiredMessages)
+
+	mailer := service.NewMailer(db)
+	s.Every("邮件发件箱投递", time.Minute, mailer.DeliverPending)
+
+	authService := service.NewAuthService(db, nil)
+	s.Every("登录会话清理", 24*time.Hour, authService.PurgeSessions)
+
+	expiryService := service.NewPermissionExpiryService(db, notificationService)
+	s.Every("过期权限清理", time.Hour, expiryService.SweepExpiredGrants)
+	s.Every("权限到期提醒", 6*time.Hour, expiryService.NotifyExpiringGrants)
+
+	auditTrail := service.NewAuditTrail(db, config.Log.Audit.AnchorFile)
+	s.Every("审计链锚点导出", time.Hour, auditTrail.ExportAnchor)
+
+	retentionService := service.NewLogRetentionService(db, auditTrail)
+	s.Every("日志表按月滚动", time.Hour, retentionService.RollLogTables)
+	s.Every("日志归档清理", 24*time.Hour, retentionService.ArchiveExpiredLogs)
+
+	ruleEngine := service.NewSecurityRuleEngine(db)
+	s.Every("安全规则检测", time.Minute, ruleEngine.Evaluate)
+}
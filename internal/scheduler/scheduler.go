@@ -0,0 +1,82 @@
+// Package scheduler 提供后台定时任务调度
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job 定时任务函数
+type Job func(ctx context.Context) error
+
+// task 定时任务定义 (私有)
+type task struct {
+	name     string
+	interval time.Duration
+	job      Job
+}
+
+// Scheduler 定时任务调度器
+type Scheduler struct {
+	tasks []task
+	wg    sync.WaitGroup
+}
+
+// New 创建调度器
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every 注册按固定间隔执行的任务
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.tasks = append(s.tasks, task{name: name, interval: interval, job: job})
+}
+
+// Start 启动所有任务，ctx取消后任务停止
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go s.run(ctx, t)
+	}
+	log.Printf("定时任务调度器已启动，共 %d 个任务", len(s.tasks))
+}
+
+// Wait 等待所有任务退出
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// run 循环执行单个任务
+func (s *Scheduler) run(ctx context.Context, t task) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.execute(ctx, t)
+		}
+	}
+}
+
+// execute 执行一次任务，捕获panic避免影响其他任务
+func (s *Scheduler) execute(ctx context.Context, t task) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("定时任务 %s 发生panic: %v", t.name, r)
+		}
+	}()
+
+	start := time.Now()
+	if err := t.job(ctx); err != nil {
+		log.Printf("定时任务 %s 执行失败: %v", t.name, err)
+		return
+	}
+	log.Printf("定时任务 %s 执行完成，耗时 %s", t.name, time.Since(start))
+}
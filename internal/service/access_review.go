@@ -0,0 +1,695 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 访问来源
+const (
+	AccessSourceOwner          = "owner"           // 文件所有者
+	AccessSourceFilePermission = "file_permission" // 直接授予的文件权限
+	AccessSourceConversation   = "conversation"    // 会话中发送文件时自动授予
+	AccessSourceTeamFile       = "team_file"       // 共享给团队的文件
+	AccessSourceRole           = "role"            // 角色
+	AccessSourceTemplate       = "template"        // 权限模板
+	AccessSourceUserPermission = "user_permission" // 单独授予的用户权限
+	AccessSourceShareLink      = "share_link"      // 分享链接
+)
+
+// 访问主体类型
+const (
+	PrincipalTypeUser = "user" // 用户
+	PrincipalTypeLink = "link" // 持有分享链接的任何人
+)
+
+// fileActions 文件和文件夹权限的展示顺序
+var fileActions = []model.PermissionAction{
+	model.PermissionRead,
+	model.PermissionPreview,
+	model.PermissionDownload,
+	model.PermissionWrite,
+	model.PermissionUpload,
+	model.PermissionDelete,
+	model.PermissionShare,
+}
+
+// AccessEntry 访问审查条目：某个主体通过某个来源对某个资源拥有的权限
+type AccessEntry struct {
+	PrincipalType string                   `json:"principal_type"`
+	PrincipalID   *uint                    `json:"principal_id"`
+	PrincipalName string                   `json:"principal_name"`
+	ResourceType  model.ResourceType       `json:"resource_type"`
+	ResourceID    *uint                    `json:"resource_id"`
+	ResourceName  string                   `json:"resource_name"`
+	Source        string                   `json:"source"`
+	SourceDetail  string                   `json:"source_detail"`
+	Actions       []model.PermissionAction `json:"actions"`
+	ExpiresAt     *time.Time               `json:"expires_at"`
+}
+
+// AccessReport 访问审查报表
+type AccessReport struct {
+	Subject     string        `json:"subject"`
+	Entries     []AccessEntry `json:"entries"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+// AccessReviewService 访问审查服务
+type AccessReviewService struct {
+	db *gorm.DB
+}
+
+// NewAccessReviewService 创建访问审查服务
+func NewAccessReviewService(db *gorm.DB) *AccessReviewService {
+	return &AccessReviewService{db: db}
+}
+
+// FileAccess 列出所有对文件或文件夹拥有有效访问权限的主体，文件所有者和拥有日志查看权限的用户可以查看
+func (s *AccessReviewService) FileAccess(operatorID, fileID uint) (*AccessReport, error) {
+	file, err := getFile(s.db, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.OwnerID != operatorID {
+		if _, err := requireSystemPermission(s.db, operatorID, model.PermissionLogView); err != nil {
+			return nil, err
+		}
+	}
+
+	chain, err := fileChain(s.db, file)
+	if err != nil {
+		return nil, err
+	}
+	collector := newAccessCollector()
+	resource := fileResource(file)
+	collectors := []func(*gorm.DB, *model.File, []model.File, *accessCollector, AccessEntry) error{
+		collectFileOwner,
+		collectFilePermissions,
+		collectTeamFiles,
+		collectFileRoles,
+		collectFileUserPermissions,
+		collectShareLinks,
+	}
+	for _, collect := range collectors {
+		if err := collect(s.db, file, chain, collector, resource); err != nil {
+			return nil, err
+		}
+	}
+	return &AccessReport{Subject: file.Path, Entries: collector.result(), GeneratedAt: time.Now()}, nil
+}
+
+// UserAccess 列出用户能访问的全部文件和文件夹，只能查看自己或由用户管理员查看
+func (s *AccessReviewService) UserAccess(operatorID, userID uint) (*AccessReport, error) {
+	if operatorID != userID {
+		if _, err := requireSystemPermission(s.db, operatorID, model.PermissionUserManage); err != nil {
+			return nil, err
+		}
+	}
+	user, err := getUser(s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	collector := newAccessCollector()
+	principal := AccessEntry{PrincipalType: PrincipalTypeUser, PrincipalID: &user.ID, PrincipalName: user.Username}
+	collectors := []func(*gorm.DB, *model.User, *accessCollector, AccessEntry) error{
+		collectOwnedFiles,
+		collectUserFilePermissions,
+		collectUserTeamFiles,
+		collectUserRoles,
+		collectUserResourcePermissions,
+	}
+	for _, collect := range collectors {
+		if err := collect(s.db, user, collector, principal); err != nil {
+			return nil, err
+		}
+	}
+	return &AccessReport{Subject: user.Username, Entries: collector.result(), GeneratedAt: time.Now()}, nil
+}
+
+// WriteAccessCSV 将访问审查条目写出为CSV，权限之间以竖线分隔，用户名和文件名按日志导出的规则转义公式
+func WriteAccessCSV(w io.Writer, entries []AccessEntry) error {
+	writer := csv.NewWriter(w)
+	header := []string{"principal_type", "principal_id", "principal_name", "resource_type", "resource_id",
+		"resource_name", "source", "source_detail", "actions", "expires_at"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("写出CSV失败: %w", err)
+	}
+	for i := range entries {
+		e := &entries[i]
+		actions := make([]string, len(e.Actions))
+		for j, action := range e.Actions {
+			actions[j] = string(action)
+		}
+		expiresAt := ""
+		if e.ExpiresAt != nil {
+			expiresAt = e.ExpiresAt.Format(time.RFC3339)
+		}
+		record := []string{e.PrincipalType, formatOptionalID(e.PrincipalID), e.PrincipalName, string(e.ResourceType),
+			formatOptionalID(e.ResourceID), e.ResourceName, e.Source, e.SourceDetail, strings.Join(actions, "|"), expiresAt}
+		if err := writer.Write(escapeCSVRow(record)); err != nil {
+			return fmt.Errorf("写出CSV失败: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("写出CSV失败: %w", err)
+	}
+	return nil
+}
+
+// formatOptionalID 格式化可为空的ID
+func formatOptionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return fmt.Sprint(*id)
+}
+
+// collectFileOwner 文件所有者拥有全部权限
+func collectFileOwner(tx *gorm.DB, file *model.File, _ []model.File, c *accessCollector, resource AccessEntry) error {
+	owner, err := getUser(tx, file.OwnerID)
+	if err != nil {
+		return err
+	}
+	c.add(withUser(resource, owner), AccessSourceOwner, "", nil, fileActions...)
+	return nil
+}
+
+// collectFilePermissions 直接授予用户或团队的文件权限，团队权限展开为团队的活跃成员
+func collectFilePermissions(tx *gorm.DB, file *model.File, _ []model.File, c *accessCollector, resource AccessEntry) error {
+	var permissions []model.FilePermission
+	if err := activeFilePermissions(tx).Preload("User").Preload("Team").
+		Where("file_id = ?", file.ID).Find(&permissions).Error; err != nil {
+		return fmt.Errorf("查询文件权限失败: %w", err)
+	}
+
+	teamIDs := make([]uint, 0, len(permissions))
+	for i := range permissions {
+		if permissions[i].TeamID != nil {
+			teamIDs = append(teamIDs, *permissions[i].TeamID)
+		}
+	}
+	members, err := activeTeamMembers(tx, teamIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range permissions {
+		p := &permissions[i]
+		switch {
+		case p.User != nil:
+			source, detail := filePermissionSource(p)
+			c.add(withUser(resource, p.User), source, detail, p.ExpiresAt, p.Action)
+		case p.Team != nil:
+			for j := range members[p.Team.ID] {
+				c.add(withUser(resource, &members[p.Team.ID][j]), AccessSourceFilePermission, "团队: "+p.Team.Name, p.ExpiresAt, p.Action)
+			}
+		}
+	}
+	return nil
+}
+
+// collectTeamFiles 共享给团队的文件及其所在文件夹，团队的活跃成员获得共享时设置的权限
+func collectTeamFiles(tx *gorm.DB, _ *model.File, chain []model.File, c *accessCollector, resource AccessEntry) error {
+	var teamFiles []model.TeamFile
+	if err := tx.Preload("Team").Preload("File").
+		Where("file_id IN ?", chainIDs(chain)).Find(&teamFiles).Error; err != nil {
+		return fmt.Errorf("查询团队文件失败: %w", err)
+	}
+
+	teamIDs := make([]uint, 0, len(teamFiles))
+	for i := range teamFiles {
+		teamIDs = append(teamIDs, teamFiles[i].TeamID)
+	}
+	members, err := activeTeamMembers(tx, teamIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range teamFiles {
+		tf := &teamFiles[i]
+		detail := fmt.Sprintf("团队: %s, 共享: %s", tf.Team.Name, tf.File.Path)
+		actions := teamFileActions(tf.Permissions)
+		for j := range members[tf.TeamID] {
+			c.add(withUser(resource, &members[tf.TeamID][j]), AccessSourceTeamFile, detail, nil, actions...)
+		}
+	}
+	return nil
+}
+
+// collectFileRoles 全局角色和文件所在文件夹子树上的角色
+func collectFileRoles(tx *gorm.DB, file *model.File, chain []model.File, c *accessCollector, resource AccessEntry) error {
+	folders := chain
+	if !file.IsFolder() {
+		folders = chain[1:]
+	}
+	scopes := []RoleScope{globalScope}
+	for _, id := range chainIDs(folders) {
+		folderID := id
+		scopes = append(scopes, RoleScope{Type: model.ResourceTypeFolder, ID: &folderID})
+	}
+
+	var assignments []model.UserRole
+	if err := scopeQuery(activeUserRoles(tx), "user_roles.", scopes).
+		Preload("Role").Preload("User").Find(&assignments).Error; err != nil {
+		return fmt.Errorf("查询角色授予失败: %w", err)
+	}
+	roles, err := allRoles(tx)
+	if err != nil {
+		return err
+	}
+
+	for i := range assignments {
+		a := &assignments[i]
+		actions := roleActions(roles, a.Role.Level, resource.ResourceType)
+		if len(actions) == 0 {
+			continue
+		}
+		detail := fmt.Sprintf("%s(%s)", a.Role.Name, scopeLabel(RoleScope{Type: a.ScopeType, ID: a.ScopeID}))
+		c.add(withUser(resource, &a.User), AccessSourceRole, detail, a.ExpiresAt, actions...)
+	}
+	return nil
+}
+
+// collectFileUserPermissions 针对该资源或该类资源全局授予的用户权限，包括来自权限模板的授权
+func collectFileUserPermissions(tx *gorm.DB, file *model.File, _ []model.File, c *accessCollector, resource AccessEntry) error {
+	var permissions []model.UserPermission
+	if err := activeUserPermissions(tx).Preload("User").
+		Where("resource_type = ? AND (resource_id = ? OR resource_id IS NULL)", resource.ResourceType, file.ID).
+		Find(&permissions).Error; err != nil {
+		return fmt.Errorf("查询用户权限失败: %w", err)
+	}
+	templateNames, err := templateNameMap(tx, permissions)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range effectiveGrants(permissions) {
+		source, detail := userPermissionSource(&p, templateNames)
+		c.add(withUser(resource, &p.User), source, detail, p.ExpiresAt, p.Action)
+	}
+	return nil
+}
+
+// collectShareLinks 文件本身或所在文件夹的有效分享链接，持有链接的任何人可以读取和下载
+func collectShareLinks(_ *gorm.DB, _ *model.File, chain []model.File, c *accessCollector, resource AccessEntry) error {
+	for i := range chain {
+		shared := &chain[i]
+		if !shared.IsShared() {
+			continue
+		}
+		detail := "共享: " + shared.Path
+		if shared.SharePassword != "" {
+			detail += ", 需要提取码"
+		}
+		entry := resource
+		entry.PrincipalType = PrincipalTypeLink
+		entry.PrincipalName = "分享链接"
+		c.add(entry, AccessSourceShareLink, detail, shared.ShareExpiry, fileGrantActions...)
+	}
+	return nil
+}
+
+// collectOwnedFiles 用户拥有的顶层文件和文件夹，文件夹包含其全部内容
+func collectOwnedFiles(tx *gorm.DB, user *model.User, c *accessCollector, principal AccessEntry) error {
+	var files []model.File
+	if err := tx.Where("owner_id = ? AND parent_id IS NULL AND status = ?", user.ID, model.FileStatusNormal).
+		Order("id ASC").Find(&files).Error; err != nil {
+		return fmt.Errorf("查询用户文件失败: %w", err)
+	}
+	for i := range files {
+		c.add(withFile(principal, &files[i]), AccessSourceOwner, "", nil, fileActions...)
+	}
+	return nil
+}
+
+// collectUserFilePermissions 直接授予用户或用户所在团队的文件权限
+func collectUserFilePermissions(tx *gorm.DB, user *model.User, c *accessCollector, principal AccessEntry) error {
+	teams := userTeams(tx, user.ID)
+	var permissions []model.FilePermission
+	if err := activeFilePermissions(tx).Preload("File").Preload("Team").
+		Where("user_id = ? OR team_id IN (?)", user.ID, teams.Select("team_id")).
+		Find(&permissions).Error; err != nil {
+		return fmt.Errorf("查询文件权限失败: %w", err)
+	}
+
+	for i := range permissions {
+		p := &permissions[i]
+		if p.File.ID == 0 {
+			continue
+		}
+		source, detail := filePermissionSource(p)
+		if p.Team != nil {
+			detail = "团队: " + p.Team.Name
+		}
+		c.add(withFile(principal, &p.File), source, detail, p.ExpiresAt, p.Action)
+	}
+	return nil
+}
+
+// collectUserTeamFiles 用户所在团队共享的文件
+func collectUserTeamFiles(tx *gorm.DB, user *model.User, c *accessCollector, principal AccessEntry) error {
+	var teamFiles []model.TeamFile
+	if err := tx.Preload("Team").Preload("File").
+		Where("team_id IN (?)", userTeams(tx, user.ID).Select("team_id")).
+		Find(&teamFiles).Error; err != nil {
+		return fmt.Errorf("查询团队文件失败: %w", err)
+	}
+	for i := range teamFiles {
+		tf := &teamFiles[i]
+		if tf.File.ID == 0 {
+			continue
+		}
+		c.add(withFile(principal, &tf.File), AccessSourceTeamFile, "团队: "+tf.Team.Name, nil, teamFileActions(tf.Permissions)...)
+	}
+	return nil
+}
+
+// collectUserRoles 用户的角色授予，资源为角色的生效范围
+func collectUserRoles(tx *gorm.DB, user *model.User, c *accessCollector, principal AccessEntry) error {
+	var assignments []model.UserRole
+	if err := activeUserRoles(tx).Preload("Role").
+		Where("user_roles.user_id = ?", user.ID).Find(&assignments).Error; err != nil {
+		return fmt.Errorf("查询角色授予失败: %w", err)
+	}
+	roles, err := allRoles(tx)
+	if err != nil {
+		return err
+	}
+
+	for i := range assignments {
+		a := &assignments[i]
+		actions := append(roleActions(roles, a.Role.Level, model.ResourceTypeFile),
+			roleActions(roles, a.Role.Level, model.ResourceTypeFolder)...)
+		if len(actions) == 0 {
+			continue
+		}
+		entry := principal
+		entry.ResourceType = a.ScopeType
+		entry.ResourceID = a.ScopeID
+		entry.ResourceName = scopeLabel(RoleScope{Type: a.ScopeType, ID: a.ScopeID})
+		c.add(entry, AccessSourceRole, a.Role.Name, a.ExpiresAt, actions...)
+	}
+	return nil
+}
+
+// collectUserResourcePermissions 授予用户的文件和文件夹权限，未指定资源的权限对该类资源全局生效
+func collectUserResourcePermissions(tx *gorm.DB, user *model.User, c *accessCollector, principal AccessEntry) error {
+	var permissions []model.UserPermission
+	if err := activeUserPermissions(tx).
+		Where("user_id = ? AND resource_type IN ?", user.ID,
+			[]model.ResourceType{model.ResourceTypeFile, model.ResourceTypeFolder}).
+		Find(&permissions).Error; err != nil {
+		return fmt.Errorf("查询用户权限失败: %w", err)
+	}
+	templateNames, err := templateNameMap(tx, permissions)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range effectiveGrants(permissions) {
+		entry := principal
+		entry.ResourceType = p.ResourceType
+		entry.ResourceID = p.ResourceID
+		entry.ResourceName = scopeLabel(RoleScope{Type: p.ResourceType, ID: p.ResourceID})
+		source, detail := userPermissionSource(&p, templateNames)
+		c.add(entry, source, detail, p.ExpiresAt, p.Action)
+	}
+	return nil
+}
+
+// fileChain 获取文件本身及其全部祖先文件夹，文件本身在第一个
+func fileChain(tx *gorm.DB, file *model.File) ([]model.File, error) {
+	chain := []model.File{*file}
+	parentID := file.ParentID
+	for depth := 0; parentID != nil && depth < maxFolderDepth; depth++ {
+		var parent model.File
+		if err := tx.First(&parent, *parentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				break
+			}
+			return nil, fmt.Errorf("查询文件夹失败: %w", err)
+		}
+		chain = append(chain, parent)
+		parentID = parent.ParentID
+	}
+	return chain, nil
+}
+
+// chainIDs 提取文件链的ID
+func chainIDs(chain []model.File) []uint {
+	ids := make([]uint, len(chain))
+	for i := range chain {
+		ids[i] = chain[i].ID
+	}
+	return ids
+}
+
+// activeFilePermissions 未过期的允许类文件权限查询
+func activeFilePermissions(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&model.FilePermission{}).Where("allowed = ?", true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now())
+}
+
+// activeUserPermissions 未过期的用户权限查询，包括显式拒绝以便计算优先级
+func activeUserPermissions(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&model.UserPermission{}).Where("expires_at IS NULL OR expires_at > ?", time.Now())
+}
+
+// userTeams 用户作为活跃成员所在团队的查询
+func userTeams(tx *gorm.DB, userID uint) *gorm.DB {
+	return tx.Model(&model.TeamMember{}).Where("user_id = ? AND status = ?", userID, model.TeamMemberStatusActive)
+}
+
+// activeTeamMembers 按团队分组获取活跃成员
+func activeTeamMembers(tx *gorm.DB, teamIDs []uint) (map[uint][]model.User, error) {
+	members := make(map[uint][]model.User)
+	if len(teamIDs) == 0 {
+		return members, nil
+	}
+	var rows []model.TeamMember
+	if err := tx.Preload("User").Where("team_id IN ? AND status = ?", teamIDs, model.TeamMemberStatusActive).
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询团队成员失败: %w", err)
+	}
+	for i := range rows {
+		if rows[i].User.ID != 0 {
+			members[rows[i].TeamID] = append(members[rows[i].TeamID], rows[i].User)
+		}
+	}
+	return members, nil
+}
+
+// allRoles 获取全部角色，用于按级别计算继承的权限
+func allRoles(tx *gorm.DB) ([]model.Role, error) {
+	var roles []model.Role
+	if err := tx.Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	return roles, nil
+}
+
+// templateNameMap 获取权限来源模板的名称
+func templateNameMap(tx *gorm.DB, permissions []model.UserPermission) (map[uint]string, error) {
+	var ids []uint
+	for i := range permissions {
+		if permissions[i].TemplateID != nil {
+			ids = append(ids, *permissions[i].TemplateID)
+		}
+	}
+	names := make(map[uint]string)
+	if len(ids) == 0 {
+		return names, nil
+	}
+	var templates []model.PermissionTemplate
+	if err := tx.Unscoped().Select("id, name").Where("id IN ?", ids).Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("查询权限模板失败: %w", err)
+	}
+	for _, template := range templates {
+		names[template.ID] = template.Name
+	}
+	return names, nil
+}
+
+// filePermissionSource 文件权限的来源，会话中自动授予的权限记录来源消息
+func filePermissionSource(p *model.FilePermission) (source, detail string) {
+	if p.SourceMessageID != nil {
+		return AccessSourceConversation, fmt.Sprintf("消息#%d", *p.SourceMessageID)
+	}
+	return AccessSourceFilePermission, ""
+}
+
+// userPermissionSource 用户权限的来源，来自模板的权限记录模板名称
+func userPermissionSource(p *model.UserPermission, templateNames map[uint]string) (source, detail string) {
+	if p.TemplateID != nil {
+		return AccessSourceTemplate, templateNames[*p.TemplateID]
+	}
+	return AccessSourceUserPermission, ""
+}
+
+// effectiveGrants 按优先级计算生效的用户权限：同一用户、资源和操作上单独授权优先于模板授权，只返回允许的权限
+func effectiveGrants(permissions []model.UserPermission) []model.UserPermission {
+	sorted := make([]model.UserPermission, len(permissions))
+	copy(sorted, permissions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].IsOverride() != sorted[j].IsOverride() {
+			return sorted[i].IsOverride()
+		}
+		return sorted[i].ID > sorted[j].ID
+	})
+
+	seen := make(map[string]bool, len(sorted))
+	var grants []model.UserPermission
+	for _, p := range sorted {
+		key := fmt.Sprintf("%d|%s|%s|%s", p.UserID, p.ResourceType, formatOptionalID(p.ResourceID), p.Action)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if p.Allowed {
+			grants = append(grants, p)
+		}
+	}
+	return grants
+}
+
+// roleActions 计算某级别角色对资源类型拥有的权限，包括级别不高于它的角色的权限
+func roleActions(roles []model.Role, level int, resourceType model.ResourceType) []model.PermissionAction {
+	var actions []model.PermissionAction
+	for i := range roles {
+		if roles[i].Level > level {
+			continue
+		}
+		permissions, err := roles[i].ParsePermissions()
+		if err != nil {
+			continue
+		}
+		for _, permission := range permissions {
+			if permission.ResourceType == resourceType {
+				actions = append(actions, permission.Action)
+			}
+		}
+	}
+	return actions
+}
+
+// teamFileActions 解析团队文件的权限配置(JSON数组)，未配置或无法解析时为只读权限
+func teamFileActions(raw string) []model.PermissionAction {
+	var actions []model.PermissionAction
+	if strings.TrimSpace(raw) == "" || json.Unmarshal([]byte(raw), &actions) != nil || len(actions) == 0 {
+		return fileGrantActions
+	}
+	return actions
+}
+
+// fileResource 以文件为资源的条目模板
+func fileResource(file *model.File) AccessEntry {
+	return withFile(AccessEntry{}, file)
+}
+
+// withFile 设置条目的资源为文件
+func withFile(entry AccessEntry, file *model.File) AccessEntry {
+	entry.ResourceType = model.ResourceTypeFile
+	if file.IsFolder() {
+		entry.ResourceType = model.ResourceTypeFolder
+	}
+	id := file.ID
+	entry.ResourceID = &id
+	entry.ResourceName = file.Path
+	return entry
+}
+
+// withUser 设置条目的主体为用户
+func withUser(entry AccessEntry, user *model.User) AccessEntry {
+	id := user.ID
+	entry.PrincipalType = PrincipalTypeUser
+	entry.PrincipalID = &id
+	entry.PrincipalName = user.Username
+	return entry
+}
+
+// accessCollector 按主体、资源和来源合并访问条目
+type accessCollector struct {
+	entries []AccessEntry
+	index   map[string]int
+}
+
+// newAccessCollector 创建访问条目收集器
+func newAccessCollector() *accessCollector {
+	return &accessCollector{index: make(map[string]int)}
+}
+
+// add 添加权限，同一主体、资源和来源的权限合并，其中任一条永久有效时合并结果永久有效，否则取最晚的过期时间
+func (c *accessCollector) add(entry AccessEntry, source, detail string, expiresAt *time.Time, actions ...model.PermissionAction) {
+	entry.Source = source
+	entry.SourceDetail = detail
+	key := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s", entry.PrincipalType, formatOptionalID(entry.PrincipalID),
+		entry.ResourceType, formatOptionalID(entry.ResourceID), source, detail, entry.PrincipalName)
+
+	i, ok := c.index[key]
+	if !ok {
+		entry.Actions = nil
+		entry.ExpiresAt = expiresAt
+		c.entries = append(c.entries, entry)
+		i = len(c.entries) - 1
+		c.index[key] = i
+	} else if existing := c.entries[i].ExpiresAt; existing != nil && (expiresAt == nil || expiresAt.After(*existing)) {
+		c.entries[i].ExpiresAt = expiresAt
+	}
+	c.entries[i].Actions = mergeActions(c.entries[i].Actions, actions)
+}
+
+// result 返回按主体和来源排序的条目
+func (c *accessCollector) result() []AccessEntry {
+	entries := c.entries
+	if entries == nil {
+		entries = []AccessEntry{}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].PrincipalName != entries[j].PrincipalName {
+			return entries[i].PrincipalName < entries[j].PrincipalName
+		}
+		if entries[i].ResourceName != entries[j].ResourceName {
+			return entries[i].ResourceName < entries[j].ResourceName
+		}
+		return entries[i].Source < entries[j].Source
+	})
+	return entries
+}
+
+// mergeActions 合并权限并去重，已知权限按固定顺序排列，未知权限排在最后
+func mergeActions(current, added []model.PermissionAction) []model.PermissionAction {
+	set := make(map[model.PermissionAction]bool, len(current)+len(added))
+	for _, action := range current {
+		set[action] = true
+	}
+	for _, action := range added {
+		set[action] = true
+	}
+
+	merged := make([]model.PermissionAction, 0, len(set))
+	for _, action := range fileActions {
+		if set[action] {
+			merged = append(merged, action)
+			delete(set, action)
+		}
+	}
+	var others []model.PermissionAction
+	for action := range set {
+		others = append(others, action)
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i] < others[j] })
+	return append(merged, others...)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"ycg_cloud/internal/model"
+)
+
+// TestEffectiveGrants 测试单独授权优先于模板授权，显式拒绝不出现在结果中
+func TestEffectiveGrants(t *testing.T) {
+	templateID := uint(1)
+	permissions := []model.UserPermission{
+		{ID: 1, UserID: 5, ResourceType: model.ResourceTypeFile, Action: model.PermissionRead, Allowed: true, TemplateID: &templateID},
+		{ID: 2, UserID: 5, ResourceType: model.ResourceTypeFile, Action: model.PermissionDelete, Allowed: true, TemplateID: &templateID},
+		{ID: 3, UserID: 5, ResourceType: model.ResourceTypeFile, Action: model.PermissionDelete, Allowed: false},
+		{ID: 4, UserID: 6, ResourceType: model.ResourceTypeFile, Action: model.PermissionRead, Allowed: true},
+	}
+
+	grants := effectiveGrants(permissions)
+	if len(grants) != 2 {
+		t.Fatalf("期望 2 条生效权限, 实际 %d", len(grants))
+	}
+	for _, grant := range grants {
+		if grant.Action == model.PermissionDelete {
+			t.Error("单独拒绝应覆盖模板授权")
+		}
+	}
+}
+
+// TestAccessCollector 测试同一主体和来源的权限合并及过期时间合并
+func TestAccessCollector(t *testing.T) {
+	userID := uint(7)
+	entry := AccessEntry{PrincipalType: PrincipalTypeUser, PrincipalID: &userID, PrincipalName: "alice"}
+	soon := time.Now().Add(time.Hour)
+	later := soon.Add(time.Hour)
+
+	c := newAccessCollector()
+	c.add(entry, AccessSourceFilePermission, "", &soon, model.PermissionDownload)
+	c.add(entry, AccessSourceFilePermission, "", &later, model.PermissionRead, model.PermissionDownload)
+	c.add(entry, AccessSourceRole, "editor", nil, model.PermissionWrite)
+
+	entries := c.result()
+	if len(entries) != 2 {
+		t.Fatalf("期望 2 条条目, 实际 %d", len(entries))
+	}
+	direct := entries[0]
+	if len(direct.Actions) != 2 || direct.Actions[0] != model.PermissionRead || direct.Actions[1] != model.PermissionDownload {
+		t.Errorf("权限合并或排序不正确: %v", direct.Actions)
+	}
+	if direct.ExpiresAt == nil || !direct.ExpiresAt.Equal(later) {
+		t.Errorf("合并后的过期时间应为较晚的时间, 实际 %v", direct.ExpiresAt)
+	}
+}
+
+// TestTeamFileActions 测试团队文件权限解析
+func TestTeamFileActions(t *testing.T) {
+	if actions := teamFileActions(`["read","write"]`); len(actions) != 2 || actions[1] != model.PermissionWrite {
+		t.Errorf("解析团队文件权限失败: %v", actions)
+	}
+	for _, raw := range []string{"", "[]", "invalid"} {
+		if actions := teamFileActions(raw); len(actions) != len(fileGrantActions) {
+			t.Errorf("teamFileActions(%q) 应回退为只读权限, 实际 %v", raw, actions)
+		}
+	}
+}
+
+// TestWriteAccessCSV 测试CSV导出格式
+func TestWriteAccessCSV(t *testing.T) {
+	fileID := uint(3)
+	entries := []AccessEntry{{
+		PrincipalType: PrincipalTypeLink, PrincipalName: "分享链接",
+		ResourceType: model.ResourceTypeFolder, ResourceID: &fileID, ResourceName: "/Finance/2026",
+		Source: AccessSourceShareLink, SourceDetail: "共享: /Finance, 需要提取码",
+		Actions: []model.PermissionAction{model.PermissionRead, model.PermissionDownload},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteAccessCSV(&buf, entries); err != nil {
+		t.Fatalf("导出CSV失败: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("期望 2 行, 实际 %d", len(lines))
+	}
+	want := `link,,分享链接,folder,3,/Finance/2026,share_link,"共享: /Finance, 需要提取码",read|download,`
+	if lines[1] != want {
+		t.Errorf("CSV行 = %s, 期望 %s", lines[1], want)
+	}
+
+	buf.Reset()
+	entries[0].PrincipalName, entries[0].ResourceName = "@admin", "=cmd|' /C calc'!A0"
+	if err := WriteAccessCSV(&buf, entries); err != nil {
+		t.Fatalf("导出CSV失败: %v", err)
+	}
+	if !strings.Contains(buf.String(), `,'@admin,`) || !strings.Contains(buf.String(), `,'=cmd|' /C calc'!A0,`) {
+		t.Errorf("以公式字符开头的单元格未转义: %s", buf.String())
+	}
+}
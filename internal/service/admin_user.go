@@ -0,0 +1,365 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// 模拟登录配置
+const (
+	configKeyImpersonationTTL = "security.impersonation.ttl_minutes"
+	defaultImpersonationTTL   = 30
+	impersonationBannerFormat = "你正在以用户 %s 的身份操作，该会话由管理员 %s 发起，所有操作都会被记录"
+)
+
+// AdminUserService 管理员用户管理服务
+type AdminUserService struct {
+	db *gorm.DB
+}
+
+// NewAdminUserService 创建管理员用户管理服务
+func NewAdminUserService(db *gorm.DB) *AdminUserService {
+	return &AdminUserService{db: db}
+}
+
+// AdminUserQuery 用户列表查询条件
+type AdminUserQuery struct {
+	Keyword    string
+	Status     model.UserStatus
+	UserType   model.UserType
+	TemplateID *uint
+	Page       int
+	PageSize   int
+}
+
+// AdminCreateUserRequest 管理员创建用户请求
+type AdminCreateUserRequest struct {
+	Username             string         `json:"username" binding:"required,min=3,max=50,alphanum"`
+	Email                string         `json:"email" binding:"required,email,max=100"`
+	Password             string         `json:"password" binding:"required"`
+	Nickname             string         `json:"nickname" binding:"max=100"`
+	UserType             model.UserType `json:"user_type" binding:"omitempty,oneof=normal admin"`
+	StorageQuota         int64          `json:"storage_quota" binding:"min=0"`
+	PermissionTemplateID *uint          `json:"permission_template_id"`
+}
+
+// UpdateUserStatusRequest 修改用户状态请求
+type UpdateUserStatusRequest struct {
+	Status model.UserStatus `json:"status" binding:"required,oneof=active suspended"`
+	Reason string           `json:"reason" binding:"max=500"`
+}
+
+// ImpersonationResult 模拟登录结果，Banner需在前端醒目展示
+type ImpersonationResult struct {
+	AccessToken string      `json:"access_token"`
+	ExpiresAt   time.Time   `json:"expires_at"`
+	Banner      string      `json:"banner"`
+	User        *model.User `json:"user"`
+}
+
+// adminChange 管理操作的执行函数，返回待写入的审计日志
+type adminChange func(tx *gorm.DB, operator, target *model.User) (*model.OperationLog, error)
+
+// ListUsers 搜索和筛选用户
+func (s *AdminUserService) ListUsers(operatorID uint, query *AdminUserQuery) ([]model.User, int64, error) {
+	if _, err := requireSystemPermission(s.db, operatorID, model.PermissionUserManage); err != nil {
+		return nil, 0, err
+	}
+
+	db := s.db.Model(&model.User{})
+	if keyword := strings.TrimSpace(query.Keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		db = db.Where("username LIKE ? OR email LIKE ? OR nickname LIKE ?", like, like, like)
+	}
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.UserType != "" {
+		db = db.Where("user_type = ?", query.UserType)
+	}
+	if query.TemplateID != nil {
+		db = db.Where("permission_template_id = ?", *query.TemplateID)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计用户失败: %w", err)
+	}
+	var users []model.User
+	if err := db.Order("id DESC").Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).Find(&users).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询用户失败: %w", err)
+	}
+	return users, total, nil
+}
+
+// CreateUser 管理员创建用户，创建的用户无需邮箱验证即可登录
+func (s *AdminUserService) CreateUser(operatorID uint, req *AdminCreateUserRequest, ip string) (*model.User, error) {
+	if err := validatePassword(req.Password); err != nil {
+		return nil, err
+	}
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &model.User{
+		Username:           strings.TrimSpace(req.Username),
+		Email:              normalizeEmail(req.Email),
+		PasswordHash:       hash,
+		Nickname:           strings.TrimSpace(req.Nickname),
+		Language:           model.DefaultLocale,
+		UserType:           req.UserType,
+		Status:             model.UserStatusActive,
+		StorageQuota:       req.StorageQuota,
+		StorageQuotaCustom: req.StorageQuota > 0,
+		EmailVerified:      true,
+		EmailVerifiedAt:    &now,
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		operator, err := requireSystemPermission(tx, operatorID, model.PermissionUserManage)
+		if err != nil {
+			return err
+		}
+		// 只有管理员可以创建管理员
+		if user.UserType == model.UserTypeAdmin && !operator.IsAdmin() {
+			return ErrForbidden
+		}
+		if err := ensureUserUnique(tx, user.Username, user.Email); err != nil {
+			return err
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("创建用户失败: %w", err)
+		}
+		if err := applyCreateTemplate(tx, operator, user, req.PermissionTemplateID); err != nil {
+			return err
+		}
+		return recordAdminAction(tx, operator, user, &model.OperationLog{
+			Action:    model.ActionAdminUserCreate,
+			Title:     "创建用户",
+			IPAddress: ip,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUserStatus 停用或恢复用户，停用时用户的全部会话立即失效
+func (s *AdminUserService) UpdateUserStatus(operatorID, userID uint, req *UpdateUserStatusRequest, ip string) error {
+	return s.manage(operatorID, userID, ip, func(tx *gorm.DB, _, target *model.User) (*model.OperationLog, error) {
+		if target.Status == req.Status {
+			return nil, nil
+		}
+		if err := tx.Model(target).Update("status", req.Status).Error; err != nil {
+			return nil, fmt.Errorf("更新用户状态失败: %w", err)
+		}
+
+		entry := &model.OperationLog{
+			Action:      model.ActionAdminUserUpdate,
+			Title:       "恢复用户",
+			Description: req.Reason,
+		}
+		if req.Status == model.UserStatusSuspended {
+			if err := revokeAllSessions(tx, target.ID, model.SessionRevokeByAdmin); err != nil {
+				return nil, err
+			}
+			entry.Action = model.ActionAdminUserBlock
+			entry.Title = "停用用户"
+		}
+		return entry, nil
+	})
+}
+
+// UpdateStorageQuota 修改用户存储配额
+func (s *AdminUserService) UpdateStorageQuota(operatorID, userID uint, quota int64, ip string) error {
+	if quota <= 0 {
+		return ErrInvalidParam
+	}
+	return s.manage(operatorID, userID, ip, func(tx *gorm.DB, _, target *model.User) (*model.OperationLog, error) {
+		// 单独设置的配额不再随权限模板同步
+		if err := tx.Model(target).Updates(map[string]interface{}{
+			"storage_quota":        quota,
+			"storage_quota_custom": true,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("更新存储配额失败: %w", err)
+		}
+		return &model.OperationLog{
+			Action:      model.ActionAdminUserUpdate,
+			Title:       "修改存储配额",
+			Description: fmt.Sprintf("%d -> %d", target.StorageQuota, quota),
+		}, nil
+	})
+}
+
+// AssignTemplate 为用户应用权限模板，templateID为nil时取消模板并清除来自模板的权限
+func (s *AdminUserService) AssignTemplate(operatorID, userID uint, templateID *uint, ip string) error {
+	return s.manage(operatorID, userID, ip, func(tx *gorm.DB, operator, target *model.User) (*model.OperationLog, error) {
+		entry := &model.OperationLog{
+			Action:      model.ActionAdminUserUpdate,
+			Title:       "分配权限模板",
+			Description: "取消权限模板",
+		}
+		if templateID == nil {
+			if err := tx.Model(target).Update("permission_template_id", nil).Error; err != nil {
+				return nil, fmt.Errorf("更新权限模板失败: %w", err)
+			}
+			return entry, syncTemplatePermissions(tx, target.ID, nil)
+		}
+
+		template, err := loadTemplate(tx, *templateID)
+		if err != nil {
+			return nil, err
+		}
+		if err := requireGrantable(tx, operator, templatePermissionItems(template)); err != nil {
+			return nil, err
+		}
+		entry.Description = "权限模板: " + template.Name
+		return entry, applyTemplate(tx, target, template)
+	})
+}
+
+// ResetMFA 重置用户的多因素认证，用户需要重新绑定
+func (s *AdminUserService) ResetMFA(operatorID, userID uint, ip string) error {
+	return s.manage(operatorID, userID, ip, func(tx *gorm.DB, _, target *model.User) (*model.OperationLog, error) {
+		if err := tx.Model(target).Updates(map[string]interface{}{
+			"mfa_enabled": false,
+			"mfa_secret":  "",
+		}).Error; err != nil {
+			return nil, fmt.Errorf("重置MFA失败: %w", err)
+		}
+		return &model.OperationLog{
+			Action: model.ActionAdminUserUpdate,
+			Title:  "重置MFA",
+		}, nil
+	})
+}
+
+// Impersonate 以用户身份签发短期访问令牌用于排查问题，不签发刷新令牌
+func (s *AdminUserService) Impersonate(operatorID, userID uint, ip string) (*ImpersonationResult, error) {
+	var result *ImpersonationResult
+	err := s.manage(operatorID, userID, ip, func(tx *gorm.DB, operator, target *model.User) (*model.OperationLog, error) {
+		if !target.IsActive() {
+			return nil, ErrAccountDisabled
+		}
+
+		ttl := time.Duration(getConfigInt(tx, configKeyImpersonationTTL, defaultImpersonationTTL)) * time.Minute
+		token, err := utils.SignToken(&utils.Claims{
+			UserID:         target.ID,
+			Username:       target.Username,
+			UserType:       string(target.UserType),
+			Version:        target.TokenVersion,
+			ImpersonatorID: operator.ID,
+		}, ttl)
+		if err != nil {
+			return nil, err
+		}
+		result = &ImpersonationResult{
+			AccessToken: token,
+			ExpiresAt:   time.Now().Add(ttl),
+			Banner:      fmt.Sprintf(impersonationBannerFormat, target.Username, operator.Username),
+			User:        target,
+		}
+		return &model.OperationLog{
+			Type:          model.LogTypeSecurity,
+			Action:        model.ActionAdminImpersonate,
+			Title:         "模拟登录",
+			Description:   fmt.Sprintf("有效期 %d 分钟", int(ttl.Minutes())),
+			ImportantFlag: true,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// manage 在事务中校验操作人权限并执行管理操作，操作成功后写入审计日志
+// 操作人不能管理自己，非管理员不能管理管理员
+func (s *AdminUserService) manage(operatorID, userID uint, ip string, change adminChange) error {
+	if operatorID == userID {
+		return ErrForbidden
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		operator, err := requireSystemPermission(tx, operatorID, model.PermissionUserManage)
+		if err != nil {
+			return err
+		}
+		target, err := getUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := checkManageTarget(operator, target); err != nil {
+			return err
+		}
+
+		entry, err := change(tx, operator, target)
+		if err != nil || entry == nil {
+			return err
+		}
+		entry.IPAddress = ip
+		return recordAdminAction(tx, operator, target, entry)
+	})
+}
+
+// checkManageTarget 校验目标用户可以被操作人管理，已删除的用户视为不存在
+func checkManageTarget(operator, target *model.User) error {
+	if target.Status == model.UserStatusDeleted {
+		return ErrUserNotFound
+	}
+	if target.IsAdmin() && !operator.IsAdmin() {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// recordAdminAction 写入管理员操作日志，追加到审计哈希链
+func recordAdminAction(tx *gorm.DB, operator, target *model.User, entry *model.OperationLog) error {
+	entry.UserID = &operator.ID
+	entry.Username = operator.Username
+	if entry.Type == "" {
+		entry.Type = model.LogTypeAdmin
+	}
+	entry.Module = "admin"
+	entry.ResourceType = "user"
+	entry.ResourceID = &target.ID
+	entry.ResourceName = target.Username
+	return appendAuditLog(tx, entry)
+}
+
+// applyCreateTemplate 为新建用户应用指定的权限模板，未指定时应用默认模板
+// 指定的模板只能包含操作人自己拥有的权限
+func applyCreateTemplate(tx *gorm.DB, operator, user *model.User, templateID *uint) error {
+	if templateID == nil {
+		return applyDefaultTemplate(tx, user)
+	}
+	template, err := loadTemplate(tx, *templateID)
+	if err != nil {
+		return err
+	}
+	if err := requireGrantable(tx, operator, templatePermissionItems(template)); err != nil {
+		return err
+	}
+	return applyTemplate(tx, user, template)
+}
+
+// getPermissionTemplate 获取权限模板
+func getPermissionTemplate(tx *gorm.DB, templateID uint) (*model.PermissionTemplate, error) {
+	var template model.PermissionTemplate
+	if err := tx.First(&template, templateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, fmt.Errorf("查询权限模板失败: %w", err)
+	}
+	return &template, nil
+}
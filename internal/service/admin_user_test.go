@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"ycg_cloud/internal/model"
+)
+
+// TestAdminManageSelf 测试管理员不能修改自己的状态、配额或模拟自己，配额必须为正数
+func TestAdminManageSelf(t *testing.T) {
+	// 以下校验在访问数据库之前完成
+	s := NewAdminUserService(nil)
+	if err := s.UpdateUserStatus(1, 1, &UpdateUserStatusRequest{Status: model.UserStatusSuspended}, ""); !errors.Is(err, ErrForbidden) {
+		t.Errorf("停用自己 = %v, 期望 ErrForbidden", err)
+	}
+	if err := s.UpdateStorageQuota(1, 1, 1024, ""); !errors.Is(err, ErrForbidden) {
+		t.Errorf("修改自己的配额 = %v, 期望 ErrForbidden", err)
+	}
+	if _, err := s.Impersonate(1, 1, ""); !errors.Is(err, ErrForbidden) {
+		t.Errorf("模拟自己 = %v, 期望 ErrForbidden", err)
+	}
+	if err := s.UpdateStorageQuota(1, 2, 0, ""); !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("配额为0 = %v, 期望 ErrInvalidParam", err)
+	}
+}
+
+// TestCheckManageTarget 测试非管理员不能管理管理员，已删除的用户视为不存在
+func TestCheckManageTarget(t *testing.T) {
+	admin := &model.User{UserType: model.UserTypeAdmin, Status: model.UserStatusActive}
+	manager := &model.User{UserType: model.UserTypeNormal, Status: model.UserStatusActive}
+	normal := &model.User{UserType: model.UserTypeNormal, Status: model.UserStatusActive}
+	deleted := &model.User{UserType: model.UserTypeNormal, Status: model.UserStatusDeleted}
+
+	tests := []struct {
+		name     string
+		operator *model.User
+		target   *model.User
+		want     error
+	}{
+		{"管理员管理普通用户", admin, normal, nil},
+		{"管理员管理管理员", admin, admin, nil},
+		{"用户管理员管理普通用户", manager, normal, nil},
+		{"用户管理员管理管理员", manager, admin, ErrForbidden},
+		{"已删除的用户", admin, deleted, ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		if err := checkManageTarget(tt.operator, tt.target); !errors.Is(err, tt.want) {
+			t.Errorf("%s: %v, 期望 %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 审计哈希链相关配置
+const (
+	defaultAuditAnchorFile = "storage/audit/anchors.ndjson" // 未配置时的锚点导出文件
+	auditChainHeadID       = 1
+	auditVerifyBatchSize   = 500
+)
+
+// AuditAnchor 导出到文件的锚点，记录某一时刻链尾的序号和哈希，Archived表示该序号及之前的日志已归档
+type AuditAnchor struct {
+	Seq        uint64    `json:"seq"`
+	Hash       string    `json:"hash"`
+	AnchoredAt time.Time `json:"anchored_at"`
+	Archived   bool      `json:"archived,omitempty"`
+}
+
+// AuditVerifyResult 审计链校验结果，Valid为false时BrokenSeq为第一处断链的序号
+type AuditVerifyResult struct {
+	Valid          bool   `json:"valid"`
+	Checked        int64  `json:"checked"`
+	LastSeq        uint64 `json:"last_seq"`
+	HeadSeq        uint64 `json:"head_seq"`
+	ArchivedSeq    uint64 `json:"archived_seq"`
+	AnchorsChecked int    `json:"anchors_checked"`
+	BrokenSeq      uint64 `json:"broken_seq,omitempty"`
+	BrokenLogID    uint   `json:"broken_log_id,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// auditPayload 参与哈希计算的审计日志字段，字段顺序固定，不能随意调整
+type auditPayload struct {
+	Seq          uint64 `json:"seq"`
+	PrevHash     string `json:"prev_hash"`
+	UserID       *uint  `json:"user_id"`
+	Username     string `json:"username"`
+	Type         string `json:"type"`
+	Level        string `json:"level"`
+	Action       string `json:"action"`
+	Module       string `json:"module"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Content      string `json:"content"`
+	ResourceType string `json:"resource_type"`
+	ResourceID   *uint  `json:"resource_id"`
+	ResourceName string `json:"resource_name"`
+	Status       string `json:"status"`
+	Result       string `json:"result"`
+	IPAddress    string `json:"ip_address"`
+	CreatedAt    int64  `json:"created_at"`
+	// 之后加入的字段需使用omitempty，保证已有日志的哈希不变
+	ImpersonatorID *uint `json:"impersonator_id,omitempty"`
+}
+
+// auditHash 计算审计日志的哈希，包含上一条日志的哈希以形成链
+func auditHash(entry *model.OperationLog) string {
+	var seq uint64
+	if entry.ChainSeq != nil {
+		seq = *entry.ChainSeq
+	}
+	payload, _ := json.Marshal(auditPayload{
+		Seq:          seq,
+		PrevHash:     entry.PrevHash,
+		UserID:       entry.UserID,
+		Username:     entry.Username,
+		Type:         string(entry.Type),
+		Level:        string(entry.Level),
+		Action:       string(entry.Action),
+		Module:       entry.Module,
+		Title:        entry.Title,
+		Description:  entry.Description,
+		Content:      entry.Content,
+		ResourceType: entry.ResourceType,
+		ResourceID:   entry.ResourceID,
+		ResourceName: entry.ResourceName,
+		Status:       entry.Status,
+		Result:       entry.Result,
+		IPAddress:    entry.IPAddress,
+		CreatedAt:    entry.CreatedAt.Unix(),
+
+		ImpersonatorID: entry.ImpersonatorID,
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// appendAuditLog 在事务中追加一条审计日志，锁住链头保证序号连续，写入后不可修改或删除
+func appendAuditLog(tx *gorm.DB, entry *model.OperationLog) error {
+	head, err := lockAuditHead(tx)
+	if err != nil {
+		return err
+	}
+
+	seq := head.Seq + 1
+	entry.ChainSeq = &seq
+	entry.PrevHash = head.Hash
+	// 默认值需在计算哈希前确定，不能依赖BeforeCreate钩子
+	if entry.Level == "" {
+		entry.Level = model.LogLevelInfo
+	}
+	if entry.Status == "" {
+		entry.Status = "success"
+	}
+	entry.CreatedAt = time.Now().Truncate(time.Second)
+	entry.Hash = auditHash(entry)
+
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("写入审计日志失败: %w", err)
+	}
+	if err := tx.Model(head).Updates(map[string]interface{}{"seq": seq, "hash": entry.Hash}).Error; err != nil {
+		return fmt.Errorf("更新审计链头失败: %w", err)
+	}
+	return nil
+}
+
+// lockAuditHead 加锁读取审计链头，不存在时先创建
+func lockAuditHead(tx *gorm.DB) (*model.AuditChainHead, error) {
+	var head model.AuditChainHead
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&head, auditChainHeadID).Error
+	if err == nil {
+		return &head, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("读取审计链头失败: %w", err)
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.AuditChainHead{ID: auditChainHeadID}).Error; err != nil {
+		return nil, fmt.Errorf("创建审计链头失败: %w", err)
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&head, auditChainHeadID).Error; err != nil {
+		return nil, fmt.Errorf("读取审计链头失败: %w", err)
+	}
+	return &head, nil
+}
+
+// chainVerifier 按序号顺序逐条校验审计日志
+type chainVerifier struct {
+	lastSeq  uint64
+	lastHash string
+	anchors  map[uint64]string
+	checked  int
+}
+
+// check 校验下一条审计日志，返回断链原因，正常时返回空字符串
+func (v *chainVerifier) check(entry *model.OperationLog) string {
+	seq := *entry.ChainSeq
+	switch {
+	case seq != v.lastSeq+1:
+		return fmt.Sprintf("序号不连续，缺少第 %d 条", v.lastSeq+1)
+	case entry.PrevHash != v.lastHash:
+		return "上一条哈希不匹配"
+	case entry.Hash != auditHash(entry):
+		return "内容与哈希不匹配，记录已被修改"
+	case entry.DeletedAt.Valid:
+		// 软删除同样属于篡改
+		return "记录已被删除"
+	}
+	if anchored, ok := v.anchors[seq]; ok {
+		if anchored != entry.Hash {
+			return "哈希与导出的锚点不一致"
+		}
+		v.checked++
+	}
+	v.lastSeq, v.lastHash = seq, entry.Hash
+	return ""
+}
+
+// newChainVerifier 从归档边界开始校验，提供锚点时归档边界必须与归档时导出的锚点一致
+func newChainVerifier(head *model.AuditChainHead, anchors []AuditAnchor) (*chainVerifier, string) {
+	verifier := &chainVerifier{
+		lastSeq:  head.ArchivedSeq,
+		lastHash: head.ArchivedHash,
+		anchors:  make(map[uint64]string, len(anchors)),
+	}
+	boundaryAnchored := false
+	for _, anchor := range anchors {
+		if anchor.Seq > head.ArchivedSeq {
+			verifier.anchors[anchor.Seq] = anchor.Hash
+		} else if anchor.Archived && anchor.Seq == head.ArchivedSeq && anchor.Hash == head.ArchivedHash {
+			boundaryAnchored = true
+		}
+	}
+	if head.ArchivedSeq > 0 && len(anchors) > 0 && !boundaryAnchored {
+		return verifier, "归档边界与锚点不一致，链头可能被修改"
+	}
+	return verifier, ""
+}
+
+// finish 比对链头和锚点，检查链尾是否被截断
+func (v *chainVerifier) finish(result *AuditVerifyResult, head *model.AuditChainHead) *AuditVerifyResult {
+	result.LastSeq, result.AnchorsChecked = v.lastSeq, v.checked
+	if result.Reason != "" {
+		return result
+	}
+
+	switch {
+	case head.Seq != v.lastSeq || head.Hash != v.lastHash:
+		result.Reason = "链尾与链头记录不一致，末尾记录可能被删除"
+	case len(v.anchors) > v.checked:
+		result.Reason = "部分锚点对应的记录不存在，链可能被截断"
+	}
+	if result.Reason != "" {
+		result.BrokenSeq = v.lastSeq + 1
+		return result
+	}
+	result.Valid = true
+	return result
+}
+
+// AuditTrail 审计哈希链服务，负责校验链的完整性和导出锚点
+type AuditTrail struct {
+	db         *gorm.DB
+	anchorFile string
+}
+
+// NewAuditTrail 创建审计哈希链服务，anchorFile取自配置文件或命令行参数，为空时使用默认路径
+func NewAuditTrail(db *gorm.DB, anchorFile string) *AuditTrail {
+	if anchorFile == "" {
+		anchorFile = defaultAuditAnchorFile
+	}
+	return &AuditTrail{db: db, anchorFile: anchorFile}
+}
+
+// AnchorFile 锚点导出文件路径
+func (t *AuditTrail) AnchorFile() string {
+	return t.anchorFile
+}
+
+// Verify 从归档边界开始遍历审计链，报告第一处断链，anchors为之前导出的锚点，可为空
+func (t *AuditTrail) Verify(ctx context.Context, anchors []AuditAnchor) (*AuditVerifyResult, error) {
+	// 先读链头，校验范围以此为准，避免把校验期间新追加的日志误判为链尾不一致
+	head, err := t.head(ctx)
+	if err != nil {
+		return nil, err
+	}
+	verifier, reason := newChainVerifier(head, anchors)
+	result := &AuditVerifyResult{HeadSeq: head.Seq, ArchivedSeq: head.ArchivedSeq}
+	if reason != "" {
+		result.BrokenSeq, result.Reason = head.ArchivedSeq, reason
+		return verifier.finish(result, head), nil
+	}
+
+	// 审计日志分布在当前表和按月滚动的历史表中
+	source, err := logSource(t.db.WithContext(ctx), &model.OperationLog{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var entries []model.OperationLog
+		// 包含软删除的记录
+		if err := source.Unscoped().
+			Where("chain_seq > ? AND chain_seq <= ?", verifier.lastSeq, head.Seq).
+			Order("chain_seq ASC").Limit(auditVerifyBatchSize).
+			Find(&entries).Error; err != nil {
+			return nil, fmt.Errorf("查询审计日志失败: %w", err)
+		}
+		for i := range entries {
+			if reason := verifier.check(&entries[i]); reason != "" {
+				result.BrokenSeq, result.BrokenLogID, result.Reason = verifier.lastSeq+1, entries[i].ID, reason
+				return verifier.finish(result, head), nil
+			}
+			result.Checked++
+		}
+		if len(entries) < auditVerifyBatchSize {
+			break
+		}
+	}
+	return verifier.finish(result, head), nil
+}
+
+// head 读取审计链头，还没有审计日志时返回空链头
+func (t *AuditTrail) head(ctx context.Context) (*model.AuditChainHead, error) {
+	var head model.AuditChainHead
+	err := t.db.WithContext(ctx).First(&head, auditChainHeadID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("读取审计链头失败: %w", err)
+	}
+	return &head, nil
+}
+
+// ExportAnchor 把当前链尾的序号和哈希追加到锚点文件，链没有增长时不重复导出
+func (t *AuditTrail) ExportAnchor(ctx context.Context) error {
+	head, err := t.head(ctx)
+	if err != nil {
+		return err
+	}
+
+	path := t.AnchorFile()
+	anchors, err := LoadAuditAnchors(path)
+	if err != nil {
+		return err
+	}
+	if head.Seq == 0 || (len(anchors) > 0 && anchors[len(anchors)-1].Seq == head.Seq) {
+		return nil
+	}
+	return appendAnchor(path, AuditAnchor{Seq: head.Seq, Hash: head.Hash, AnchoredAt: time.Now()})
+}
+
+// appendAnchor 以追加方式写入一行锚点并落盘
+func appendAnchor(path string, anchor AuditAnchor) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("创建锚点目录失败: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("打开锚点文件失败: %w", err)
+	}
+	defer file.Close()
+
+	line, err := json.Marshal(anchor)
+	if err != nil {
+		return fmt.Errorf("序列化锚点失败: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入锚点文件失败: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("同步锚点文件失败: %w", err)
+	}
+	return nil
+}
+
+// LoadAuditAnchors 读取锚点文件，文件不存在时返回空列表
+func LoadAuditAnchors(path string) ([]AuditAnchor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("打开锚点文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var anchors []AuditAnchor
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var anchor AuditAnchor
+		if err := json.Unmarshal(scanner.Bytes(), &anchor); err != nil {
+			return nil, fmt.Errorf("锚点文件第 %d 行格式错误: %w", line, err)
+		}
+		anchors = append(anchors, anchor)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取锚点文件失败: %w", err)
+	}
+	return anchors, nil
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ycg_cloud/internal/model"
+)
+
+// buildChain 构造一条哈希正确的审计链
+func buildChain(n int) []model.OperationLog {
+	entries := make([]model.OperationLog, n)
+	prevHash := ""
+	for i := range entries {
+		seq := uint64(i + 1)
+		entries[i] = model.OperationLog{
+			ID:        uint(100 + i),
+			ChainSeq:  &seq,
+			PrevHash:  prevHash,
+			Username:  "admin",
+			Type:      model.LogTypeAdmin,
+			Level:     model.LogLevelInfo,
+			Action:    model.ActionPermissionRevoke,
+			Title:     "权限到期自动撤销",
+			Status:    "success",
+			CreatedAt: time.Date(2024, 5, 1, 12, 0, i, 0, time.UTC),
+		}
+		entries[i].Hash = auditHash(&entries[i])
+		prevHash = entries[i].Hash
+	}
+	return entries
+}
+
+// runVerifier 依次校验，返回第一处断链的下标和原因
+func runVerifier(entries []model.OperationLog, anchors map[uint64]string) (int, string) {
+	verifier := &chainVerifier{anchors: anchors}
+	for i := range entries {
+		if reason := verifier.check(&entries[i]); reason != "" {
+			return i, reason
+		}
+	}
+	return -1, ""
+}
+
+// TestAuditHashCoversContent 测试哈希覆盖内容和上一条哈希
+func TestAuditHashCoversContent(t *testing.T) {
+	entry := buildChain(1)[0]
+	original := auditHash(&entry)
+	if len(original) != 64 {
+		t.Fatalf("哈希长度 = %d, 期望 64", len(original))
+	}
+
+	modified := entry
+	modified.Description = "篡改"
+	if auditHash(&modified) == original {
+		t.Error("修改描述后哈希未变化")
+	}
+	relinked := entry
+	relinked.PrevHash = strings.Repeat("0", 64)
+	if auditHash(&relinked) == original {
+		t.Error("修改上一条哈希后哈希未变化")
+	}
+}
+
+// TestChainVerifier 测试审计链校验能定位第一处断链
+func TestChainVerifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(entries []model.OperationLog) []model.OperationLog
+		broken  int
+		keyword string
+	}{
+		{"完整的链", func(e []model.OperationLog) []model.OperationLog { return e }, -1, ""},
+		{"修改内容", func(e []model.OperationLog) []model.OperationLog {
+			e[2].Title = "篡改"
+			return e
+		}, 2, "已被修改"},
+		{"删除中间记录", func(e []model.OperationLog) []model.OperationLog {
+			return append(e[:1], e[2:]...)
+		}, 1, "序号不连续"},
+		{"修改后重算哈希", func(e []model.OperationLog) []model.OperationLog {
+			e[1].Title = "篡改"
+			e[1].Hash = auditHash(&e[1])
+			return e
+		}, 2, "上一条哈希不匹配"},
+		{"软删除", func(e []model.OperationLog) []model.OperationLog {
+			e[3].DeletedAt.Valid = true
+			return e
+		}, 3, "已被删除"},
+	}
+	for _, tt := range tests {
+		index, reason := runVerifier(tt.tamper(buildChain(5)), nil)
+		if index != tt.broken || !strings.Contains(reason, tt.keyword) {
+			t.Errorf("%s: 断链位置 = %d (%s), 期望 %d (%s)", tt.name, index, reason, tt.broken, tt.keyword)
+		}
+	}
+}
+
+// TestChainVerifierAnchors 测试整条链被重算后可以通过锚点发现
+func TestChainVerifierAnchors(t *testing.T) {
+	entries := buildChain(4)
+	anchors := map[uint64]string{2: entries[1].Hash}
+
+	// 篡改第一条后重算整条链，链本身仍然自洽
+	entries[0].Title = "篡改"
+	entries[0].Hash = auditHash(&entries[0])
+	for i := 1; i < len(entries); i++ {
+		entries[i].PrevHash = entries[i-1].Hash
+		entries[i].Hash = auditHash(&entries[i])
+	}
+	if index, _ := runVerifier(entries, nil); index != -1 {
+		t.Fatalf("重算后的链在 %d 处断开, 期望自洽", index)
+	}
+
+	index, reason := runVerifier(entries, anchors)
+	if index != 1 || !strings.Contains(reason, "锚点") {
+		t.Errorf("断链位置 = %d (%s), 期望在锚点处 1", index, reason)
+	}
+}
+
+// TestAuditAnchorFile 测试锚点文件的追加和读取
+func TestAuditAnchorFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "anchors.ndjson")
+
+	anchors, err := LoadAuditAnchors(path)
+	if err != nil || len(anchors) != 0 {
+		t.Fatalf("读取不存在的锚点文件 = %v, %v, 期望空列表", anchors, err)
+	}
+
+	for seq := uint64(1); seq <= 2; seq++ {
+		if err := appendAnchor(path, AuditAnchor{Seq: seq, Hash: strings.Repeat("a", 64), AnchoredAt: time.Now()}); err != nil {
+			t.Fatalf("写入锚点失败: %v", err)
+		}
+	}
+	anchors, err = LoadAuditAnchors(path)
+	if err != nil {
+		t.Fatalf("读取锚点失败: %v", err)
+	}
+	if len(anchors) != 2 || anchors[1].Seq != 2 {
+		t.Errorf("锚点 = %+v, 期望序号 1 和 2", anchors)
+	}
+}
+
+// TestChainVerifierArchived 测试归档后从归档边界继续校验，并用归档锚点确认边界
+func TestChainVerifierArchived(t *testing.T) {
+	entries := buildChain(5)
+	head := &model.AuditChainHead{Seq: 5, Hash: entries[4].Hash, ArchivedSeq: 2, ArchivedHash: entries[1].Hash}
+	remaining := entries[2:]
+
+	boundary := AuditAnchor{Seq: 2, Hash: entries[1].Hash, Archived: true}
+	tests := []struct {
+		name    string
+		anchors []AuditAnchor
+		invalid bool
+	}{
+		{"没有锚点", nil, false},
+		{"归档锚点一致", []AuditAnchor{{Seq: 1, Hash: entries[0].Hash}, boundary}, false},
+		{"缺少归档锚点", []AuditAnchor{{Seq: 4, Hash: entries[3].Hash}}, true},
+		{"归档锚点不一致", []AuditAnchor{{Seq: 2, Hash: entries[0].Hash, Archived: true}}, true},
+	}
+	for _, tt := range tests {
+		verifier, reason := newChainVerifier(head, tt.anchors)
+		if (reason != "") != tt.invalid {
+			t.Errorf("%s: 边界校验结果 = %q, 期望不通过 = %v", tt.name, reason, tt.invalid)
+			continue
+		}
+		if tt.invalid {
+			continue
+		}
+		for i := range remaining {
+			if reason := verifier.check(&remaining[i]); reason != "" {
+				t.Fatalf("%s: 归档后的链在序号 %d 断开: %s", tt.name, *remaining[i].ChainSeq, reason)
+			}
+		}
+		if result := verifier.finish(&AuditVerifyResult{}, head); !result.Valid {
+			t.Errorf("%s: 校验结果 = %+v, 期望完整", tt.name, result)
+		}
+	}
+}
+
+// TestAuditHashImpersonator 测试模拟登录发起人参与哈希，未设置时已有日志的哈希不变
+func TestAuditHashImpersonator(t *testing.T) {
+	entry := buildChain(1)[0]
+	original := auditHash(&entry)
+	payload, err := json.Marshal(auditPayload{})
+	if err != nil || strings.Contains(string(payload), "impersonator_id") {
+		t.Fatalf("未设置模拟登录发起人时不应写入该字段: %s", payload)
+	}
+
+	impersonatorID := uint(7)
+	entry.ImpersonatorID = &impersonatorID
+	if auditHash(&entry) == original {
+		t.Error("设置模拟登录发起人后哈希未变化")
+	}
+}
@@ -0,0 +1,443 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// 认证相关配置
+const (
+	configKeySiteURL          = "system.site_url"
+	configKeyVerifyTTL        = "security.email_verify.ttl_minutes"
+	configKeyLoginMaxFailures = "security.login.max_failures"
+	configKeyLoginLockMinutes = "security.login.lock_minutes"
+	defaultSiteURL            = "http://localhost:8080"
+	defaultVerifyTTLMinutes   = 24 * 60
+	defaultLoginMaxFailures   = 5
+	defaultLoginLockMinutes   = 15
+	minPasswordLength         = 8
+	maxPasswordLength         = 64
+	userTokenBytes            = 32
+)
+
+// AuthService 认证服务
+type AuthService struct {
+	db      *gorm.DB
+	locator IPLocator
+}
+
+// NewAuthService 创建认证服务，locator为nil时会话不记录公网IP的地理位置
+func NewAuthService(db *gorm.DB, locator IPLocator) *AuthService {
+	return &AuthService{db: db, locator: locator}
+}
+
+// RegisterRequest 注册请求
+type RegisterRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50,alphanum"`
+	Email    string `json:"email" binding:"required,email,max=100"`
+	Password string `json:"password" binding:"required"`
+	Nickname string `json:"nickname" binding:"max=100"`
+	Language string `json:"language" binding:"omitempty,oneof=zh-CN en-US"`
+}
+
+// LoginRequest 登录请求，Account可以是用户名或邮箱
+type LoginRequest struct {
+	Account  string `json:"account" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// LoginResult 登录结果
+type LoginResult struct {
+	AccessToken      string      `json:"access_token"`
+	RefreshToken     string      `json:"refresh_token"`
+	RefreshExpiresAt time.Time   `json:"refresh_expires_at"`
+	SessionID        uint        `json:"session_id"`
+	User             *model.User `json:"user"`
+}
+
+// Register 注册账号，账号在邮箱验证前保持未激活状态
+func (s *AuthService) Register(req *RegisterRequest, ip string) (*model.User, error) {
+	if err := validatePassword(req.Password); err != nil {
+		return nil, err
+	}
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Username:     strings.TrimSpace(req.Username),
+		Email:        normalizeEmail(req.Email),
+		PasswordHash: hash,
+		Nickname:     strings.TrimSpace(req.Nickname),
+		Language:     req.Language,
+		Status:       model.UserStatusInactive,
+	}
+	if user.Language == "" {
+		user.Language = model.DefaultLocale
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ensureUserUnique(tx, user.Username, user.Email); err != nil {
+			return err
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("创建用户失败: %w", err)
+		}
+		if err := applyDefaultTemplate(tx, user); err != nil {
+			return err
+		}
+		return sendVerificationEmail(tx, user, ip)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// VerifyEmail 使用验证令牌完成邮箱验证并激活账号
+func (s *AuthService) VerifyEmail(token string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := consumeUserToken(tx, token, model.UserTokenEmailVerify)
+		if err != nil {
+			return err
+		}
+		return markEmailVerified(tx, record.UserID)
+	})
+}
+
+// ResendVerification 重新发送验证邮件，邮箱不存在或已验证时静默成功，避免泄露账号信息
+func (s *AuthService) ResendVerification(email, ip string) error {
+	email = normalizeEmail(email)
+	limited, err := s.rateLimited(securityEventVerificationResend, email, ip)
+	if err != nil {
+		return err
+	}
+	if limited {
+		return ErrTooManyRequests
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := writeSecurityLog(tx, &model.SecurityLog{
+			Username:  email,
+			EventType: securityEventVerificationResend,
+			Severity:  model.LogLevelInfo,
+			Title:     "重新发送验证邮件",
+			SourceIP:  ip,
+		}); err != nil {
+			return err
+		}
+
+		var user model.User
+		err := tx.Where("email = ?", email).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("查询用户失败: %w", err)
+		}
+		if user.EmailVerified {
+			return nil
+		}
+		if err := invalidateUserTokens(tx, user.ID, model.UserTokenEmailVerify); err != nil {
+			return err
+		}
+		return sendVerificationEmail(tx, &user, ip)
+	})
+}
+
+// Login 使用用户名或邮箱登录，连续失败达到上限后锁定账号
+func (s *AuthService) Login(req *LoginRequest, client *ClientInfo) (*LoginResult, error) {
+	account := strings.TrimSpace(req.Account)
+	var user model.User
+	err := s.db.Where("username = ? OR email = ?", account, normalizeEmail(account)).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user.IsLocked() {
+		return nil, ErrAccountLocked
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		if err := s.recordLoginFailure(&user, client.IP); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+	if err := checkCanLogin(&user); err != nil {
+		return nil, err
+	}
+
+	var result *LoginResult
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"last_login_at":    time.Now(),
+			"last_login_ip":    client.IP,
+			"login_fail_count": 0,
+			"locked_until":     nil,
+		}).Error; err != nil {
+			return fmt.Errorf("更新登录信息失败: %w", err)
+		}
+		result, err = s.createSession(tx, &user, client)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ValidateToken 校验令牌对应的用户仍可用、令牌版本未失效且所属会话未注销，供认证中间件使用
+func (s *AuthService) ValidateToken(claims *utils.Claims) error {
+	var user model.User
+	if err := s.db.Select("id, status, token_version").First(&user, claims.UserID).Error; err != nil {
+		return ErrTokenRevoked
+	}
+	if !user.IsActive() || user.TokenVersion != claims.Version {
+		return ErrTokenRevoked
+	}
+	if claims.ImpersonatorID != 0 {
+		// 模拟登录令牌在发起人失去用户管理权限后立即失效
+		if _, err := requireSystemPermission(s.db, claims.ImpersonatorID, model.PermissionUserManage); err != nil {
+			return ErrTokenRevoked
+		}
+	}
+	if claims.SessionID == 0 {
+		return nil
+	}
+
+	var session model.UserSession
+	if err := s.db.Select("id, user_id, expires_at, revoked_at").First(&session, claims.SessionID).Error; err != nil {
+		return ErrTokenRevoked
+	}
+	if session.UserID != claims.UserID || !session.IsActive(time.Now()) {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// ensureUserUnique 校验用户名和邮箱未被占用
+func ensureUserUnique(tx *gorm.DB, username, email string) error {
+	var count int64
+	if err := tx.Model(&model.User{}).
+		Where("username = ? OR email = ?", username, email).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if count > 0 {
+		return ErrUserExists
+	}
+	return nil
+}
+
+// checkCanLogin 校验账号状态是否允许登录
+func checkCanLogin(user *model.User) error {
+	switch user.Status {
+	case model.UserStatusActive:
+		return nil
+	case model.UserStatusInactive:
+		if !user.EmailVerified {
+			return ErrEmailNotVerified
+		}
+		return ErrAccountDisabled
+	default:
+		return ErrAccountDisabled
+	}
+}
+
+// recordLoginFailure 记录登录失败，达到上限时锁定账号
+func (s *AuthService) recordLoginFailure(user *model.User, ip string) error {
+	maxFailures := getConfigInt(s.db, configKeyLoginMaxFailures, defaultLoginMaxFailures)
+	failures := user.LoginFailCount + 1
+	updates := map[string]interface{}{"login_fail_count": failures}
+	entry := &model.SecurityLog{
+		UserID:    &user.ID,
+		Username:  user.Username,
+		EventType: securityEventLoginFailed,
+		Title:     "登录失败",
+		SourceIP:  ip,
+	}
+	if maxFailures > 0 && failures >= maxFailures {
+		lockMinutes := getConfigInt(s.db, configKeyLoginLockMinutes, defaultLoginLockMinutes)
+		updates["locked_until"] = time.Now().Add(time.Duration(lockMinutes) * time.Minute)
+		updates["login_fail_count"] = 0
+		entry.EventType = securityEventAccountLocked
+		entry.Title = "连续登录失败，账号已锁定"
+		entry.ThreatLevel = "medium"
+		entry.BlockedFlag = true
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(updates).Error; err != nil {
+			return fmt.Errorf("更新登录失败次数失败: %w", err)
+		}
+		return writeSecurityLog(tx, entry)
+	})
+}
+
+// sendVerificationEmail 签发验证令牌并写入验证邮件
+func sendVerificationEmail(tx *gorm.DB, user *model.User, ip string) error {
+	ttl := getConfigInt(tx, configKeyVerifyTTL, defaultVerifyTTLMinutes)
+	token, err := issueUserToken(tx, user.ID, model.UserTokenEmailVerify, time.Duration(ttl)*time.Minute, ip)
+	if err != nil {
+		return err
+	}
+	_, err = enqueueEmail(tx, &EmailRequest{
+		To:       user.Email,
+		UserID:   &user.ID,
+		Template: EmailVerification,
+		Locale:   user.Language,
+		Data: map[string]interface{}{
+			"Username":      displayName(user),
+			"Link":          siteLink(tx, "/verify-email", token),
+			"ExpireMinutes": ttl,
+		},
+	})
+	return err
+}
+
+// markEmailVerified 标记邮箱已验证，未激活的账号同时激活
+func markEmailVerified(tx *gorm.DB, userID uint) error {
+	if err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"email_verified":    true,
+		"email_verified_at": time.Now(),
+	}).Error; err != nil {
+		return fmt.Errorf("更新邮箱验证状态失败: %w", err)
+	}
+	if err := tx.Model(&model.User{}).
+		Where("id = ? AND status = ?", userID, model.UserStatusInactive).
+		Update("status", model.UserStatusActive).Error; err != nil {
+		return fmt.Errorf("激活账号失败: %w", err)
+	}
+	return nil
+}
+
+// issueUserToken 签发一次性令牌，数据库只保存摘要
+func issueUserToken(tx *gorm.DB, userID uint, purpose model.UserTokenPurpose, ttl time.Duration, ip string) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	record := &model.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+		IPAddress: ip,
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return "", fmt.Errorf("保存令牌失败: %w", err)
+	}
+	return token, nil
+}
+
+// consumeUserToken 核销一次性令牌，令牌不存在、已使用或已过期时返回ErrInvalidToken
+func consumeUserToken(tx *gorm.DB, token string, purpose model.UserTokenPurpose) (*model.UserToken, error) {
+	hash := hashToken(strings.TrimSpace(token))
+	now := time.Now()
+	// 条件更新保证并发请求中只有一个能核销成功
+	result := tx.Model(&model.UserToken{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, purpose, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, fmt.Errorf("核销令牌失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	var record model.UserToken
+	if err := tx.Where("token_hash = ?", hash).First(&record).Error; err != nil {
+		return nil, fmt.Errorf("查询令牌失败: %w", err)
+	}
+	return &record, nil
+}
+
+// invalidateUserTokens 使用户某一用途的未使用令牌全部失效
+func invalidateUserTokens(tx *gorm.DB, userID uint, purpose model.UserTokenPurpose) error {
+	if err := tx.Model(&model.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("作废令牌失败: %w", err)
+	}
+	return nil
+}
+
+// randomToken 生成URL安全的随机令牌
+func randomToken() (string, error) {
+	raw := make([]byte, userTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("生成令牌失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashToken 计算令牌摘要
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashPassword 计算密码哈希
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("计算密码哈希失败: %w", err)
+	}
+	return string(hash), nil
+}
+
+// validatePassword 校验密码强度：8到64位，至少包含字母和数字
+func validatePassword(password string) error {
+	length := len([]rune(password))
+	if length < minPasswordLength || length > maxPasswordLength {
+		return ErrWeakPassword
+	}
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+// normalizeEmail 规范化邮箱地址
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// displayName 用户的展示名称，优先使用昵称
+func displayName(user *model.User) string {
+	if user.Nickname != "" {
+		return user.Nickname
+	}
+	return user.Username
+}
+
+// siteLink 拼接带令牌的前端页面链接
+func siteLink(tx *gorm.DB, path, token string) string {
+	base := strings.TrimRight(getConfigValue(tx, configKeySiteURL, defaultSiteURL), "/")
+	return base + path + "?token=" + token
+}
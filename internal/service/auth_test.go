@@ -0,0 +1,33 @@
+package service
+
+import "testing"
+
+// TestValidatePassword 测试密码强度校验
+func TestValidatePassword(t *testing.T) {
+	cases := []struct {
+		password string
+		want     error
+	}{
+		{"abc12345", nil},
+		{"Passw0rd!", nil},
+		{"abc123", ErrWeakPassword},
+		{"abcdefgh", ErrWeakPassword},
+		{"12345678", ErrWeakPassword},
+	}
+	for _, tc := range cases {
+		if got := validatePassword(tc.password); got != tc.want {
+			t.Errorf("密码 %q: 期望 %v, 实际 %v", tc.password, tc.want, got)
+		}
+	}
+}
+
+// TestHashToken 测试令牌摘要固定为64位十六进制且不泄露原文
+func TestHashToken(t *testing.T) {
+	hash := hashToken("secret-token")
+	if len(hash) != 64 {
+		t.Fatalf("摘要长度不正确: %d", len(hash))
+	}
+	if hash != hashToken("secret-token") || hash == hashToken("other-token") {
+		t.Error("相同令牌应得到相同摘要，不同令牌应得到不同摘要")
+	}
+}
@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/realtime"
+
+	"gorm.io/gorm"
+)
+
+// 会话成员角色
+const (
+	conversationRoleOwner  = "owner"
+	conversationRoleMember = "member"
+)
+
+// EventPublisher 实时事件发布接口
+type EventPublisher interface {
+	Publish(ctx context.Context, recipients []uint, event realtime.Event) error
+	PublishToConversation(ctx context.Context, conversationID, excludeUserID uint, event realtime.Event) error
+}
+
+// ConversationService 会话服务
+type ConversationService struct {
+	db        *gorm.DB
+	publisher EventPublisher
+}
+
+// NewConversationService 创建会话服务
+func NewConversationService(db *gorm.DB, publisher EventPublisher) *ConversationService {
+	return &ConversationService{db: db, publisher: publisher}
+}
+
+// CreateConversationRequest 创建会话请求
+type CreateConversationRequest struct {
+	Type        string `json:"type" binding:"required,oneof=private group team"`
+	Title       string `json:"title" binding:"max=200"`
+	Description string `json:"description"`
+	MemberIDs   []uint `json:"member_ids"`
+	TeamID      *uint  `json:"team_id"`
+}
+
+// ConversationItem 会话列表项
+type ConversationItem struct {
+	ID            uint                     `json:"id"`
+	Title         string                   `json:"title"`
+	Avatar        string                   `json:"avatar"`
+	Type          string                   `json:"type"`
+	Status        string                   `json:"status"`
+	TeamID        *uint                    `json:"team_id"`
+	LastMessageID *uint                    `json:"last_message_id"`
+	LastMessageAt *time.Time               `json:"last_message_at"`
+	LastMessage   *realtime.MessagePayload `json:"last_message,omitempty"`
+	UnreadCount   int                      `json:"unread_count"`
+	IsMuted       bool                     `json:"is_muted"`
+	IsAdmin       bool                     `json:"is_admin"`
+}
+
+// ConversationMemberItem 会话成员信息
+type ConversationMemberItem struct {
+	UserID         uint      `json:"user_id"`
+	Username       string    `json:"username"`
+	Nickname       string    `json:"nickname"`
+	Avatar         string    `json:"avatar"`
+	MemberNickname string    `json:"member_nickname"`
+	Role           string    `json:"role"`
+	IsAdmin        bool      `json:"is_admin"`
+	JoinedAt       time.Time `json:"joined_at"`
+}
+
+// ConversationDetail 会话详情
+type ConversationDetail struct {
+	ConversationItem
+	Description          string                   `json:"description"`
+	CreatorID            uint                     `json:"creator_id"`
+	MaxMembers           int                      `json:"max_members"`
+	AllowInvite          bool                     `json:"allow_invite"`
+	AllowFileShare       bool                     `json:"allow_file_share"`
+	MessageRetentionDays int                      `json:"message_retention_days"`
+	CreatedAt            time.Time                `json:"created_at"`
+	Members              []ConversationMemberItem `json:"members"`
+}
+
+// CreateConversation 创建私聊、群聊或团队会话
+func (s *ConversationService) CreateConversation(creatorID uint, req *CreateConversationRequest) (*model.Conversation, error) {
+	switch req.Type {
+	case string(model.ConversationTypePrivate):
+		return s.createPrivateConversation(creatorID, req)
+	case string(model.ConversationTypeGroup):
+		return s.createGroupConversation(creatorID, req)
+	case string(model.ConversationTypeTeam):
+		return s.createTeamConversation(creatorID, req)
+	default:
+		return nil, ErrInvalidConversation
+	}
+}
+
+// createPrivateConversation 创建私聊，两人之间已有私聊时直接返回
+func (s *ConversationService) createPrivateConversation(creatorID uint, req *CreateConversationRequest) (*model.Conversation, error) {
+	if len(req.MemberIDs) != 1 || req.MemberIDs[0] == creatorID {
+		return nil, ErrInvalidConversation
+	}
+	peerID := req.MemberIDs[0]
+	if err := requireUsersExist(s.db, []uint{peerID}); err != nil {
+		return nil, err
+	}
+
+	var existing model.Conversation
+	err := s.db.Where("type = ? AND id IN (?) AND id IN (?)", model.ConversationTypePrivate,
+		s.db.Model(&model.ConversationMember{}).Select("conversation_id").Where("user_id = ?", creatorID),
+		s.db.Model(&model.ConversationMember{}).Select("conversation_id").Where("user_id = ?", peerID)).
+		First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("查询私聊会话失败: %w", err)
+	}
+
+	conversation := &model.Conversation{Type: model.ConversationTypePrivate, CreatorID: creatorID, MaxMembers: 2}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(conversation).Error; err != nil {
+			return fmt.Errorf("创建私聊会话失败: %w", err)
+		}
+		return addConversationMembers(tx, conversation, []uint{creatorID, peerID}, creatorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// createGroupConversation 创建群聊，创建者为群主
+func (s *ConversationService) createGroupConversation(creatorID uint, req *CreateConversationRequest) (*model.Conversation, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return nil, ErrInvalidConversation
+	}
+	memberIDs := uniqueIDs(append([]uint{creatorID}, req.MemberIDs...))
+	if err := requireUsersExist(s.db, memberIDs); err != nil {
+		return nil, err
+	}
+
+	conversation := &model.Conversation{
+		Title:       title,
+		Description: req.Description,
+		Type:        model.ConversationTypeGroup,
+		CreatorID:   creatorID,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(conversation).Error; err != nil {
+			return fmt.Errorf("创建群聊会话失败: %w", err)
+		}
+		if len(memberIDs) > conversation.MaxMembers {
+			return ErrConversationFull
+		}
+		return addConversationMembers(tx, conversation, memberIDs, creatorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// createTeamConversation 创建团队会话，成员为团队全部活跃成员，每个团队仅一个
+func (s *ConversationService) createTeamConversation(creatorID uint, req *CreateConversationRequest) (*model.Conversation, error) {
+	if req.TeamID == nil {
+		return nil, ErrInvalidConversation
+	}
+	var conversation *model.Conversation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := requireTeamManager(tx, *req.TeamID, creatorID); err != nil {
+			return err
+		}
+		team, err := getTeam(tx, *req.TeamID)
+		if err != nil {
+			return err
+		}
+		conversation, err = createTeamConversation(tx, team, creatorID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// createTeamConversation 在事务中为团队创建会话并加入全部活跃成员
+func createTeamConversation(tx *gorm.DB, team *model.Team, creatorID uint) (*model.Conversation, error) {
+	var count int64
+	if err := tx.Model(&model.Conversation{}).
+		Where("type = ? AND team_id = ?", model.ConversationTypeTeam, team.ID).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("查询团队会话失败: %w", err)
+	}
+	if count > 0 {
+		return nil, ErrTeamConversationExists
+	}
+
+	var memberIDs []uint
+	if err := tx.Model(&model.TeamMember{}).
+		Where("team_id = ? AND status = ?", team.ID, model.TeamMemberStatusActive).
+		Pluck("user_id", &memberIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询团队成员失败: %w", err)
+	}
+
+	conversation := &model.Conversation{
+		Title:      team.Name,
+		Avatar:     team.Avatar,
+		Type:       model.ConversationTypeTeam,
+		CreatorID:  creatorID,
+		TeamID:     &team.ID,
+		MaxMembers: max(team.MaxMembers, len(memberIDs)),
+	}
+	if err := tx.Create(conversation).Error; err != nil {
+		return nil, fmt.Errorf("创建团队会话失败: %w", err)
+	}
+	if err := addConversationMembers(tx, conversation, uniqueIDs(append([]uint{creatorID}, memberIDs...)), creatorID); err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// ListConversations 获取用户的会话列表，按最后消息时间倒序
+func (s *ConversationService) ListConversations(userID uint, page, pageSize int) ([]ConversationItem, int64, error) {
+	db := s.db.Table("conversation_members AS cm").
+		Joins("JOIN conversations AS c ON c.id = cm.conversation_id").
+		Where("cm.user_id = ? AND cm.deleted_at IS NULL AND c.deleted_at IS NULL AND c.status <> ?",
+			userID, model.ConversationStatusDeleted)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计会话失败: %w", err)
+	}
+
+	var items []ConversationItem
+	err := db.Select("c.id, c.title, c.avatar, c.type, c.status, c.team_id, c.last_message_id, c.last_message_at, " +
+		"cm.unread_count, cm.is_muted, cm.admin_flag AS is_admin").
+		Order("c.last_message_at IS NULL, c.last_message_at DESC, c.id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Scan(&items).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询会话列表失败: %w", err)
+	}
+	if err := s.attachLastMessages(items); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// attachLastMessages 批量填充会话的最后一条消息
+func (s *ConversationService) attachLastMessages(items []ConversationItem) error {
+	var messageIDs []uint
+	for _, item := range items {
+		if item.LastMessageID != nil {
+			messageIDs = append(messageIDs, *item.LastMessageID)
+		}
+	}
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	var messages []model.Message
+	if err := s.db.Where("id IN ?", messageIDs).Find(&messages).Error; err != nil {
+		return fmt.Errorf("查询最后消息失败: %w", err)
+	}
+	payloads := make(map[uint]*realtime.MessagePayload, len(messages))
+	for i := range messages {
+		payload := realtime.NewMessagePayload(&messages[i])
+		payloads[messages[i].ID] = &payload
+	}
+	for i := range items {
+		if items[i].LastMessageID != nil {
+			items[i].LastMessage = payloads[*items[i].LastMessageID]
+		}
+	}
+	return nil
+}
+
+// GetConversation 获取会话详情及成员
+func (s *ConversationService) GetConversation(conversationID, userID uint) (*ConversationDetail, error) {
+	member, err := getConversationMember(s.db, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	conversation, err := getConversation(s.db, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &ConversationDetail{
+		ConversationItem: ConversationItem{
+			ID:            conversation.ID,
+			Title:         conversation.Title,
+			Avatar:        conversation.Avatar,
+			Type:          string(conversation.Type),
+			Status:        string(conversation.Status),
+			TeamID:        conversation.TeamID,
+			LastMessageID: conversation.LastMessageID,
+			LastMessageAt: conversation.LastMessageAt,
+			UnreadCount:   member.UnreadCount,
+			IsMuted:       member.IsMuted,
+			IsAdmin:       member.AdminFlag,
+		},
+		Description:          conversation.Description,
+		CreatorID:            conversation.CreatorID,
+		MaxMembers:           conversation.MaxMembers,
+		AllowInvite:          conversation.AllowInvite,
+		AllowFileShare:       conversation.AllowFileShare,
+		MessageRetentionDays: conversation.MessageRetentionDays,
+		CreatedAt:            conversation.CreatedAt,
+	}
+	err = s.db.Table("conversation_members AS cm").
+		Select("cm.user_id, u.username, u.nickname, u.avatar, cm.nickname AS member_nickname, cm.role, cm.admin_flag AS is_admin, cm.joined_at").
+		Joins("JOIN users AS u ON u.id = cm.user_id").
+		Where("cm.conversation_id = ? AND cm.deleted_at IS NULL", conversationID).
+		Order("cm.id").
+		Scan(&detail.Members).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询会话成员失败: %w", err)
+	}
+	return detail, nil
+}
+
+// LeaveConversation 退出群聊，同时撤销通过会话文件消息获得的文件权限
+func (s *ConversationService) LeaveConversation(conversationID, userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		conversation, err := getConversation(tx, conversationID)
+		if err != nil {
+			return err
+		}
+		if !conversation.IsGroup() {
+			return ErrCannotLeave
+		}
+		member, err := getConversationMember(tx, conversationID, userID)
+		if err != nil {
+			return err
+		}
+		return removeConversationMember(tx, member)
+	})
+}
+
+// removeConversationMember 移除会话成员并撤销其文件权限
+func removeConversationMember(tx *gorm.DB, member *model.ConversationMember) error {
+	if err := tx.Delete(member).Error; err != nil {
+		return fmt.Errorf("移除会话成员失败: %w", err)
+	}
+	return revokeMemberFileGrants(tx, member.ConversationID, member.UserID)
+}
+
+// getConversation 获取会话
+func getConversation(tx *gorm.DB, conversationID uint) (*model.Conversation, error) {
+	var conversation model.Conversation
+	if err := tx.First(&conversation, conversationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrConversationNotFound
+		}
+		return nil, fmt.Errorf("查询会话失败: %w", err)
+	}
+	return &conversation, nil
+}
+
+// getConversationMember 获取会话成员，非成员返回ErrNotConversationMember
+func getConversationMember(tx *gorm.DB, conversationID, userID uint) (*model.ConversationMember, error) {
+	var member model.ConversationMember
+	err := tx.Where("conversation_id = ? AND user_id = ?", conversationID, userID).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotConversationMember
+		}
+		return nil, fmt.Errorf("查询会话成员失败: %w", err)
+	}
+	return &member, nil
+}
+
+// canModerateConversation 检查成员能否管理会话，私聊双方地位相同，即使历史数据中带有管理员标记也不能管理
+func canModerateConversation(tx *gorm.DB, member *model.ConversationMember) (bool, error) {
+	if !member.CanManageConversation() {
+		return false, nil
+	}
+	conversation, err := getConversation(tx, member.ConversationID)
+	if err != nil {
+		return false, err
+	}
+	return !conversation.IsPrivate(), nil
+}
+
+// addConversationMembers 批量加入会话成员，群聊和团队会话的创建者成为管理员，私聊没有管理员
+func addConversationMembers(tx *gorm.DB, conversation *model.Conversation, userIDs []uint, inviterID uint) error {
+	members := make([]model.ConversationMember, 0, len(userIDs))
+	for _, userID := range userIDs {
+		member := model.ConversationMember{
+			ConversationID: conversation.ID,
+			UserID:         userID,
+			Role:           conversationRoleMember,
+		}
+		if userID == conversation.CreatorID {
+			if !conversation.IsPrivate() {
+				member.Role = conversationRoleOwner
+				member.AdminFlag = true
+			}
+		} else {
+			invitedBy := inviterID
+			member.InvitedBy = &invitedBy
+		}
+		members = append(members, member)
+	}
+	if len(members) == 0 {
+		return nil
+	}
+	if err := tx.Create(&members).Error; err != nil {
+		return fmt.Errorf("添加会话成员失败: %w", err)
+	}
+	return nil
+}
+
+// requireUsersExist 校验用户均存在
+func requireUsersExist(tx *gorm.DB, userIDs []uint) error {
+	var count int64
+	if err := tx.Model(&model.User{}).Where("id IN ?", userIDs).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if int(count) != len(userIDs) {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// uniqueIDs 去重并保持原有顺序
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"ycg_cloud/internal/model"
+)
+
+// 邮件模板键
+const (
+	EmailVerification      = "verification"
+	EmailPasswordReset     = "password_reset"
+	EmailTeamInvitation    = "team_invitation"
+	EmailShareNotification = "share_notification"
+)
+
+// emailLayout 所有HTML邮件共用的外层布局，content模板由具体邮件提供
+const emailLayout = `<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="margin:0;padding:24px;background:#f5f6f8;font-family:Arial,'Microsoft YaHei',sans-serif;color:#333;">
+<div style="max-width:560px;margin:0 auto;padding:32px;background:#fff;border-radius:8px;">
+{{template "content" .}}
+</div>
+</body>
+</html>`
+
+// emailText 某一语言下的邮件主题、纯文本和HTML正文模板
+type emailText struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// renderedEmail 渲染后的邮件
+type renderedEmail struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// emailTemplates 内置邮件模板，缺少用户语言时回退到默认语言
+var emailTemplates = map[string]map[string]emailText{
+	EmailVerification: {
+		model.LocaleZhCN: {
+			Subject: "请验证你的邮箱",
+			Text:    "{{.Username}}，你好：\n\n请在 {{.ExpireMinutes}} 分钟内打开以下链接完成邮箱验证：\n{{.Link}}\n\n如果这不是你本人的操作，请忽略本邮件。",
+			HTML:    `<p>{{.Username}}，你好：</p><p>请在 {{.ExpireMinutes}} 分钟内点击下方按钮完成邮箱验证。</p><p><a href="{{.Link}}">验证邮箱</a></p><p>如果这不是你本人的操作，请忽略本邮件。</p>`,
+		},
+		model.LocaleEnUS: {
+			Subject: "Verify your email address",
+			Text:    "Hi {{.Username}},\n\nOpen the link below within {{.ExpireMinutes}} minutes to verify your email:\n{{.Link}}\n\nIf you didn't sign up, you can ignore this email.",
+			HTML:    `<p>Hi {{.Username}},</p><p>Click the button below within {{.ExpireMinutes}} minutes to verify your email.</p><p><a href="{{.Link}}">Verify email</a></p><p>If you didn't sign up, you can ignore this email.</p>`,
+		},
+	},
+	EmailPasswordReset: {
+		model.LocaleZhCN: {
+			Subject: "重置你的密码",
+			Text:    "{{.Username}}，你好：\n\n我们收到了重置密码的请求，请在 {{.ExpireMinutes}} 分钟内打开以下链接设置新密码：\n{{.Link}}\n\n链接只能使用一次。如果这不是你本人的操作，请忽略本邮件，你的密码不会改变。",
+			HTML:    `<p>{{.Username}}，你好：</p><p>我们收到了重置密码的请求，请在 {{.ExpireMinutes}} 分钟内点击下方按钮设置新密码，链接只能使用一次。</p><p><a href="{{.Link}}">重置密码</a></p><p>如果这不是你本人的操作，请忽略本邮件，你的密码不会改变。</p>`,
+		},
+		model.LocaleEnUS: {
+			Subject: "Reset your password",
+			Text:    "Hi {{.Username}},\n\nWe received a request to reset your password. Open the link below within {{.ExpireMinutes}} minutes to choose a new one:\n{{.Link}}\n\nThe link can only be used once. If you didn't request this, ignore this email and your password will stay the same.",
+			HTML:    `<p>Hi {{.Username}},</p><p>We received a request to reset your password. Click the button below within {{.ExpireMinutes}} minutes to choose a new one. The link can only be used once.</p><p><a href="{{.Link}}">Reset password</a></p><p>If you didn't request this, ignore this email and your password will stay the same.</p>`,
+		},
+	},
+	EmailTeamInvitation: {
+		model.LocaleZhCN: {
+			Subject: "{{.Inviter}} 邀请你加入团队「{{.TeamName}}」",
+			Text:    "{{.Inviter}} 邀请你加入团队「{{.TeamName}}」。\n\n打开以下链接接受邀请：\n{{.Link}}",
+			HTML:    `<p>{{.Inviter}} 邀请你加入团队「{{.TeamName}}」。</p><p><a href="{{.Link}}">接受邀请</a></p>`,
+		},
+		model.LocaleEnUS: {
+			Subject: "{{.Inviter}} invited you to join \"{{.TeamName}}\"",
+			Text:    "{{.Inviter}} invited you to join the team \"{{.TeamName}}\".\n\nOpen the link below to accept:\n{{.Link}}",
+			HTML:    `<p>{{.Inviter}} invited you to join the team "{{.TeamName}}".</p><p><a href="{{.Link}}">Accept invitation</a></p>`,
+		},
+	},
+	EmailShareNotification: {
+		model.LocaleZhCN: {
+			Subject: "{{.Sharer}} 与你分享了「{{.FileName}}」",
+			Text:    "{{.Sharer}} 与你分享了「{{.FileName}}」。\n\n打开以下链接查看：\n{{.Link}}",
+			HTML:    `<p>{{.Sharer}} 与你分享了「{{.FileName}}」。</p><p><a href="{{.Link}}">查看文件</a></p>`,
+		},
+		model.LocaleEnUS: {
+			Subject: "{{.Sharer}} shared \"{{.FileName}}\" with you",
+			Text:    "{{.Sharer}} shared \"{{.FileName}}\" with you.\n\nOpen the link below to view it:\n{{.Link}}",
+			HTML:    `<p>{{.Sharer}} shared "{{.FileName}}" with you.</p><p><a href="{{.Link}}">View file</a></p>`,
+		},
+	},
+}
+
+// renderEmail 按语言渲染邮件模板，HTML正文会对变量做转义
+func renderEmail(key, locale string, data map[string]interface{}) (*renderedEmail, error) {
+	texts, ok := emailTemplates[key]
+	if !ok {
+		return nil, ErrEmailTemplate
+	}
+	text, ok := texts[locale]
+	if !ok {
+		text = texts[model.DefaultLocale]
+	}
+
+	subject, err := executeText(key+".subject", text.Subject, data)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := executeText(key+".text", text.Text, data)
+	if err != nil {
+		return nil, err
+	}
+	html, err := executeHTML(key, text.HTML, data)
+	if err != nil {
+		return nil, err
+	}
+	return &renderedEmail{Subject: subject, Text: plain, HTML: html}, nil
+}
+
+// executeHTML 将正文模板套入公共布局并渲染
+func executeHTML(name, content string, data map[string]interface{}) (string, error) {
+	layout, err := template.New(name).Option("missingkey=error").Parse(emailLayout)
+	if err != nil {
+		return "", fmt.Errorf("解析邮件布局失败: %w", err)
+	}
+	if _, err := layout.New("content").Parse(content); err != nil {
+		return "", fmt.Errorf("解析邮件模板 %s 失败: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := layout.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染邮件模板 %s 失败: %w", name, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,85 @@
+// Package service 实现应用程序的业务逻辑
+package service
+
+import "errors"
+
+// 通用错误
+var (
+	ErrInvalidParam = errors.New("参数错误")
+	ErrForbidden    = errors.New("无权执行该操作")
+	ErrUserNotFound = errors.New("用户不存在")
+)
+
+// 团队相关错误
+var (
+	ErrTeamNotFound           = errors.New("团队不存在")
+	ErrTeamNotPublic          = errors.New("团队未公开，无法申请加入")
+	ErrTeamInactive           = errors.New("团队当前不可用")
+	ErrTeamFull               = errors.New("团队成员已满")
+	ErrAlreadyTeamMember      = errors.New("已经是团队成员")
+	ErrNotTeamMember          = errors.New("不是团队成员")
+	ErrTeamOwnerCannotLeave   = errors.New("团队所有者不能退出团队")
+	ErrJoinRequestExists      = errors.New("已存在待审批的入队申请")
+	ErrJoinRequestNotFound    = errors.New("入队申请不存在")
+	ErrJoinRequestProcessed   = errors.New("入队申请已处理")
+	ErrAutoApproveRuleInvalid = errors.New("自动审批规则无效")
+	ErrAutoApproveRuleMissing = errors.New("自动审批规则不存在")
+)
+
+// 会话与消息相关错误
+var (
+	ErrConversationNotFound   = errors.New("会话不存在")
+	ErrConversationInactive   = errors.New("会话已归档或不可用")
+	ErrConversationFull       = errors.New("会话成员已满")
+	ErrNotConversationMember  = errors.New("不是会话成员")
+	ErrInvalidConversation    = errors.New("会话参数无效")
+	ErrTeamConversationExists = errors.New("团队会话已存在")
+	ErrMessageNotFound        = errors.New("消息不存在")
+	ErrInvalidMessage         = errors.New("消息内容无效")
+	ErrFileNotFound           = errors.New("文件不存在")
+	ErrFileShareDisabled      = errors.New("该会话不允许分享文件")
+	ErrMessageRecalled        = errors.New("消息已撤回")
+	ErrMessageNotEditable     = errors.New("该消息不支持编辑")
+	ErrRecallExpired          = errors.New("已超过撤回时限")
+	ErrMentionNotMember       = errors.New("被提及的用户不是会话成员")
+	ErrCannotLeave            = errors.New("该会话不支持退出")
+	ErrStorageQuotaExceeded   = errors.New("存储空间不足")
+	ErrRetentionOutOfRange    = errors.New("消息保留天数超出允许范围")
+)
+
+// 通知相关错误
+var (
+	ErrNotificationTemplate = errors.New("通知模板不存在")
+	ErrEmailTemplate        = errors.New("邮件模板不存在")
+	ErrMailNotConfigured    = errors.New("邮件服务未配置")
+)
+
+// 认证相关错误
+var (
+	ErrUserExists         = errors.New("用户名或邮箱已被注册")
+	ErrWeakPassword       = errors.New("密码长度需为8-64位且同时包含字母和数字")
+	ErrInvalidCredentials = errors.New("账号或密码错误")
+	ErrAccountLocked      = errors.New("登录失败次数过多，账号已临时锁定")
+	ErrEmailNotVerified   = errors.New("邮箱尚未验证")
+	ErrAccountDisabled    = errors.New("账号已被禁用")
+	ErrInvalidToken       = errors.New("链接无效或已过期")
+	ErrTokenRevoked       = errors.New("认证令牌已失效，请重新登录")
+	ErrTooManyRequests    = errors.New("请求过于频繁，请稍后再试")
+	ErrSessionNotFound    = errors.New("会话不存在或已注销")
+)
+
+// 权限相关错误
+var (
+	ErrTemplateNotFound = errors.New("权限模板不存在")
+	ErrTemplateExists   = errors.New("权限模板名称已存在")
+	ErrRoleNotFound     = errors.New("角色不存在")
+	ErrRoleLevelTooHigh = errors.New("不能授予级别高于自己的角色")
+
+	ErrRoleAssignmentNotFound = errors.New("角色授予记录不存在")
+)
+
+// 日志相关错误
+var (
+	ErrLogKindNotFound      = errors.New("日志类型不存在")
+	ErrLogFilterUnsupported = errors.New("该日志类型不支持此过滤条件")
+)
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 会话文件分享配置
+const (
+	configKeyFileGrantDays = "im.file_share.grant_days"
+	defaultFileGrantDays   = 7
+	maxFileGrantDays       = 365
+)
+
+// fileGrantActions 分享到会话的文件授予成员的权限
+var fileGrantActions = []model.PermissionAction{
+	model.PermissionRead,
+	model.PermissionPreview,
+	model.PermissionDownload,
+}
+
+// getFile 获取正常状态的文件
+func getFile(tx *gorm.DB, fileID uint) (*model.File, error) {
+	var file model.File
+	if err := tx.Where("status = ?", model.FileStatusNormal).First(&file, fileID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFileNotFound
+		}
+		return nil, fmt.Errorf("查询文件失败: %w", err)
+	}
+	return &file, nil
+}
+
+// hasFilePermission 检查用户对文件是否拥有指定权限，所有者拥有全部权限
+// 没有直接授权时检查全局角色和文件所在文件夹子树上的角色
+func hasFilePermission(tx *gorm.DB, file *model.File, userID uint, action model.PermissionAction) (bool, error) {
+	if file.OwnerID == userID {
+		return true, nil
+	}
+
+	var count int64
+	err := tx.Model(&model.FilePermission{}).
+		Where("file_id = ? AND user_id = ? AND action = ? AND allowed = ?", file.ID, userID, action, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("查询文件权限失败: %w", err)
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	folderID := file.ParentID
+	if file.IsFolder() {
+		folderID = &file.ID
+	}
+	scopes, err := expandScope(tx, RoleScope{Type: model.ResourceTypeFolder, ID: folderID})
+	if err != nil {
+		return false, err
+	}
+	resourceType := model.ResourceTypeFile
+	if file.IsFolder() {
+		resourceType = model.ResourceTypeFolder
+	}
+	return hasRolePermission(tx, userID, scopes, resourceType, action)
+}
+
+// grantMessageFile 为文件消息的接收者授予临时的读取、预览和下载权限
+func grantMessageFile(tx *gorm.DB, message *model.Message) error {
+	if message.FileID == nil {
+		return nil
+	}
+
+	var userIDs []uint
+	if err := tx.Model(&model.ConversationMember{}).
+		Where("conversation_id = ? AND user_id <> ?", message.ConversationID, message.SenderID).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return fmt.Errorf("查询会话成员失败: %w", err)
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	days := getConfigInt(tx, configKeyFileGrantDays, defaultFileGrantDays)
+	if days <= 0 || days > maxFileGrantDays {
+		days = defaultFileGrantDays
+	}
+	expiresAt := time.Now().AddDate(0, 0, days)
+
+	permissions := make([]model.FilePermission, 0, len(userIDs)*len(fileGrantActions))
+	for _, userID := range userIDs {
+		for _, action := range fileGrantActions {
+			recipientID := userID
+			permissions = append(permissions, model.FilePermission{
+				FileID:          *message.FileID,
+				UserID:          &recipientID,
+				Action:          action,
+				Allowed:         true,
+				GrantedBy:       &message.SenderID,
+				ExpiresAt:       &expiresAt,
+				SourceMessageID: &message.ID,
+			})
+		}
+	}
+	if err := tx.Create(&permissions).Error; err != nil {
+		return fmt.Errorf("授予文件权限失败: %w", err)
+	}
+	return nil
+}
+
+// revokeMessageFileGrants 撤销文件消息授予的全部权限
+func revokeMessageFileGrants(tx *gorm.DB, messageID uint) error {
+	if err := tx.Where("source_message_id = ?", messageID).Delete(&model.FilePermission{}).Error; err != nil {
+		return fmt.Errorf("撤销文件权限失败: %w", err)
+	}
+	return nil
+}
+
+// revokeMemberFileGrants 撤销成员通过会话文件消息获得的全部权限
+func revokeMemberFileGrants(tx *gorm.DB, conversationID, userID uint) error {
+	messageIDs := tx.Model(&model.Message{}).Select("id").
+		Where("conversation_id = ? AND file_id IS NOT NULL", conversationID)
+	if err := tx.Where("source_message_id IN (?) AND user_id = ?", messageIDs, userID).Delete(&model.FilePermission{}).Error; err != nil {
+		return fmt.Errorf("撤销成员文件权限失败: %w", err)
+	}
+	return nil
+}
+
+// SaveFileToDrive 将会话中的文件保存到我的网盘，通过引用同一存储对象实现秒存，已存在相同内容时直接返回
+func (s *MessageService) SaveFileToDrive(conversationID, messageID, userID uint, parentID *uint) (*model.File, error) {
+	var saved *model.File
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		source, err := getSharedFile(tx, conversationID, messageID, userID)
+		if err != nil {
+			return err
+		}
+		if saved, err = findSameContentFile(tx, source, userID); err != nil || saved != nil {
+			return err
+		}
+
+		// Path为所在目录，根目录为"/"
+		parentPath := "/"
+		if parentID != nil {
+			parent, err := getFile(tx, *parentID)
+			if err != nil {
+				return err
+			}
+			if parent.OwnerID != userID || !parent.IsFolder() {
+				return ErrInvalidParam
+			}
+			parentPath = path.Clean(parent.GetFullPath())
+		}
+		if err := consumeStorage(tx, userID, source.Size); err != nil {
+			return err
+		}
+
+		// 引用同一存储对象，加密文件同时复制密钥才能解密
+		saved = &model.File{
+			OwnerID:       userID,
+			ParentID:      parentID,
+			Name:          source.Name,
+			Path:          parentPath,
+			Size:          source.Size,
+			MimeType:      source.MimeType,
+			MD5Hash:       source.MD5Hash,
+			SHA256Hash:    source.SHA256Hash,
+			StoragePath:   source.StoragePath,
+			BucketName:    source.BucketName,
+			StorageType:   source.StorageType,
+			FileType:      source.FileType,
+			CanPreview:    source.CanPreview,
+			IsEncrypted:   source.IsEncrypted,
+			EncryptionKey: source.EncryptionKey,
+		}
+		if err := tx.Create(saved).Error; err != nil {
+			return fmt.Errorf("保存文件失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// getSharedFile 获取会话文件消息中的文件并校验用户的读取权限
+func getSharedFile(tx *gorm.DB, conversationID, messageID, userID uint) (*model.File, error) {
+	if _, err := getConversationMember(tx, conversationID, userID); err != nil {
+		return nil, err
+	}
+	message, err := getConversationMessage(tx, conversationID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.IsRecalled() {
+		return nil, ErrMessageRecalled
+	}
+	if message.FileID == nil {
+		return nil, ErrInvalidMessage
+	}
+
+	file, err := getFile(tx, *message.FileID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := hasFilePermission(tx, file, userID, model.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+	return file, nil
+}
+
+// findSameContentFile 查找用户网盘中内容相同的文件
+func findSameContentFile(tx *gorm.DB, source *model.File, userID uint) (*model.File, error) {
+	if source.OwnerID == userID {
+		return source, nil
+	}
+
+	db := tx.Where("owner_id = ? AND status = ?", userID, model.FileStatusNormal)
+	switch {
+	case source.SHA256Hash != "":
+		db = db.Where("sha256_hash = ?", source.SHA256Hash)
+	case source.MD5Hash != "":
+		db = db.Where("md5_hash = ?", source.MD5Hash)
+	default:
+		return nil, nil
+	}
+
+	var file model.File
+	if err := db.First(&file).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询重复文件失败: %w", err)
+	}
+	return &file, nil
+}
+
+// consumeStorage 占用用户存储空间，超出配额时返回错误
+func consumeStorage(tx *gorm.DB, userID uint, size int64) error {
+	result := tx.Model(&model.User{}).
+		Where("id = ? AND used_storage + ? <= storage_quota", userID, size).
+		UpdateColumn("used_storage", gorm.Expr("used_storage + ?", size))
+	if result.Error != nil {
+		return fmt.Errorf("更新存储用量失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrStorageQuotaExceeded
+	}
+	return nil
+}
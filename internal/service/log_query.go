@@ -0,0 +1,471 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 日志查询相关限制
+const (
+	defaultLogLimit    = 50
+	maxLogLimit        = 500
+	logExportBatchSize = 500
+	maxLogStatsDays    = 366
+)
+
+// 日志类型，对应查询接口路径中的kind
+const (
+	LogKindOperation = "operation"
+	LogKindSystem    = "system"
+	LogKindSecurity  = "security"
+)
+
+// 导出格式
+const (
+	LogExportCSV    = "csv"
+	LogExportNDJSON = "ndjson"
+)
+
+// 过滤条件名称，同时用于提示不支持的过滤条件
+const (
+	logFilterUser         = "user_id"
+	logFilterType         = "type"
+	logFilterAction       = "action"
+	logFilterLevel        = "level"
+	logFilterModule       = "module"
+	logFilterIP           = "ip"
+	logFilterResourceType = "resource_type"
+	logFilterResourceID   = "resource_id"
+)
+
+// LogFilter 日志过滤条件，空值表示不过滤
+type LogFilter struct {
+	UserID       *uint
+	Type         string
+	Action       string
+	Level        string
+	Module       string
+	IP           string
+	ResourceType string
+	ResourceID   *uint
+	StartTime    *time.Time
+	EndTime      *time.Time
+}
+
+// LogQuery 日志游标查询参数，BeforeID为上一页返回的NextCursor
+type LogQuery struct {
+	LogFilter
+	BeforeID uint
+	Limit    int
+}
+
+// LogPage 日志游标分页结果，按ID倒序
+type LogPage struct {
+	List       []interface{} `json:"list"`
+	NextCursor uint          `json:"next_cursor"`
+	HasMore    bool          `json:"has_more"`
+}
+
+// LogCount 分组计数
+type LogCount struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// LogStats 日志聚合统计，ByAction按操作(系统日志按模块、安全日志按事件类型)分组
+type LogStats struct {
+	Total    int64      `json:"total"`
+	ByAction []LogCount `json:"by_action"`
+	ByDay    []LogCount `json:"by_day"`
+}
+
+// logRecord 查询出的一条日志，Value用于JSON输出，Row用于CSV输出
+type logRecord struct {
+	ID    uint
+	Value interface{}
+	Row   []string
+}
+
+// logKind 一类日志的表模型、可过滤的列和输出方式
+type logKind struct {
+	newModel  func() interface{}
+	columns   map[string]string // 过滤条件名称到列名的映射
+	groupBy   string            // 按操作统计时分组的列
+	csvHeader []string
+	find      func(query *gorm.DB) ([]logRecord, error)
+}
+
+// logKinds 支持查询的日志类型
+var logKinds = map[string]logKind{
+	LogKindOperation: {
+		newModel: func() interface{} { return &model.OperationLog{} },
+		columns: map[string]string{
+			logFilterUser: "user_id", logFilterType: "type", logFilterAction: "action", logFilterLevel: "level",
+			logFilterModule: "module", logFilterIP: "ip_address", logFilterResourceType: "resource_type",
+			logFilterResourceID: "resource_id",
+		},
+		groupBy: "action",
+		csvHeader: []string{"id", "created_at", "user_id", "username", "impersonator_id", "type", "level", "action",
+			"module", "title", "resource_type", "resource_id", "resource_name", "status", "error_code", "method", "url",
+			"ip_address", "duration", "chain_seq"},
+		find: findOperationLogs,
+	},
+	LogKindSystem: {
+		newModel: func() interface{} { return &model.SystemLog{} },
+		columns:  map[string]string{logFilterType: "type", logFilterLevel: "level", logFilterModule: "module"},
+		groupBy:  "module",
+		csvHeader: []string{"id", "created_at", "level", "type", "module", "component", "title", "message",
+			"error_code", "error_type", "request_id", "hostname"},
+		find: findSystemLogs,
+	},
+	LogKindSecurity: {
+		newModel: func() interface{} { return &model.SecurityLog{} },
+		columns: map[string]string{
+			logFilterUser: "user_id", logFilterAction: "event_type", logFilterLevel: "severity", logFilterIP: "source_ip",
+		},
+		groupBy: "event_type",
+		csvHeader: []string{"id", "created_at", "user_id", "username", "event_type", "severity", "status", "title",
+			"threat_level", "source_ip", "country", "city", "is_blocked", "is_resolved"},
+		find: findSecurityLogs,
+	},
+}
+
+// LogQueryService 日志查询服务，调用方需已校验日志查看权限
+type LogQueryService struct {
+	db *gorm.DB
+}
+
+// NewLogQueryService 创建日志查询服务
+func NewLogQueryService(db *gorm.DB) *LogQueryService {
+	return &LogQueryService{db: db}
+}
+
+// ListLogs 按条件和游标查询日志
+func (s *LogQueryService) ListLogs(ctx context.Context, kindName string, query *LogQuery) (*LogPage, error) {
+	kind, db, err := s.filtered(ctx, kindName, &query.LogFilter)
+	if err != nil {
+		return nil, err
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultLogLimit
+	}
+	if limit > maxLogLimit {
+		limit = maxLogLimit
+	}
+	if query.BeforeID > 0 {
+		db = db.Where("id < ?", query.BeforeID)
+	}
+
+	// 多取一条用于判断是否还有更多
+	records, err := kind.find(db.Order("id DESC").Limit(limit + 1))
+	if err != nil {
+		return nil, err
+	}
+	page := &LogPage{List: make([]interface{}, 0, limit)}
+	if len(records) > limit {
+		page.HasMore = true
+		records = records[:limit]
+	}
+	for _, record := range records {
+		page.List = append(page.List, record.Value)
+	}
+	if len(records) > 0 {
+		page.NextCursor = records[len(records)-1].ID
+	}
+	return page, nil
+}
+
+// LogStats 按条件统计日志总数、按操作和按天的数量
+func (s *LogQueryService) LogStats(ctx context.Context, kindName string, filter *LogFilter) (*LogStats, error) {
+	if filter.StartTime == nil || filter.EndTime == nil || filter.EndTime.Sub(*filter.StartTime) > maxLogStatsDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: 统计需指定不超过%d天的时间范围", ErrInvalidParam, maxLogStatsDays)
+	}
+	kind, db, err := s.filtered(ctx, kindName, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &LogStats{ByAction: []LogCount{}, ByDay: []LogCount{}}
+	if err := db.Session(&gorm.Session{}).Count(&stats.Total).Error; err != nil {
+		return nil, fmt.Errorf("统计日志失败: %w", err)
+	}
+	if err := db.Session(&gorm.Session{}).Select(kind.groupBy + " AS `key`, COUNT(*) AS count").
+		Group(kind.groupBy).Order("count DESC").Scan(&stats.ByAction).Error; err != nil {
+		return nil, fmt.Errorf("按操作统计日志失败: %w", err)
+	}
+	if err := db.Session(&gorm.Session{}).Select("DATE_FORMAT(created_at, '%Y-%m-%d') AS `key`, COUNT(*) AS count").
+		Group("`key`").Order("`key` ASC").Scan(&stats.ByDay).Error; err != nil {
+		return nil, fmt.Errorf("按天统计日志失败: %w", err)
+	}
+	return stats, nil
+}
+
+// ExportLogs 按条件分批读取日志并流式写出为CSV或NDJSON，过滤条件无效时在写出前返回错误
+func (s *LogQueryService) ExportLogs(ctx context.Context, w io.Writer, kindName string, filter *LogFilter, format string) error {
+	kind, db, err := s.filtered(ctx, kindName, filter)
+	if err != nil {
+		return err
+	}
+	var exporter logExporter
+	switch format {
+	case LogExportCSV:
+		exporter = newCSVLogExporter(w, kind.csvHeader)
+	case LogExportNDJSON:
+		exporter = newNDJSONLogExporter(w)
+	default:
+		return fmt.Errorf("%w: 不支持的导出格式", ErrInvalidParam)
+	}
+
+	var cursor uint
+	for {
+		batch := db.Session(&gorm.Session{})
+		if cursor > 0 {
+			batch = batch.Where("id < ?", cursor)
+		}
+		records, err := kind.find(batch.Order("id DESC").Limit(logExportBatchSize))
+		if err != nil {
+			return err
+		}
+		for i := range records {
+			if err := exporter.write(&records[i]); err != nil {
+				return err
+			}
+		}
+		if err := exporter.flush(); err != nil {
+			return err
+		}
+		flushWriter(w)
+		if len(records) < logExportBatchSize {
+			return nil
+		}
+		cursor = records[len(records)-1].ID
+	}
+}
+
+// filtered 查找日志类型并应用过滤条件
+func (s *LogQueryService) filtered(ctx context.Context, kindName string, filter *LogFilter) (logKind, *gorm.DB, error) {
+	kind, ok := logKinds[kindName]
+	if !ok {
+		return logKind{}, nil, ErrLogKindNotFound
+	}
+	var since time.Time
+	if filter.StartTime != nil {
+		since = *filter.StartTime
+	}
+	db, err := logSource(s.db.WithContext(ctx), kind.newModel(), since)
+	if err != nil {
+		return logKind{}, nil, err
+	}
+	for _, condition := range filter.conditions() {
+		column, ok := kind.columns[condition.name]
+		if !ok {
+			return logKind{}, nil, fmt.Errorf("%w: %s", ErrLogFilterUnsupported, condition.name)
+		}
+		db = db.Where(column+" = ?", condition.value)
+	}
+	if filter.StartTime != nil {
+		db = db.Where("created_at >= ?", *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		db = db.Where("created_at < ?", *filter.EndTime)
+	}
+	return kind, db, nil
+}
+
+// logCondition 一个已设置的等值过滤条件
+type logCondition struct {
+	name  string
+	value interface{}
+}
+
+// conditions 列出已设置的等值过滤条件
+func (f *LogFilter) conditions() []logCondition {
+	var conditions []logCondition
+	for _, c := range []struct {
+		name  string
+		value string
+	}{
+		{logFilterType, f.Type}, {logFilterAction, f.Action}, {logFilterLevel, f.Level},
+		{logFilterModule, f.Module}, {logFilterIP, f.IP}, {logFilterResourceType, f.ResourceType},
+	} {
+		if c.value != "" {
+			conditions = append(conditions, logCondition{name: c.name, value: c.value})
+		}
+	}
+	if f.UserID != nil {
+		conditions = append(conditions, logCondition{name: logFilterUser, value: *f.UserID})
+	}
+	if f.ResourceID != nil {
+		conditions = append(conditions, logCondition{name: logFilterResourceID, value: *f.ResourceID})
+	}
+	return conditions
+}
+
+// logExporter 日志导出格式
+type logExporter interface {
+	write(record *logRecord) error
+	flush() error
+}
+
+// csvLogExporter CSV导出，表头在第一次写出时输出
+type csvLogExporter struct {
+	writer *csv.Writer
+	header []string
+}
+
+// newCSVLogExporter 创建CSV导出
+func newCSVLogExporter(w io.Writer, header []string) *csvLogExporter {
+	return &csvLogExporter{writer: csv.NewWriter(w), header: header}
+}
+
+func (e *csvLogExporter) write(record *logRecord) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	if err := e.writer.Write(escapeCSVRow(record.Row)); err != nil {
+		return fmt.Errorf("写出CSV失败: %w", err)
+	}
+	return nil
+}
+
+func (e *csvLogExporter) flush() error {
+	// 没有数据时也输出表头
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	if err := e.writer.Error(); err != nil {
+		return fmt.Errorf("写出CSV失败: %w", err)
+	}
+	return nil
+}
+
+// writeHeader 输出表头，只输出一次
+func (e *csvLogExporter) writeHeader() error {
+	if e.header == nil {
+		return nil
+	}
+	if err := e.writer.Write(e.header); err != nil {
+		return fmt.Errorf("写出CSV失败: %w", err)
+	}
+	e.header = nil
+	return nil
+}
+
+// escapeCSVRow 转义可能被电子表格当作公式执行的单元格，以 = + - @ 开头时加单引号前缀
+// 用户名、标题、URL和文件名都由用户控制，打开导出文件时不能执行其中的公式
+func escapeCSVRow(row []string) []string {
+	escaped := make([]string, len(row))
+	for i, cell := range row {
+		if cell != "" && strings.ContainsRune("=+-@", rune(cell[0])) {
+			cell = "'" + cell
+		}
+		escaped[i] = cell
+	}
+	return escaped
+}
+
+// ndjsonLogExporter NDJSON导出，每行一条日志
+type ndjsonLogExporter struct {
+	encoder *json.Encoder
+}
+
+// newNDJSONLogExporter 创建NDJSON导出
+func newNDJSONLogExporter(w io.Writer) *ndjsonLogExporter {
+	return &ndjsonLogExporter{encoder: json.NewEncoder(w)}
+}
+
+func (e *ndjsonLogExporter) write(record *logRecord) error {
+	if err := e.encoder.Encode(record.Value); err != nil {
+		return fmt.Errorf("写出NDJSON失败: %w", err)
+	}
+	return nil
+}
+
+func (e *ndjsonLogExporter) flush() error {
+	return nil
+}
+
+// flushWriter 把已写出的数据推送给客户端，避免大范围导出时积压在缓冲区
+func flushWriter(w io.Writer) {
+	if flusher, ok := w.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+}
+
+// formatLogTime 格式化日志时间
+func formatLogTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
+
+// findOperationLogs 查询操作日志
+func findOperationLogs(query *gorm.DB) ([]logRecord, error) {
+	var logs []model.OperationLog
+	if err := query.Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("查询操作日志失败: %w", err)
+	}
+
+	records := make([]logRecord, 0, len(logs))
+	for i := range logs {
+		l := &logs[i]
+		chainSeq := ""
+		if l.ChainSeq != nil {
+			chainSeq = strconv.FormatUint(*l.ChainSeq, 10)
+		}
+		records = append(records, logRecord{ID: l.ID, Value: l, Row: []string{
+			fmt.Sprint(l.ID), formatLogTime(l.CreatedAt), formatOptionalID(l.UserID), l.Username,
+			formatOptionalID(l.ImpersonatorID), string(l.Type), string(l.Level), string(l.Action), l.Module, l.Title,
+			l.ResourceType, formatOptionalID(l.ResourceID),
+			l.ResourceName, l.Status, l.ErrorCode, l.Method, l.URL, l.IPAddress, fmt.Sprint(l.Duration), chainSeq,
+		}})
+	}
+	return records, nil
+}
+
+// findSystemLogs 查询系统日志
+func findSystemLogs(query *gorm.DB) ([]logRecord, error) {
+	var logs []model.SystemLog
+	if err := query.Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("查询系统日志失败: %w", err)
+	}
+
+	records := make([]logRecord, 0, len(logs))
+	for i := range logs {
+		l := &logs[i]
+		records = append(records, logRecord{ID: l.ID, Value: l, Row: []string{
+			fmt.Sprint(l.ID), formatLogTime(l.CreatedAt), string(l.Level), string(l.Type), l.Module, l.Component,
+			l.Title, l.Message, l.ErrorCode, l.ErrorType, l.RequestID, l.Hostname,
+		}})
+	}
+	return records, nil
+}
+
+// findSecurityLogs 查询安全日志
+func findSecurityLogs(query *gorm.DB) ([]logRecord, error) {
+	var logs []model.SecurityLog
+	if err := query.Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("查询安全日志失败: %w", err)
+	}
+
+	records := make([]logRecord, 0, len(logs))
+	for i := range logs {
+		l := &logs[i]
+		records = append(records, logRecord{ID: l.ID, Value: l, Row: []string{
+			fmt.Sprint(l.ID), formatLogTime(l.CreatedAt), formatOptionalID(l.UserID), l.Username, l.EventType,
+			string(l.Severity), l.Status, l.Title, l.ThreatLevel, l.SourceIP, l.Country, l.City,
+			strconv.FormatBool(l.BlockedFlag), strconv.FormatBool(l.ResolvedFlag),
+		}})
+	}
+	return records, nil
+}
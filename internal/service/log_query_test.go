@@ -0,0 +1,111 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestLogFilterConditions 测试只列出已设置的过滤条件
+func TestLogFilterConditions(t *testing.T) {
+	userID := uint(7)
+	filter := &LogFilter{UserID: &userID, Action: "login", IP: "10.0.0.1"}
+
+	got := make(map[string]interface{})
+	for _, c := range filter.conditions() {
+		got[c.name] = c.value
+	}
+	if len(got) != 3 || got[logFilterUser] != userID || got[logFilterAction] != "login" || got[logFilterIP] != "10.0.0.1" {
+		t.Errorf("过滤条件 = %v", got)
+	}
+	if conditions := (&LogFilter{}).conditions(); len(conditions) != 0 {
+		t.Errorf("空过滤条件 = %v, 期望为空", conditions)
+	}
+}
+
+// TestLogKindColumns 测试各日志类型的过滤列映射
+func TestLogKindColumns(t *testing.T) {
+	tests := []struct {
+		kind   string
+		filter string
+		column string
+	}{
+		{LogKindOperation, logFilterIP, "ip_address"},
+		{LogKindOperation, logFilterResourceID, "resource_id"},
+		{LogKindSecurity, logFilterAction, "event_type"},
+		{LogKindSecurity, logFilterLevel, "severity"},
+		{LogKindSecurity, logFilterResourceType, ""},
+		{LogKindSystem, logFilterModule, "module"},
+		{LogKindSystem, logFilterUser, ""},
+	}
+	for _, tt := range tests {
+		if column := logKinds[tt.kind].columns[tt.filter]; column != tt.column {
+			t.Errorf("%s日志的%s过滤列 = %q, 期望 %q", tt.kind, tt.filter, column, tt.column)
+		}
+	}
+}
+
+// TestCSVLogExporter 测试CSV导出只输出一次表头，没有数据时也输出表头
+func TestCSVLogExporter(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := newCSVLogExporter(&buf, []string{"id", "title"})
+	for _, record := range []logRecord{{ID: 2, Row: []string{"2", "b"}}, {ID: 1, Row: []string{"1", "a,c"}}} {
+		if err := exporter.write(&record); err != nil {
+			t.Fatalf("写出失败: %v", err)
+		}
+	}
+	if err := exporter.flush(); err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	if want := "id,title\n2,b\n1,\"a,c\"\n"; buf.String() != want {
+		t.Errorf("CSV = %q, 期望 %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	empty := newCSVLogExporter(&buf, []string{"id", "title"})
+	if err := empty.flush(); err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	if err := empty.flush(); err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	if buf.String() != "id,title\n" {
+		t.Errorf("空CSV = %q, 期望只有表头", buf.String())
+	}
+}
+
+// TestCSVLogExporterEscapesFormulas 测试以公式字符开头的单元格加单引号前缀
+func TestCSVLogExporterEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := newCSVLogExporter(&buf, []string{"id", "username", "title", "url", "referer"})
+	record := logRecord{ID: 1, Row: []string{"1", "=HYPERLINK(\"http://evil\")", "+1", "-2+3", "@SUM(A1)"}}
+	if err := exporter.write(&record); err != nil {
+		t.Fatalf("写出失败: %v", err)
+	}
+	if err := exporter.flush(); err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	want := "id,username,title,url,referer\n1,\"'=HYPERLINK(\"\"http://evil\"\")\",'+1,'-2+3,'@SUM(A1)\n"
+	if buf.String() != want {
+		t.Errorf("CSV = %q, 期望 %q", buf.String(), want)
+	}
+	if record.Row[1] != "=HYPERLINK(\"http://evil\")" {
+		t.Errorf("转义不应修改原记录")
+	}
+}
+
+// TestNDJSONLogExporter 测试NDJSON每行一条记录
+func TestNDJSONLogExporter(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := newNDJSONLogExporter(&buf)
+	for i := 0; i < 2; i++ {
+		record := logRecord{Value: map[string]int{"id": i}}
+		if err := exporter.write(&record); err != nil {
+			t.Fatalf("写出失败: %v", err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[1] != `{"id":1}` {
+		t.Errorf("NDJSON = %q", buf.String())
+	}
+}
@@ -0,0 +1,392 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 日志保留配置，天数为0表示永久保留
+const (
+	configKeyLogArchiveDir      = "log.retention.archive_dir"
+	defaultLogArchiveDir        = "storage/archives/logs"
+	configKeyAuditRetentionDays = "log.retention.audit_days" // 哈希链中的审计日志，默认永久保留
+	logArchiveBatchSize         = 500
+	logArchiveFileTimeFormat    = "20060102150405"
+)
+
+// logMaintenanceLock 日志表滚动和归档使用的MySQL命名锁，多个实例同时运行定时任务时只有一个实例执行
+const logMaintenanceLock = "ycg_cloud.log_maintenance"
+
+// logRetentionPolicy 一类日志的保留配置
+type logRetentionPolicy struct {
+	kind        string
+	table       string
+	configKey   string
+	defaultDays int
+	// scope 限定写入归档文件的记录，为空表示全部
+	scope func(db *gorm.DB) *gorm.DB
+}
+
+// logRetentionPolicies 各类日志的保留配置，操作日志中的审计日志单独按审计保留天数归档
+var logRetentionPolicies = []logRetentionPolicy{
+	{
+		kind: LogKindOperation, table: operationLogTable, configKey: "log.retention.operation_days", defaultDays: 180,
+		scope: func(db *gorm.DB) *gorm.DB { return db.Where("chain_seq IS NULL") },
+	},
+	{kind: LogKindSystem, table: "system_logs", configKey: "log.retention.system_days", defaultDays: 90},
+	{kind: LogKindSecurity, table: "security_logs", configKey: "log.retention.security_days", defaultDays: 365},
+}
+
+// operationLogTable 操作日志当前表
+const operationLogTable = "operation_logs"
+
+// LogRetentionService 日志保留服务
+// 日志表每月滚动为按月的历史表，历史表中的日志全部过期后压缩归档并整表删除，不在当前表上逐行删除
+type LogRetentionService struct {
+	db    *gorm.DB
+	trail *AuditTrail
+}
+
+// NewLogRetentionService 创建日志保留服务，归档审计日志时向trail的锚点文件写入归档边界
+func NewLogRetentionService(db *gorm.DB, trail *AuditTrail) *LogRetentionService {
+	return &LogRetentionService{db: db, trail: trail}
+}
+
+// RollLogTables 把各类日志表中本月之前的日志滚动为上个月的历史表，当前表只保留本月的日志
+func (s *LogRetentionService) RollLogTables(ctx context.Context) error {
+	until := monthStart(time.Now())
+	return s.withMaintenanceLock(ctx, func(db *gorm.DB) error {
+		for _, policy := range logRetentionPolicies {
+			if err := model.RollLogTable(db, logKinds[policy.kind].newModel(), until); err != nil {
+				return fmt.Errorf("滚动%s日志表失败: %w", policy.kind, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ArchiveExpiredLogs 按各类日志的保留天数归档并删除已全部过期的历史表，审计日志按审计保留天数单独归档
+func (s *LogRetentionService) ArchiveExpiredLogs(ctx context.Context) error {
+	now := time.Now()
+	return s.withMaintenanceLock(ctx, func(db *gorm.DB) error {
+		// 先归档审计链，删除历史表时只需转移仍未归档的审计日志
+		if days := getConfigInt(db, configKeyAuditRetentionDays, 0); days > 0 {
+			if err := s.archiveAuditChain(ctx, db, retentionCutoff(now, days)); err != nil {
+				return err
+			}
+		}
+		for _, policy := range logRetentionPolicies {
+			days := getConfigInt(db, policy.configKey, policy.defaultDays)
+			if days <= 0 {
+				continue
+			}
+			if err := s.archivePolicy(ctx, db, policy, retentionCutoff(now, days)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// withMaintenanceLock 在持有命名锁的连接上执行日志维护，锁被其他实例持有时跳过本次执行
+func (s *LogRetentionService) withMaintenanceLock(ctx context.Context, fn func(db *gorm.DB) error) error {
+	return s.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		var locked sql.NullInt64
+		if err := conn.Raw("SELECT GET_LOCK(?, 0)", logMaintenanceLock).Scan(&locked).Error; err != nil {
+			return fmt.Errorf("获取日志维护锁失败: %w", err)
+		}
+		if locked.Int64 != 1 {
+			return nil
+		}
+		defer func() {
+			var released sql.NullInt64
+			if err := conn.Raw("SELECT RELEASE_LOCK(?)", logMaintenanceLock).Scan(&released).Error; err != nil {
+				log.Printf("释放日志维护锁失败: %v", err)
+			}
+		}()
+		return fn(conn)
+	})
+}
+
+// retentionCutoff 保留期限对齐到所在月份的月初，早于该时间的月份已全部过期
+func retentionCutoff(now time.Time, days int) time.Time {
+	t := now.AddDate(0, 0, -days)
+	return monthStart(t)
+}
+
+// monthStart 所在月份的月初
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// logSource 跨当前表和按月滚动的历史表查询日志，查询写法与单表相同
+// since不为零时只合并可能包含since之后日志的历史表，没有需要合并的历史表时直接查询当前表
+func logSource(db *gorm.DB, value interface{}, since time.Time) (*gorm.DB, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(value); err != nil {
+		return nil, fmt.Errorf("解析日志模型失败: %w", err)
+	}
+	table := stmt.Schema.Table
+	rolled, err := model.RolledLogTables(db, table)
+	if err != nil {
+		return nil, err
+	}
+	if !since.IsZero() {
+		rolled = recentRolledTables(table, rolled, since)
+	}
+	if len(rolled) == 0 {
+		return db.Model(value).Session(&gorm.Session{}), nil
+	}
+	return db.Model(value).Table(logUnionSQL(table, stmt.Schema.DBNames, rolled)).Session(&gorm.Session{}), nil
+}
+
+// logUnionSQL 合并历史表和当前表的子查询，别名与当前表同名，各表按相同的列顺序选出
+func logUnionSQL(table string, columns []string, rolled []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = "`" + column + "`"
+	}
+	selects := make([]string, 0, len(rolled)+1)
+	tables := append(append([]string{}, rolled...), table)
+	for _, name := range tables {
+		selects = append(selects, fmt.Sprintf("SELECT %s FROM %s", strings.Join(quoted, ", "), name))
+	}
+	return fmt.Sprintf("(%s) AS %s", strings.Join(selects, " UNION ALL "), table)
+}
+
+// recentRolledTables 只保留可能包含since之后日志的历史表: 月份不早于since所在月份的表，
+// 以及最近滚动出的一张表(滚动前最后写入的日志和定时任务延迟滚动时的日志都在这张表中)
+func recentRolledTables(table string, rolled []string, since time.Time) []string {
+	from := table + "_" + since.Format(model.LogTableMonthFormat)
+	for i, name := range rolled {
+		if name >= from || i == len(rolled)-1 {
+			return rolled[i:]
+		}
+	}
+	return nil
+}
+
+// archivePolicy 按月份从早到晚归档并删除已全部过期的历史表，表中最新的日志早于cutoff才视为过期
+func (s *LogRetentionService) archivePolicy(ctx context.Context, db *gorm.DB, policy logRetentionPolicy, cutoff time.Time) error {
+	rolled, err := model.RolledLogTables(db, policy.table)
+	if err != nil {
+		return err
+	}
+	for _, name := range rolled {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var newest sql.NullTime
+		if err := db.Table(name).Select("MAX(created_at)").Row().Scan(&newest); err != nil {
+			return fmt.Errorf("查询历史表 %s 最新时间失败: %w", name, err)
+		}
+		if newest.Valid && !newest.Time.Before(cutoff) {
+			// 之后的历史表更新，同样没有过期
+			return nil
+		}
+		if err := s.archiveTable(ctx, db, policy, name); err != nil {
+			return fmt.Errorf("归档历史表 %s 失败: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// archiveTable 把历史表写入归档文件后整表删除
+// 审计日志只能由审计链归档删除，操作日志历史表中尚未归档的审计日志在删除前转入当前表
+func (s *LogRetentionService) archiveTable(ctx context.Context, db *gorm.DB, policy logRetentionPolicy, name string) error {
+	rows := db.Table(name).Unscoped()
+	if policy.scope != nil {
+		rows = policy.scope(rows)
+	}
+	dir := getConfigValue(db, configKeyLogArchiveDir, defaultLogArchiveDir)
+	file := fmt.Sprintf("%s_logs_%s_%s.ndjson.gz", policy.kind, strings.TrimPrefix(name, policy.table+"_"),
+		time.Now().Format(logArchiveFileTimeFormat))
+	written, err := writeLogArchive(ctx, filepath.Join(dir, file), logKinds[policy.kind], rows)
+	if err != nil {
+		return err
+	}
+
+	if policy.table == operationLogTable {
+		if err := carryChainedLogs(db, name); err != nil {
+			return err
+		}
+	}
+	if err := db.Migrator().DropTable(name); err != nil {
+		return fmt.Errorf("删除历史表失败: %w", err)
+	}
+	log.Printf("已归档 %d 条%s日志并删除历史表 %s", written, policy.kind, name)
+	return nil
+}
+
+// carryChainedLogs 把操作日志历史表中尚未归档的审计日志按原ID复制到当前表，重复执行时跳过已复制的记录
+func carryChainedLogs(db *gorm.DB, rolled string) error {
+	var head model.AuditChainHead
+	if err := db.Where("id = ?", auditChainHeadID).Limit(1).Find(&head).Error; err != nil {
+		return fmt.Errorf("读取审计链头失败: %w", err)
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&model.OperationLog{}); err != nil {
+		return fmt.Errorf("解析日志模型失败: %w", err)
+	}
+	quoted := make([]string, len(stmt.Schema.DBNames))
+	for i, column := range stmt.Schema.DBNames {
+		quoted[i] = "`" + column + "`"
+	}
+	columns := strings.Join(quoted, ", ")
+	if err := db.Exec(fmt.Sprintf("INSERT IGNORE INTO %s (%s) SELECT %s FROM %s WHERE chain_seq > ?",
+		operationLogTable, columns, columns, rolled), head.ArchivedSeq).Error; err != nil {
+		return fmt.Errorf("转移未归档的审计日志失败: %w", err)
+	}
+	return nil
+}
+
+// archiveAuditChain 归档早于cutoff的审计日志前缀，归档边界写入锚点文件和链头后再从当前表和历史表中删除
+func (s *LogRetentionService) archiveAuditChain(ctx context.Context, db *gorm.DB, cutoff time.Time) error {
+	head, err := s.trail.head(ctx)
+	if err != nil {
+		return err
+	}
+	source, err := logSource(db, &model.OperationLog{}, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	var boundary model.OperationLog
+	err = source.Unscoped().
+		Where("chain_seq > ? AND created_at < ?", head.ArchivedSeq, cutoff).
+		Order("chain_seq DESC").First(&boundary).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询审计日志归档边界失败: %w", err)
+	}
+	seq := *boundary.ChainSeq
+
+	// 审计日志在链头锁内按序号写入，ID顺序与序号顺序一致
+	rows := source.Unscoped().Where("chain_seq > ? AND chain_seq <= ?", head.ArchivedSeq, seq)
+	dir := getConfigValue(db, configKeyLogArchiveDir, defaultLogArchiveDir)
+	name := fmt.Sprintf("audit_logs_%d-%d_%s.ndjson.gz", head.ArchivedSeq+1, seq, time.Now().Format(logArchiveFileTimeFormat))
+	if _, err := writeLogArchive(ctx, filepath.Join(dir, name), logKinds[LogKindOperation], rows); err != nil {
+		return fmt.Errorf("归档审计日志失败: %w", err)
+	}
+
+	// 先导出归档锚点再移动边界，校验时据此确认链头记录的归档边界没有被篡改
+	anchor := AuditAnchor{Seq: seq, Hash: boundary.Hash, AnchoredAt: time.Now(), Archived: true}
+	if err := appendAnchor(s.trail.AnchorFile(), anchor); err != nil {
+		return err
+	}
+	if err := db.Model(&model.AuditChainHead{}).Where("id = ? AND archived_seq < ?", auditChainHeadID, seq).
+		Updates(map[string]interface{}{"archived_seq": seq, "archived_hash": boundary.Hash}).Error; err != nil {
+		return fmt.Errorf("更新审计链归档边界失败: %w", err)
+	}
+
+	deleted, err := deleteArchivedChain(db, head.ArchivedSeq, seq)
+	if err != nil {
+		return fmt.Errorf("删除已归档审计日志失败: %w", err)
+	}
+	log.Printf("已归档并删除 %d 条审计日志 (序号 %d-%d)", deleted, head.ArchivedSeq+1, seq)
+	return nil
+}
+
+// writeLogArchive 按ID顺序把日志流式写入gzip压缩的NDJSON文件
+func writeLogArchive(ctx context.Context, path string, kind logKind, rows *gorm.DB) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, fmt.Errorf("创建归档目录失败: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return 0, fmt.Errorf("创建归档文件失败: %w", err)
+	}
+
+	buffered := bufio.NewWriter(file)
+	compressed := gzip.NewWriter(buffered)
+	written, err := writeLogRecords(ctx, compressed, kind, rows)
+	if err == nil {
+		err = compressed.Close()
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// 不完整的归档文件没有意义，删除后下次重新归档
+		_ = os.Remove(path)
+		return 0, fmt.Errorf("写入归档文件失败: %w", err)
+	}
+	return written, nil
+}
+
+// writeLogRecords 分批读取日志并逐行写出
+func writeLogRecords(ctx context.Context, w *gzip.Writer, kind logKind, rows *gorm.DB) (int64, error) {
+	exporter := newNDJSONLogExporter(w)
+	var (
+		cursor  uint
+		written int64
+	)
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		records, err := kind.find(rows.Session(&gorm.Session{}).Where("id > ?", cursor).
+			Order("id ASC").Limit(logArchiveBatchSize))
+		if err != nil {
+			return written, err
+		}
+		for i := range records {
+			if err := exporter.write(&records[i]); err != nil {
+				return written, err
+			}
+		}
+		written += int64(len(records))
+		if len(records) < logArchiveBatchSize {
+			return written, nil
+		}
+		cursor = records[len(records)-1].ID
+	}
+}
+
+// deleteArchivedChain 分批彻底删除当前表和历史表中序号在(from, to]内的已归档审计日志
+func deleteArchivedChain(db *gorm.DB, from, to uint64) (int64, error) {
+	rolled, err := model.RolledLogTables(db, operationLogTable)
+	if err != nil {
+		return 0, err
+	}
+	var deleted int64
+	for _, table := range append(rolled, operationLogTable) {
+		for {
+			var ids []uint
+			if err := db.Table(table).Where("chain_seq > ? AND chain_seq <= ?", from, to).
+				Order("id").Limit(logArchiveBatchSize).Pluck("id", &ids).Error; err != nil {
+				return deleted, fmt.Errorf("查询已归档审计日志失败: %w", err)
+			}
+			if len(ids) == 0 {
+				break
+			}
+			result := db.Table(table).Unscoped().Where("id IN ?", ids).Delete(&model.OperationLog{})
+			if result.Error != nil {
+				return deleted, fmt.Errorf("删除已归档审计日志失败: %w", result.Error)
+			}
+			deleted += result.RowsAffected
+		}
+	}
+	return deleted, nil
+}
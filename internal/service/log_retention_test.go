@@ -0,0 +1,82 @@
+package service
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// TestRetentionCutoff 测试保留期限对齐到月初，只归档完整过期的月份
+func TestRetentionCutoff(t *testing.T) {
+	now := time.Date(2024, 5, 20, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		days int
+		want time.Time
+	}{
+		{30, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{19, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{20, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{365, time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := retentionCutoff(now, tt.days); !got.Equal(tt.want) {
+			t.Errorf("保留 %d 天的截止时间 = %s, 期望 %s", tt.days, got, tt.want)
+		}
+	}
+}
+
+// TestLogRetentionPolicies 测试各类日志都有保留配置，操作日志排除审计日志
+func TestLogRetentionPolicies(t *testing.T) {
+	for _, policy := range logRetentionPolicies {
+		if _, ok := logKinds[policy.kind]; !ok {
+			t.Errorf("保留配置 %s 没有对应的日志类型", policy.kind)
+		}
+		parsed, err := schema.Parse(logKinds[policy.kind].newModel(), &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("解析 %s 日志模型失败: %v", policy.kind, err)
+		}
+		if policy.table != parsed.Table {
+			t.Errorf("保留配置 %s 的表名 = %s, 期望 %s", policy.kind, policy.table, parsed.Table)
+		}
+		if policy.kind == LogKindOperation && policy.scope == nil {
+			t.Error("操作日志的保留配置必须排除哈希链中的审计日志")
+		}
+	}
+	if len(logRetentionPolicies) != len(logKinds) {
+		t.Errorf("保留配置数量 = %d, 期望与日志类型数量 %d 一致", len(logRetentionPolicies), len(logKinds))
+	}
+}
+
+// TestRecentRolledTables 测试按起始时间筛选需要合并查询的历史表，最近滚动出的表始终保留
+func TestRecentRolledTables(t *testing.T) {
+	rolled := []string{"system_logs_202401", "system_logs_202402", "system_logs_202403"}
+	tests := []struct {
+		since time.Time
+		want  []string
+	}{
+		{time.Date(2023, 12, 15, 0, 0, 0, 0, time.UTC), rolled},
+		{time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC), rolled[1:]},
+		{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), rolled[2:]},
+		{time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), rolled[2:]},
+	}
+	for _, tt := range tests {
+		if got := recentRolledTables("system_logs", rolled, tt.since); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("起始时间 %s 的历史表 = %v, 期望 %v", tt.since.Format("2006-01-02"), got, tt.want)
+		}
+	}
+	if got := recentRolledTables("system_logs", nil, time.Now()); len(got) != 0 {
+		t.Errorf("没有历史表时返回 %v, 期望为空", got)
+	}
+}
+
+// TestLogUnionSQL 测试历史表和当前表按相同列顺序合并，别名与当前表同名
+func TestLogUnionSQL(t *testing.T) {
+	got := logUnionSQL("system_logs", []string{"id", "level"}, []string{"system_logs_202401"})
+	want := "(SELECT `id`, `level` FROM system_logs_202401 UNION ALL SELECT `id`, `level` FROM system_logs) AS system_logs"
+	if got != want {
+		t.Errorf("合并查询 = %s, 期望 %s", got, want)
+	}
+}
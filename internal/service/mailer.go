@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/mail"
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// SMTP相关的系统配置键
+const (
+	configKeySMTPHost     = "email.smtp.host"
+	configKeySMTPPort     = "email.smtp.port"
+	configKeySMTPUsername = "email.smtp.username"
+	configKeySMTPPassword = "email.smtp.password"
+	configKeySMTPFrom     = "email.smtp.from"
+	configKeySMTPFromName = "email.smtp.from_name"
+	defaultSMTPPort       = 587
+)
+
+// 发件箱投递参数
+const (
+	emailBatchSize      = 50
+	emailBaseBackoff    = time.Minute
+	emailMaxBackoff     = 6 * time.Hour
+	emailSendingTimeout = 10 * time.Minute
+	maxEmailErrorLength = 1000
+)
+
+// Mailer 邮件服务，邮件先写入发件箱，由后台任务按重试策略投递
+type Mailer struct {
+	db *gorm.DB
+}
+
+// NewMailer 创建邮件服务
+func NewMailer(db *gorm.DB) *Mailer {
+	return &Mailer{db: db}
+}
+
+// EmailRequest 按模板发送邮件的请求
+type EmailRequest struct {
+	To       string
+	UserID   *uint
+	Template string
+	Locale   string
+	Data     map[string]interface{}
+}
+
+// EmailTestResult 测试邮件的发送结果
+type EmailTestResult struct {
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration_ms"`
+}
+
+// Enqueue 渲染模板并写入发件箱
+func (m *Mailer) Enqueue(req *EmailRequest) (*model.EmailOutbox, error) {
+	return enqueueEmail(m.db, req)
+}
+
+// enqueueEmail 在事务中渲染模板并写入发件箱，使邮件与业务数据一起提交
+func enqueueEmail(tx *gorm.DB, req *EmailRequest) (*model.EmailOutbox, error) {
+	email, err := renderEmail(req.Template, req.Locale, req.Data)
+	if err != nil {
+		return nil, err
+	}
+	return saveOutbox(tx, req.To, req.UserID, req.Template, email)
+}
+
+// saveOutbox 写入一封待发送邮件
+func saveOutbox(tx *gorm.DB, to string, userID *uint, templateKey string, email *renderedEmail) (*model.EmailOutbox, error) {
+	to = strings.TrimSpace(to)
+	if to == "" {
+		return nil, ErrInvalidParam
+	}
+	outbox := &model.EmailOutbox{
+		UserID:        userID,
+		ToAddress:     to,
+		Subject:       email.Subject,
+		TextBody:      email.Text,
+		HTMLBody:      email.HTML,
+		Template:      templateKey,
+		Status:        model.EmailStatusPending,
+		MaxAttempts:   model.DefaultEmailMaxAttempts,
+		NextAttemptAt: time.Now(),
+	}
+	if err := tx.Create(outbox).Error; err != nil {
+		return nil, fmt.Errorf("写入发件箱失败: %w", err)
+	}
+	return outbox, nil
+}
+
+// DeliverPending 投递发件箱中到期的邮件，未配置SMTP时邮件保持待发送状态
+func (m *Mailer) DeliverPending(ctx context.Context) error {
+	if err := m.recoverStale(); err != nil {
+		return err
+	}
+	config := loadMailConfig(m.db)
+	if !config.IsConfigured() {
+		return nil
+	}
+
+	var pending []model.EmailOutbox
+	if err := m.db.Where("status = ? AND next_attempt_at <= ?", model.EmailStatusPending, time.Now()).
+		Order("id").Limit(emailBatchSize).Find(&pending).Error; err != nil {
+		return fmt.Errorf("查询待发送邮件失败: %w", err)
+	}
+
+	for i := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		claimed, err := m.claim(&pending[i])
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		sendErr := mail.Send(config, &mail.Message{
+			To:      []string{pending[i].ToAddress},
+			Subject: pending[i].Subject,
+			Text:    pending[i].TextBody,
+			HTML:    pending[i].HTMLBody,
+		})
+		if err := m.finish(&pending[i], sendErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recoverStale 将长时间处于发送中的邮件恢复为待发送，避免进程中断后邮件卡住
+func (m *Mailer) recoverStale() error {
+	if err := m.db.Model(&model.EmailOutbox{}).
+		Where("status = ? AND updated_at < ?", model.EmailStatusSending, time.Now().Add(-emailSendingTimeout)).
+		Update("status", model.EmailStatusPending).Error; err != nil {
+		return fmt.Errorf("恢复发送中的邮件失败: %w", err)
+	}
+	return nil
+}
+
+// claim 抢占一封待发送邮件，多实例部署时只有一个实例能抢占成功
+func (m *Mailer) claim(outbox *model.EmailOutbox) (bool, error) {
+	result := m.db.Model(&model.EmailOutbox{}).
+		Where("id = ? AND status = ?", outbox.ID, model.EmailStatusPending).
+		Update("status", model.EmailStatusSending)
+	if result.Error != nil {
+		return false, fmt.Errorf("抢占待发送邮件失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// finish 记录发送结果，失败时按指数退避安排重试，超过最大次数后标记为失败
+func (m *Mailer) finish(outbox *model.EmailOutbox, sendErr error) error {
+	now := time.Now()
+	attempts := outbox.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+	switch {
+	case sendErr == nil:
+		updates["status"] = model.EmailStatusSent
+		updates["sent_at"] = now
+		updates["last_error"] = ""
+	case attempts >= outbox.MaxAttempts:
+		updates["status"] = model.EmailStatusFailed
+		updates["last_error"] = truncate(sendErr.Error(), maxEmailErrorLength)
+	default:
+		updates["status"] = model.EmailStatusPending
+		updates["next_attempt_at"] = now.Add(emailBackoff(attempts))
+		updates["last_error"] = truncate(sendErr.Error(), maxEmailErrorLength)
+	}
+	if sendErr != nil {
+		log.Printf("发送邮件 %d 到 %s 失败(第%d次): %v", outbox.ID, outbox.ToAddress, attempts, sendErr)
+	}
+
+	if err := m.db.Model(outbox).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新邮件发送状态失败: %w", err)
+	}
+	return nil
+}
+
+// loadMailConfig 从邮件类系统配置读取SMTP参数
+func loadMailConfig(db *gorm.DB) mail.Config {
+	return mail.Config{
+		Host:     getConfigValue(db, configKeySMTPHost, ""),
+		Port:     getConfigInt(db, configKeySMTPPort, defaultSMTPPort),
+		Username: getConfigValue(db, configKeySMTPUsername, ""),
+		Password: getConfigValue(db, configKeySMTPPassword, ""),
+		From:     getConfigValue(db, configKeySMTPFrom, ""),
+		FromName: getConfigValue(db, configKeySMTPFromName, ""),
+	}
+}
+
+// emailBackoff 第attempts次失败后的重试间隔，按分钟指数增长并设置上限
+func emailBackoff(attempts int) time.Duration {
+	backoff := emailBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= emailMaxBackoff {
+			return emailMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// SendTest 拥有系统配置权限的用户发送测试邮件以验证SMTP配置，同步发送且不经过发件箱
+func (m *Mailer) SendTest(operatorID uint, to string) (*EmailTestResult, error) {
+	if _, err := requireSystemPermission(m.db, operatorID, model.PermissionSystemConfig); err != nil {
+		return nil, err
+	}
+	config := loadMailConfig(m.db)
+	if !config.IsConfigured() {
+		return nil, ErrMailNotConfigured
+	}
+
+	start := time.Now()
+	err := mail.Send(config, &mail.Message{
+		To:      []string{strings.TrimSpace(to)},
+		Subject: "测试邮件",
+		Text:    "这是一封测试邮件，收到说明邮件服务配置正确。",
+	})
+	result := &EmailTestResult{Success: err == nil, Duration: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, nil
+}
+
+// truncate 按字符截断字符串
+func truncate(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
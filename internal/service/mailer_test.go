@@ -0,0 +1,64 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ycg_cloud/internal/model"
+)
+
+// TestRenderEmail 测试邮件模板渲染及HTML转义
+func TestRenderEmail(t *testing.T) {
+	data := map[string]interface{}{
+		"Inviter":  "<alice>",
+		"TeamName": "研发部",
+		"Link":     "https://example.com/invite?code=abc",
+	}
+
+	email, err := renderEmail(EmailTeamInvitation, model.LocaleZhCN, data)
+	if err != nil {
+		t.Fatalf("渲染邮件失败: %v", err)
+	}
+	if email.Subject != "<alice> 邀请你加入团队「研发部」" {
+		t.Errorf("邮件主题不正确: %s", email.Subject)
+	}
+	if !strings.Contains(email.Text, "https://example.com/invite?code=abc") {
+		t.Errorf("纯文本正文缺少链接: %s", email.Text)
+	}
+	if !strings.Contains(email.HTML, "&lt;alice&gt;") || strings.Contains(email.HTML, "<alice>") {
+		t.Errorf("HTML正文未转义变量: %s", email.HTML)
+	}
+	if !strings.Contains(email.HTML, "<!DOCTYPE html>") {
+		t.Error("HTML正文缺少公共布局")
+	}
+
+	for _, key := range []string{EmailVerification, EmailPasswordReset} {
+		if _, err := renderEmail(key, model.LocaleEnUS, map[string]interface{}{
+			"Username": "alice", "Link": "https://example.com", "ExpireMinutes": 30,
+		}); err != nil {
+			t.Errorf("渲染模板 %s 失败: %v", key, err)
+		}
+	}
+	if _, err := renderEmail("unknown", model.LocaleZhCN, data); err != ErrEmailTemplate {
+		t.Errorf("期望 ErrEmailTemplate, 实际 %v", err)
+	}
+}
+
+// TestEmailBackoff 测试发件箱重试间隔
+func TestEmailBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{20, emailMaxBackoff},
+	}
+	for _, tc := range cases {
+		if got := emailBackoff(tc.attempts); got != tc.want {
+			t.Errorf("第%d次失败: 期望 %v, 实际 %v", tc.attempts, tc.want, got)
+		}
+	}
+}
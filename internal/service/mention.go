@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/realtime"
+
+	"gorm.io/gorm"
+)
+
+// mentionAll 提及会话全体成员的关键字
+const mentionAll = "all"
+
+// mentionPattern 匹配行首或空白之后的@用户名，避免误识别邮箱地址
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@([A-Za-z0-9_.\-]{1,50})`)
+
+// mentionResult 消息提及解析结果，序列化后写入Message.Mentions
+type mentionResult struct {
+	UserIDs []uint `json:"user_ids"`
+	All     bool   `json:"all"`
+
+	recipients []mentionRecipient
+}
+
+// mentionRecipient 需要通知的被提及成员
+type mentionRecipient struct {
+	UserID uint
+	Direct bool
+	Muted  bool
+}
+
+// mentionMember 会话成员的提及信息
+type mentionMember struct {
+	UserID   uint
+	Username string
+	IsMuted  bool
+}
+
+// MentionItem 提及我的消息
+type MentionItem struct {
+	ID             uint                    `json:"id"`
+	ConversationID uint                    `json:"conversation_id"`
+	IsDirect       bool                    `json:"is_direct"`
+	MentionAll     bool                    `json:"mention_all"`
+	Message        realtime.MessagePayload `json:"message"`
+}
+
+// parseMentions 从消息内容中解析被提及的用户名，@all单独返回
+func parseMentions(content string) (usernames []string, all bool) {
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		// 去掉结尾的标点，便于"@alice." 这类写法
+		name := strings.TrimRight(match[1], ".-")
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if key == mentionAll {
+			all = true
+			continue
+		}
+		usernames = append(usernames, name)
+	}
+	return usernames, all
+}
+
+// resolveMentions 解析并校验消息中的提及，被提及的已注册用户必须是会话成员
+func resolveMentions(tx *gorm.DB, conversationID, senderID uint, content string) (*mentionResult, error) {
+	result := &mentionResult{UserIDs: []uint{}}
+	usernames, all := parseMentions(content)
+	if len(usernames) == 0 && !all {
+		return result, nil
+	}
+
+	var members []mentionMember
+	if err := tx.Table("conversation_members AS cm").
+		Select("cm.user_id, u.username, cm.is_muted").
+		Joins("JOIN users AS u ON u.id = cm.user_id").
+		Where("cm.conversation_id = ? AND cm.deleted_at IS NULL", conversationID).
+		Scan(&members).Error; err != nil {
+		return nil, fmt.Errorf("查询会话成员失败: %w", err)
+	}
+	byName := make(map[string]mentionMember, len(members))
+	for _, member := range members {
+		byName[strings.ToLower(member.Username)] = member
+	}
+
+	direct := make(map[uint]bool)
+	for _, name := range usernames {
+		member, ok := byName[strings.ToLower(name)]
+		if !ok {
+			if err := checkMentionedUser(tx, name); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if member.UserID != senderID && !direct[member.UserID] {
+			direct[member.UserID] = true
+			result.UserIDs = append(result.UserIDs, member.UserID)
+		}
+	}
+
+	result.All = all
+	for _, member := range members {
+		if member.UserID == senderID || (!all && !direct[member.UserID]) {
+			continue
+		}
+		result.recipients = append(result.recipients, mentionRecipient{
+			UserID: member.UserID,
+			Direct: direct[member.UserID],
+			Muted:  member.IsMuted,
+		})
+	}
+	return result, nil
+}
+
+// checkMentionedUser 被提及的用户名对应已注册用户但不在会话中时返回错误，未注册的按普通文本处理
+func checkMentionedUser(tx *gorm.DB, username string) error {
+	var count int64
+	if err := tx.Model(&model.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: @%s", ErrMentionNotMember, username)
+	}
+	return nil
+}
+
+// encode 序列化为Message.Mentions字段内容，没有提及时为空
+func (r *mentionResult) encode() string {
+	if len(r.recipients) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// saveMentions 重建消息的提及记录，返回此前已被提及的用户，用于编辑时避免重复通知
+func saveMentions(tx *gorm.DB, message *model.Message, result *mentionResult) (map[uint]bool, error) {
+	var previousIDs []uint
+	if err := tx.Model(&model.MessageMention{}).Where("message_id = ?", message.ID).
+		Pluck("user_id", &previousIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询消息提及失败: %w", err)
+	}
+	previous := make(map[uint]bool, len(previousIDs))
+	for _, id := range previousIDs {
+		previous[id] = true
+	}
+
+	if len(previousIDs) > 0 {
+		if err := tx.Where("message_id = ?", message.ID).Delete(&model.MessageMention{}).Error; err != nil {
+			return nil, fmt.Errorf("清理消息提及失败: %w", err)
+		}
+	}
+	if len(result.recipients) == 0 {
+		return previous, nil
+	}
+
+	mentions := make([]model.MessageMention, 0, len(result.recipients))
+	for _, recipient := range result.recipients {
+		mentions = append(mentions, model.MessageMention{
+			MessageID:      message.ID,
+			UserID:         recipient.UserID,
+			ConversationID: message.ConversationID,
+			SenderID:       message.SenderID,
+			IsDirect:       recipient.Direct,
+			MentionAll:     result.All,
+		})
+	}
+	if err := tx.Create(&mentions).Error; err != nil {
+		return nil, fmt.Errorf("保存消息提及失败: %w", err)
+	}
+	return previous, nil
+}
+
+// notifyMentions 推送提及通知，直接提及为高优先级并忽略成员的免打扰设置，@all遵循免打扰
+func (s *MessageService) notifyMentions(ctx context.Context, message *model.Message, result *mentionResult, skip map[uint]bool) {
+	var priority, normal []uint
+	for _, recipient := range result.recipients {
+		switch {
+		case skip[recipient.UserID]:
+			// 编辑前已经通知过
+		case recipient.Direct:
+			priority = append(priority, recipient.UserID)
+		case !recipient.Muted:
+			normal = append(normal, recipient.UserID)
+		}
+	}
+
+	for _, group := range []struct {
+		recipients []uint
+		priority   bool
+	}{{priority, true}, {normal, false}} {
+		if len(group.recipients) == 0 {
+			continue
+		}
+		event, err := realtime.NewEvent(realtime.EventMention, message.ConversationID, message.SenderID, map[string]interface{}{
+			"priority": group.priority,
+			"message":  realtime.NewMessagePayload(message),
+		})
+		if err != nil {
+			log.Printf("构造提及事件失败: %v", err)
+			return
+		}
+		event.MessageID = message.ID
+		if err := s.publisher.Publish(ctx, group.recipients, event); err != nil {
+			log.Printf("推送提及通知失败: %v", err)
+		}
+	}
+}
+
+// ListMentions 查询提及我的消息，已撤回的消息不再展示
+func (s *MessageService) ListMentions(userID uint, page, pageSize int) ([]MentionItem, int64, error) {
+	db := s.db.Model(&model.MessageMention{}).
+		Joins("JOIN messages ON messages.id = message_mentions.message_id").
+		Where("message_mentions.user_id = ? AND messages.recalled_flag = ? AND messages.deleted_at IS NULL", userID, false)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计提及消息失败: %w", err)
+	}
+
+	var mentions []model.MessageMention
+	if err := db.Preload("Message").
+		Order("message_mentions.id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&mentions).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询提及消息失败: %w", err)
+	}
+
+	items := make([]MentionItem, 0, len(mentions))
+	for i := range mentions {
+		items = append(items, MentionItem{
+			ID:             mentions[i].ID,
+			ConversationID: mentions[i].ConversationID,
+			IsDirect:       mentions[i].IsDirect,
+			MentionAll:     mentions[i].MentionAll,
+			Message:        realtime.NewMessagePayload(&mentions[i].Message),
+		})
+	}
+	return items, total, nil
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseMentions 测试消息提及解析
+func TestParseMentions(t *testing.T) {
+	cases := []struct {
+		name      string
+		content   string
+		usernames []string
+		all       bool
+	}{
+		{"单个用户", "@alice 请看一下", []string{"alice"}, false},
+		{"多个用户去重", "@alice @bob, @Alice", []string{"alice", "bob"}, false},
+		{"提及全体", "@all 开会了 @bob", []string{"bob"}, true},
+		{"结尾标点", "交给 @carol.", []string{"carol"}, false},
+		{"忽略邮箱", "发到 dave@example.com", nil, false},
+		{"无提及", "普通消息", nil, false},
+	}
+
+	for _, tc := range cases {
+		usernames, all := parseMentions(tc.content)
+		if !reflect.DeepEqual(usernames, tc.usernames) || all != tc.all {
+			t.Errorf("%s: 期望 %v/%t, 实际 %v/%t", tc.name, tc.usernames, tc.all, usernames, all)
+		}
+	}
+}
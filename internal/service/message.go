@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/realtime"
+
+	"gorm.io/gorm"
+)
+
+// 消息分页参数
+const (
+	defaultMessageLimit = 20
+	maxMessageLimit     = 100
+	maxMessageLength    = 5000
+)
+
+// MessageService 消息服务
+type MessageService struct {
+	db        *gorm.DB
+	publisher EventPublisher
+	notifier  *NotificationService
+}
+
+// NewMessageService 创建消息服务，notifier用于向被撤回消息的发送者发送通知
+func NewMessageService(db *gorm.DB, publisher EventPublisher, notifier *NotificationService) *MessageService {
+	return &MessageService{db: db, publisher: publisher, notifier: notifier}
+}
+
+// MessageQuery 消息游标查询参数，BeforeID向前翻历史，AfterID拉取新消息
+type MessageQuery struct {
+	BeforeID uint
+	AfterID  uint
+	Limit    int
+}
+
+// MessagePage 消息游标分页结果
+type MessagePage struct {
+	List       []realtime.MessagePayload `json:"list"`
+	NextCursor uint                      `json:"next_cursor"`
+	HasMore    bool                      `json:"has_more"`
+}
+
+// SendMessageRequest 发送消息请求
+type SendMessageRequest struct {
+	Type          string `json:"type" binding:"required,oneof=text file reply forward"`
+	Content       string `json:"content"`
+	Metadata      string `json:"metadata"`
+	ReplyToID     *uint  `json:"reply_to_id"`
+	ForwardFromID *uint  `json:"forward_from_id"`
+	FileID        *uint  `json:"file_id"`
+}
+
+// ListMessages 按游标获取会话消息
+func (s *MessageService) ListMessages(conversationID, userID uint, query *MessageQuery) (*MessagePage, error) {
+	if _, err := getConversationMember(s.db, conversationID, userID); err != nil {
+		return nil, err
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultMessageLimit
+	}
+	if limit > maxMessageLimit {
+		limit = maxMessageLimit
+	}
+
+	db := s.db.Where("conversation_id = ?", conversationID)
+	if query.AfterID > 0 {
+		db = db.Where("id > ?", query.AfterID).Order("id ASC")
+	} else {
+		if query.BeforeID > 0 {
+			db = db.Where("id < ?", query.BeforeID)
+		}
+		db = db.Order("id DESC")
+	}
+
+	// 多取一条用于判断是否还有更多
+	var messages []model.Message
+	if err := db.Limit(limit + 1).Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("查询消息失败: %w", err)
+	}
+
+	page := &MessagePage{List: make([]realtime.MessagePayload, 0, limit)}
+	if len(messages) > limit {
+		page.HasMore = true
+		messages = messages[:limit]
+	}
+	for i := range messages {
+		page.List = append(page.List, realtime.NewMessagePayload(&messages[i]))
+	}
+	if len(messages) > 0 {
+		page.NextCursor = messages[len(messages)-1].ID
+	}
+	return page, nil
+}
+
+// SendMessage 发送消息并推送给会话成员
+func (s *MessageService) SendMessage(ctx context.Context, conversationID, senderID uint, req *SendMessageRequest) (*model.Message, error) {
+	var (
+		message  *model.Message
+		mentions *mentionResult
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		conversation, err := getConversation(tx, conversationID)
+		if err != nil {
+			return err
+		}
+		if !conversation.IsActive() {
+			return ErrConversationInactive
+		}
+		if _, err := getConversationMember(tx, conversationID, senderID); err != nil {
+			return err
+		}
+
+		message, err = buildMessage(tx, conversation, senderID, req)
+		if err != nil {
+			return err
+		}
+		mentions = &mentionResult{}
+		if message.CanEdit() {
+			if mentions, err = resolveMentions(tx, conversationID, senderID, message.Content); err != nil {
+				return err
+			}
+			message.Mentions = mentions.encode()
+		}
+		if err := saveMessage(tx, conversation, message); err != nil {
+			return err
+		}
+		if err := grantMessageFile(tx, message); err != nil {
+			return err
+		}
+		_, err = saveMentions(tx, message, mentions)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishMessage(ctx, realtime.EventMessageNew, message, senderID)
+	s.notifyMentions(ctx, message, mentions, nil)
+	return message, nil
+}
+
+// buildMessage 校验请求并构造消息
+func buildMessage(tx *gorm.DB, conversation *model.Conversation, senderID uint, req *SendMessageRequest) (*model.Message, error) {
+	message := &model.Message{
+		ConversationID: conversation.ID,
+		SenderID:       senderID,
+		Content:        strings.TrimSpace(req.Content),
+		Metadata:       req.Metadata,
+	}
+
+	switch req.Type {
+	case string(model.MessageTypeText):
+		message.Type = model.MessageTypeText
+	case string(model.MessageTypeReply):
+		if req.ReplyToID == nil {
+			return nil, ErrInvalidMessage
+		}
+		if _, err := getConversationMessage(tx, conversation.ID, *req.ReplyToID); err != nil {
+			return nil, err
+		}
+		message.Type = model.MessageTypeReply
+		message.ReplyToID = req.ReplyToID
+	case string(model.MessageTypeFile):
+		if err := attachFile(tx, conversation, message, req.FileID); err != nil {
+			return nil, err
+		}
+	case string(model.MessageTypeForward):
+		if err := copyForwardSource(tx, conversation, message, req.ForwardFromID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrInvalidMessage
+	}
+
+	if message.Content == "" || len([]rune(message.Content)) > maxMessageLength {
+		return nil, ErrInvalidMessage
+	}
+	return message, nil
+}
+
+// attachFile 为文件消息关联文件
+func attachFile(tx *gorm.DB, conversation *model.Conversation, message *model.Message, fileID *uint) error {
+	if fileID == nil {
+		return ErrInvalidMessage
+	}
+	if !conversation.AllowFileShare {
+		return ErrFileShareDisabled
+	}
+
+	file, err := getFile(tx, *fileID)
+	if err != nil {
+		return err
+	}
+	if err := requireFileShare(tx, file, message.SenderID); err != nil {
+		return err
+	}
+	message.Type = model.MessageTypeFile
+	message.FileID = &file.ID
+	if message.Content == "" {
+		message.Content = file.Name
+	}
+	return nil
+}
+
+// requireFileShare 校验发送者是否有权将文件分享到会话
+func requireFileShare(tx *gorm.DB, file *model.File, senderID uint) error {
+	allowed, err := hasFilePermission(tx, file, senderID, model.PermissionShare)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// copyForwardSource 复制转发来源消息的内容，来源必须位于发送者所在的会话
+func copyForwardSource(tx *gorm.DB, conversation *model.Conversation, message *model.Message, sourceID *uint) error {
+	if sourceID == nil {
+		return ErrInvalidMessage
+	}
+
+	var source model.Message
+	if err := tx.First(&source, *sourceID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMessageNotFound
+		}
+		return fmt.Errorf("查询转发消息失败: %w", err)
+	}
+	if source.IsRecalled() {
+		return ErrMessageNotFound
+	}
+	if _, err := getConversationMember(tx, source.ConversationID, message.SenderID); err != nil {
+		return err
+	}
+	if source.FileID != nil {
+		if !conversation.AllowFileShare {
+			return ErrFileShareDisabled
+		}
+		file, err := getFile(tx, *source.FileID)
+		if err != nil {
+			return err
+		}
+		if err := requireFileShare(tx, file, message.SenderID); err != nil {
+			return err
+		}
+	}
+
+	message.Type = model.MessageTypeForward
+	message.ForwardFromID = &source.ID
+	message.Content = source.Content
+	message.Metadata = source.Metadata
+	message.FileID = source.FileID
+	return nil
+}
+
+// saveMessage 保存消息并更新会话最后消息、成员未读数
+func saveMessage(tx *gorm.DB, conversation *model.Conversation, message *model.Message) error {
+	if err := tx.Create(message).Error; err != nil {
+		return fmt.Errorf("保存消息失败: %w", err)
+	}
+
+	if err := tx.Model(conversation).Updates(map[string]interface{}{
+		"last_message_id": message.ID,
+		"last_message_at": message.CreatedAt,
+	}).Error; err != nil {
+		return fmt.Errorf("更新会话最后消息失败: %w", err)
+	}
+
+	if err := tx.Model(&model.ConversationMember{}).
+		Where("conversation_id = ? AND user_id <> ?", conversation.ID, message.SenderID).
+		UpdateColumn("unread_count", gorm.Expr("unread_count + 1")).Error; err != nil {
+		return fmt.Errorf("更新未读数失败: %w", err)
+	}
+
+	// 发送者自己的消息视为已读
+	if err := tx.Model(&model.ConversationMember{}).
+		Where("conversation_id = ? AND user_id = ?", conversation.ID, message.SenderID).
+		Updates(map[string]interface{}{
+			"last_read_message_id": message.ID,
+			"last_read_at":         time.Now(),
+			"unread_count":         0,
+		}).Error; err != nil {
+		return fmt.Errorf("更新已读位置失败: %w", err)
+	}
+	return nil
+}
+
+// MarkRead 标记会话已读到指定消息
+func (s *MessageService) MarkRead(ctx context.Context, conversationID, userID, messageID uint) error {
+	if _, err := getConversationMember(s.db, conversationID, userID); err != nil {
+		return err
+	}
+	if _, err := getConversationMessage(s.db, conversationID, messageID); err != nil {
+		return err
+	}
+	if err := realtime.MarkRead(s.db, conversationID, userID, messageID); err != nil {
+		return err
+	}
+
+	event, err := realtime.NewEvent(realtime.EventRead, conversationID, userID, nil)
+	if err != nil {
+		return err
+	}
+	event.MessageID = messageID
+	if err := s.publisher.PublishToConversation(ctx, conversationID, userID, event); err != nil {
+		log.Printf("推送已读事件失败: %v", err)
+	}
+	return nil
+}
+
+// publishMessage 推送消息事件，推送失败不影响业务结果
+func (s *MessageService) publishMessage(ctx context.Context, eventType realtime.EventType, message *model.Message, actorID uint) {
+	publishMessageEvent(ctx, s.publisher, eventType, message, actorID)
+}
+
+// publishMessageEvent 向会话全体成员推送消息事件，未配置推送时跳过
+func publishMessageEvent(ctx context.Context, publisher EventPublisher, eventType realtime.EventType, message *model.Message, actorID uint) {
+	if publisher == nil || message == nil {
+		return
+	}
+	event, err := realtime.NewMessageEvent(eventType, message, actorID)
+	if err != nil {
+		log.Printf("构造消息事件失败: %v", err)
+		return
+	}
+	if err := publisher.PublishToConversation(ctx, message.ConversationID, 0, event); err != nil {
+		log.Printf("推送消息事件失败: %v", err)
+	}
+}
+
+// getConversationMessage 获取会话中的消息
+func getConversationMessage(tx *gorm.DB, conversationID, messageID uint) (*model.Message, error) {
+	var message model.Message
+	err := tx.Where("id = ? AND conversation_id = ?", messageID, conversationID).First(&message).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, fmt.Errorf("查询消息失败: %w", err)
+	}
+	return &message, nil
+}
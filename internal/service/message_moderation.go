@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/realtime"
+
+	"gorm.io/gorm"
+)
+
+// 消息撤回时限配置
+const (
+	configKeyRecallWindow             = "im.message.recall_window_seconds"
+	configKeyConversationRecallWindow = "im.conversation.%d.recall_window_seconds"
+	maxRecallWindowSeconds            = 24 * 60 * 60
+)
+
+// EditMessageRequest 编辑消息请求
+type EditMessageRequest struct {
+	Content  string `json:"content" binding:"required"`
+	Metadata string `json:"metadata"`
+}
+
+// EditMessage 编辑消息，保留每个版本的完整历史
+func (s *MessageService) EditMessage(ctx context.Context, conversationID, messageID, editorID uint, req *EditMessageRequest) (*model.Message, error) {
+	content := strings.TrimSpace(req.Content)
+	if content == "" || len([]rune(content)) > maxMessageLength {
+		return nil, ErrInvalidMessage
+	}
+
+	var (
+		message  *model.Message
+		mentions *mentionResult
+		notified map[uint]bool
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		conversation, err := getConversation(tx, conversationID)
+		if err != nil {
+			return err
+		}
+		if !conversation.IsActive() {
+			return ErrConversationInactive
+		}
+		// 已离开或被移出会话的成员不能再编辑之前的消息
+		if _, err := getConversationMember(tx, conversationID, editorID); err != nil {
+			return err
+		}
+		message, err = getConversationMessage(tx, conversationID, messageID)
+		if err != nil {
+			return err
+		}
+		if message.SenderID != editorID {
+			return ErrForbidden
+		}
+		if !message.CanEdit() {
+			return ErrMessageNotEditable
+		}
+		if mentions, err = resolveMentions(tx, conversationID, editorID, content); err != nil {
+			return err
+		}
+		if err := saveRevision(tx, message, editorID, content, req.Metadata); err != nil {
+			return err
+		}
+		if err := tx.Model(message).Update("mentions", mentions.encode()).Error; err != nil {
+			return fmt.Errorf("更新消息提及失败: %w", err)
+		}
+		notified, err = saveMentions(tx, message, mentions)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishMessage(ctx, realtime.EventMessageEdit, message, editorID)
+	s.notifyMentions(ctx, message, mentions, notified)
+	return message, nil
+}
+
+// saveRevision 写入编辑版本并更新消息，首次编辑时先保存原始内容为版本0
+func saveRevision(tx *gorm.DB, message *model.Message, editorID uint, content, metadata string) error {
+	var latest model.MessageRevision
+	err := tx.Where("message_id = ?", message.ID).Order("revision DESC").First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		latest = model.MessageRevision{
+			MessageID: message.ID,
+			Revision:  0,
+			Content:   message.Content,
+			Metadata:  message.Metadata,
+			EditedBy:  message.SenderID,
+			EditedAt:  message.CreatedAt,
+		}
+		if err := tx.Create(&latest).Error; err != nil {
+			return fmt.Errorf("保存原始消息版本失败: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("查询消息版本失败: %w", err)
+	}
+
+	now := time.Now()
+	revision := model.MessageRevision{
+		MessageID: message.ID,
+		Revision:  latest.Revision + 1,
+		Content:   content,
+		Metadata:  metadata,
+		EditedBy:  editorID,
+		EditedAt:  now,
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		return fmt.Errorf("保存消息版本失败: %w", err)
+	}
+
+	updates := map[string]interface{}{
+		"content":   content,
+		"metadata":  metadata,
+		"is_edited": true,
+		"edited_at": now,
+	}
+	if message.RawContent == "" {
+		updates["raw_content"] = message.Content
+	}
+	if err := tx.Model(message).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新消息失败: %w", err)
+	}
+	return nil
+}
+
+// ListRevisions 获取消息的编辑历史
+func (s *MessageService) ListRevisions(conversationID, messageID, userID uint) ([]model.MessageRevision, error) {
+	if _, err := getConversationMember(s.db, conversationID, userID); err != nil {
+		return nil, err
+	}
+	message, err := getConversationMessage(s.db, conversationID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.IsRecalled() {
+		return nil, ErrMessageRecalled
+	}
+
+	var revisions []model.MessageRevision
+	if err := s.db.Where("message_id = ?", messageID).Order("revision").Find(&revisions).Error; err != nil {
+		return nil, fmt.Errorf("查询消息版本失败: %w", err)
+	}
+	return revisions, nil
+}
+
+// RecallMessage 撤回消息，发送者需在撤回时限内，会话管理员可随时撤回任何消息
+func (s *MessageService) RecallMessage(ctx context.Context, conversationID, messageID, operatorID uint) (*model.Message, error) {
+	var (
+		message *model.Message
+		notices []NotifyRequest
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		member, err := getConversationMember(tx, conversationID, operatorID)
+		if err != nil {
+			return err
+		}
+		message, err = getConversationMessage(tx, conversationID, messageID)
+		if err != nil {
+			return err
+		}
+		if message.IsRecalled() {
+			return ErrMessageRecalled
+		}
+		if err := checkCanRecall(tx, member, message); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(message).Updates(map[string]interface{}{
+			"recalled_flag": true,
+			"recalled_at":   now,
+			"recalled_by":   operatorID,
+			"status":        model.MessageStatusRecalled,
+		}).Error; err != nil {
+			return fmt.Errorf("撤回消息失败: %w", err)
+		}
+		if err := revokeMessageFileGrants(tx, message.ID); err != nil {
+			return err
+		}
+
+		// 管理员撤回他人消息时通知发送者
+		if message.SenderID != operatorID {
+			notices = append(notices, NotifyRequest{
+				UserID:   message.SenderID,
+				SenderID: operatorID,
+				Template: NoticeMessageRecalled,
+				Data:     map[string]interface{}{"MessageID": message.ID},
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishMessage(ctx, realtime.EventMessageRecall, message, operatorID)
+	deliverNotices(ctx, s.notifier, notices)
+	return message, nil
+}
+
+// checkCanRecall 校验撤回权限，群聊和团队会话的管理员不受发送者和时限限制
+func checkCanRecall(tx *gorm.DB, member *model.ConversationMember, message *model.Message) error {
+	moderator, err := canModerateConversation(tx, member)
+	if err != nil {
+		return err
+	}
+	if moderator {
+		return nil
+	}
+	if message.SenderID != member.UserID {
+		return ErrForbidden
+	}
+	if !message.CanRecallWithin(getRecallWindow(tx, message.ConversationID)) {
+		return ErrRecallExpired
+	}
+	return nil
+}
+
+// getRecallWindow 获取会话的撤回时限，优先使用会话级配置，其次全局配置
+func getRecallWindow(db *gorm.DB, conversationID uint) time.Duration {
+	defaultSeconds := int(model.DefaultRecallWindow / time.Second)
+	seconds := getConfigInt(db, configKeyRecallWindow, defaultSeconds)
+	seconds = getConfigInt(db, fmt.Sprintf(configKeyConversationRecallWindow, conversationID), seconds)
+	if seconds < 0 {
+		seconds = defaultSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// SetRecallWindow 设置会话的撤回时限(秒)，仅会话管理员可操作
+func (s *MessageService) SetRecallWindow(conversationID, operatorID uint, seconds int) error {
+	if seconds < 0 || seconds > maxRecallWindowSeconds {
+		return ErrInvalidParam
+	}
+	member, err := getConversationMember(s.db, conversationID, operatorID)
+	if err != nil {
+		return err
+	}
+	moderator, err := canModerateConversation(s.db, member)
+	if err != nil {
+		return err
+	}
+	if !moderator {
+		return ErrForbidden
+	}
+
+	key := fmt.Sprintf(configKeyConversationRecallWindow, conversationID)
+	value := strconv.Itoa(seconds)
+	var config model.SystemConfig
+	err = s.db.Where("`key` = ?", key).First(&config).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		minValue, maxValue := float64(0), float64(maxRecallWindowSeconds)
+		config = model.SystemConfig{
+			Key:          key,
+			Value:        value,
+			DefaultValue: strconv.Itoa(int(model.DefaultRecallWindow / time.Second)),
+			Name:         fmt.Sprintf("会话%d消息撤回时限", conversationID),
+			Description:  "会话成员撤回自己消息的时限(秒)，0表示不允许撤回",
+			Group:        string(model.ConfigTypeIM),
+			DataType:     "int",
+			Type:         model.ConfigTypeIM,
+			Status:       model.ConfigStatusActive,
+			CreatedBy:    operatorID,
+			UpdatedBy:    &operatorID,
+			MinValue:     &minValue,
+			MaxValue:     &maxValue,
+		}
+		if err := s.db.Create(&config).Error; err != nil {
+			return fmt.Errorf("保存撤回时限配置失败: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询撤回时限配置失败: %w", err)
+	}
+
+	if err := s.db.Model(&config).Updates(map[string]interface{}{
+		"value":      value,
+		"status":     model.ConfigStatusActive,
+		"updated_by": operatorID,
+	}).Error; err != nil {
+		return fmt.Errorf("更新撤回时限配置失败: %w", err)
+	}
+	return nil
+}
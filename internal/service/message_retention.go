@@ -0,0 +1,375 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 消息保留配置
+const (
+	configKeyRetentionMinDays    = "im.retention.min_days"
+	configKeyRetentionMaxDays    = "im.retention.max_days"
+	configKeyRetentionArchiveDir = "im.retention.archive_dir"
+	defaultRetentionArchiveDir   = "storage/archives/messages"
+	retentionBatchSize           = 500
+)
+
+// conversationExportHeader 会话导出文件头部信息
+type conversationExportHeader struct {
+	ID                   uint      `json:"id"`
+	Title                string    `json:"title"`
+	Type                 string    `json:"type"`
+	TeamID               *uint     `json:"team_id"`
+	CreatorID            uint      `json:"creator_id"`
+	MessageRetentionDays int       `json:"message_retention_days"`
+	CreatedAt            time.Time `json:"created_at"`
+	ExportedAt           time.Time `json:"exported_at"`
+}
+
+// messageExport 导出的消息，保留撤回和删除前的原始内容
+type messageExport struct {
+	ID            uint       `json:"id"`
+	SenderID      uint       `json:"sender_id"`
+	Type          string     `json:"type"`
+	Status        string     `json:"status"`
+	Content       string     `json:"content"`
+	Metadata      string     `json:"metadata,omitempty"`
+	Mentions      string     `json:"mentions,omitempty"`
+	ReplyToID     *uint      `json:"reply_to_id,omitempty"`
+	ForwardFromID *uint      `json:"forward_from_id,omitempty"`
+	FileID        *uint      `json:"file_id,omitempty"`
+	IsEdited      bool       `json:"is_edited"`
+	EditedAt      *time.Time `json:"edited_at,omitempty"`
+	IsRecalled    bool       `json:"is_recalled"`
+	RecalledAt    *time.Time `json:"recalled_at,omitempty"`
+	RecalledBy    *uint      `json:"recalled_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+}
+
+// effectiveRetentionDays 根据全局最小、最大保留天数计算会话实际保留天数，0表示永久保留
+func effectiveRetentionDays(days, minDays, maxDays int) int {
+	if days <= 0 {
+		if maxDays > 0 {
+			return maxDays
+		}
+		return 0
+	}
+	if minDays > 0 && days < minDays {
+		days = minDays
+	}
+	if maxDays > 0 && days > maxDays {
+		days = maxDays
+	}
+	return days
+}
+
+// retentionBounds 读取全局保留天数范围
+func retentionBounds(db *gorm.DB) (minDays, maxDays int) {
+	return getConfigInt(db, configKeyRetentionMinDays, 0), getConfigInt(db, configKeyRetentionMaxDays, 0)
+}
+
+// SetRetention 设置会话消息保留天数，仅会话管理员可操作且需在全局范围内
+func (s *ConversationService) SetRetention(conversationID, operatorID uint, days int) error {
+	member, err := getConversationMember(s.db, conversationID, operatorID)
+	if err != nil {
+		return err
+	}
+	moderator, err := canModerateConversation(s.db, member)
+	if err != nil {
+		return err
+	}
+	if !moderator {
+		return ErrForbidden
+	}
+
+	minDays, maxDays := retentionBounds(s.db)
+	if days < 0 || effectiveRetentionDays(days, minDays, maxDays) != days {
+		return fmt.Errorf("%w: 允许范围 %d-%d 天", ErrRetentionOutOfRange, minDays, maxDays)
+	}
+	if err := s.db.Model(&model.Conversation{}).Where("id = ?", conversationID).
+		Update("message_retention_days", days).Error; err != nil {
+		return fmt.Errorf("更新消息保留天数失败: %w", err)
+	}
+	return nil
+}
+
+// SetLegalHold 设置或解除会话的法律保留，需要系统配置权限
+func (s *ConversationService) SetLegalHold(conversationID, operatorID uint, hold bool, reason string) error {
+	if _, err := requireSystemPermission(s.db, operatorID, model.PermissionSystemConfig); err != nil {
+		return err
+	}
+	if _, err := getConversation(s.db, conversationID); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"legal_hold":        hold,
+		"legal_hold_reason": strings.TrimSpace(reason),
+		"legal_hold_by":     operatorID,
+		"legal_hold_at":     time.Now(),
+	}
+	if !hold {
+		updates["legal_hold_reason"] = ""
+	}
+	if err := s.db.Model(&model.Conversation{}).Where("id = ?", conversationID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("更新法律保留失败: %w", err)
+	}
+	return nil
+}
+
+// ExportConversation 会话管理员将会话的全部消息导出为JSON
+func (s *ConversationService) ExportConversation(w io.Writer, conversationID, operatorID uint) error {
+	member, err := getConversationMember(s.db, conversationID, operatorID)
+	if err != nil {
+		return err
+	}
+	moderator, err := canModerateConversation(s.db, member)
+	if err != nil {
+		return err
+	}
+	if !moderator {
+		return ErrForbidden
+	}
+	return s.exportConversation(w, conversationID)
+}
+
+// AuditExportConversation 拥有日志查看权限的用户导出任意会话，用于合规审查
+func (s *ConversationService) AuditExportConversation(w io.Writer, conversationID, operatorID uint) error {
+	if _, err := requireSystemPermission(s.db, operatorID, model.PermissionLogView); err != nil {
+		return err
+	}
+	return s.exportConversation(w, conversationID)
+}
+
+// exportConversation 导出会话的全部消息
+func (s *ConversationService) exportConversation(w io.Writer, conversationID uint) error {
+	conversation, err := getConversation(s.db, conversationID)
+	if err != nil {
+		return err
+	}
+	return writeConversationExport(w, s.db.Where("conversation_id = ?", conversationID), conversation)
+}
+
+// writeConversationExport 以流式方式写出会话导出JSON，messages为限定好范围的消息查询
+func writeConversationExport(w io.Writer, messages *gorm.DB, conversation *model.Conversation) error {
+	header, err := json.Marshal(conversationExportHeader{
+		ID:                   conversation.ID,
+		Title:                conversation.Title,
+		Type:                 string(conversation.Type),
+		TeamID:               conversation.TeamID,
+		CreatorID:            conversation.CreatorID,
+		MessageRetentionDays: conversation.MessageRetentionDays,
+		CreatedAt:            conversation.CreatedAt,
+		ExportedAt:           time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("序列化会话信息失败: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, `{"conversation":%s,"messages":[`, header); err != nil {
+		return fmt.Errorf("写入导出数据失败: %w", err)
+	}
+
+	var (
+		cursor uint
+		first  = true
+	)
+	for {
+		var batch []model.Message
+		if err := messages.Session(&gorm.Session{}).Where("id > ?", cursor).
+			Order("id").Limit(retentionBatchSize).Find(&batch).Error; err != nil {
+			return fmt.Errorf("查询导出消息失败: %w", err)
+		}
+		for i := range batch {
+			data, err := json.Marshal(newMessageExport(&batch[i]))
+			if err != nil {
+				return fmt.Errorf("序列化消息失败: %w", err)
+			}
+			if !first {
+				data = append([]byte{','}, data...)
+			}
+			first = false
+			if _, err := w.Write(data); err != nil {
+				return fmt.Errorf("写入导出数据失败: %w", err)
+			}
+		}
+		if len(batch) < retentionBatchSize {
+			break
+		}
+		cursor = batch[len(batch)-1].ID
+	}
+
+	if _, err := io.WriteString(w, "]}"); err != nil {
+		return fmt.Errorf("写入导出数据失败: %w", err)
+	}
+	return nil
+}
+
+// newMessageExport 转换为导出格式
+func newMessageExport(message *model.Message) messageExport {
+	export := messageExport{
+		ID:            message.ID,
+		SenderID:      message.SenderID,
+		Type:          string(message.Type),
+		Status:        string(message.Status),
+		Content:       message.Content,
+		Metadata:      message.Metadata,
+		Mentions:      message.Mentions,
+		ReplyToID:     message.ReplyToID,
+		ForwardFromID: message.ForwardFromID,
+		FileID:        message.FileID,
+		IsEdited:      message.IsEdited,
+		EditedAt:      message.EditedAt,
+		IsRecalled:    message.RecalledFlag,
+		RecalledAt:    message.RecalledAt,
+		RecalledBy:    message.RecalledBy,
+		CreatedAt:     message.CreatedAt,
+	}
+	if message.DeletedAt.Valid {
+		export.DeletedAt = &message.DeletedAt.Time
+	}
+	return export
+}
+
+// PurgeExpiredMessages 按会话保留天数清理过期消息，清理前先导出归档，法律保留的会话跳过
+func (s *ConversationService) PurgeExpiredMessages(ctx context.Context) error {
+	minDays, maxDays := retentionBounds(s.db)
+	db := s.db.Where("legal_hold = ?", false)
+	if maxDays == 0 {
+		// 未设置全局上限时只需处理设置了保留天数的会话
+		db = db.Where("message_retention_days > 0")
+	}
+
+	var conversations []model.Conversation
+	if err := db.Find(&conversations).Error; err != nil {
+		return fmt.Errorf("查询会话失败: %w", err)
+	}
+	for i := range conversations {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		days := effectiveRetentionDays(conversations[i].MessageRetentionDays, minDays, maxDays)
+		if days == 0 {
+			continue
+		}
+		cutoff := time.Now().AddDate(0, 0, -days)
+		purged, err := s.purgeConversation(&conversations[i], cutoff)
+		if err != nil {
+			log.Printf("清理会话 %d 过期消息失败: %v", conversations[i].ID, err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("会话 %d 已清理 %d 条超过 %d 天的消息", conversations[i].ID, purged, days)
+		}
+	}
+	return nil
+}
+
+// purgeConversation 导出并彻底删除会话中早于cutoff的消息
+func (s *ConversationService) purgeConversation(conversation *model.Conversation, cutoff time.Time) (int64, error) {
+	expired := s.db.Unscoped().Where("conversation_id = ? AND created_at < ?", conversation.ID, cutoff)
+	var count int64
+	if err := expired.Session(&gorm.Session{}).Model(&model.Message{}).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("统计过期消息失败: %w", err)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	if err := s.archiveConversation(conversation, expired); err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for {
+		var ids []uint
+		if err := expired.Session(&gorm.Session{}).Model(&model.Message{}).
+			Order("id").Limit(retentionBatchSize).Pluck("id", &ids).Error; err != nil {
+			return purged, fmt.Errorf("查询过期消息失败: %w", err)
+		}
+		if len(ids) == 0 {
+			return purged, nil
+		}
+		if err := s.db.Transaction(func(tx *gorm.DB) error {
+			return deleteMessages(tx, conversation.ID, ids)
+		}); err != nil {
+			return purged, err
+		}
+		purged += int64(len(ids))
+	}
+}
+
+// archiveConversation 将待清理的消息写入归档目录
+func (s *ConversationService) archiveConversation(conversation *model.Conversation, expired *gorm.DB) error {
+	dir := getConfigValue(s.db, configKeyRetentionArchiveDir, defaultRetentionArchiveDir)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("创建归档目录失败: %w", err)
+	}
+	name := fmt.Sprintf("conversation_%d_%s.json", conversation.ID, time.Now().Format("20060102150405"))
+	file, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("创建归档文件失败: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	err = writeConversationExport(writer, expired, conversation)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("归档会话 %d 失败: %w", conversation.ID, err)
+	}
+	return nil
+}
+
+// deleteMessages 彻底删除消息及其关联数据
+func deleteMessages(tx *gorm.DB, conversationID uint, ids []uint) error {
+	steps := []struct {
+		desc string
+		run  func() error
+	}{
+		{"清理消息提及", func() error { return tx.Where("message_id IN ?", ids).Delete(&model.MessageMention{}).Error }},
+		{"清理编辑历史", func() error { return tx.Where("message_id IN ?", ids).Delete(&model.MessageRevision{}).Error }},
+		{"清理已读回执", func() error { return tx.Where("message_id IN ?", ids).Delete(&model.MessageReadReceipt{}).Error }},
+		{"撤销文件权限", func() error {
+			return tx.Unscoped().Where("source_message_id IN ?", ids).Delete(&model.FilePermission{}).Error
+		}},
+		{"解除回复引用", func() error {
+			return tx.Unscoped().Model(&model.Message{}).Where("reply_to_id IN ?", ids).Update("reply_to_id", nil).Error
+		}},
+		{"解除转发引用", func() error {
+			return tx.Unscoped().Model(&model.Message{}).Where("forward_from_id IN ?", ids).Update("forward_from_id", nil).Error
+		}},
+		{"更新已读位置", func() error {
+			return tx.Unscoped().Model(&model.ConversationMember{}).
+				Where("conversation_id = ? AND last_read_message_id IN ?", conversationID, ids).
+				Update("last_read_message_id", nil).Error
+		}},
+		{"更新最后消息", func() error {
+			return tx.Model(&model.Conversation{}).Where("id = ? AND last_message_id IN ?", conversationID, ids).
+				Update("last_message_id", nil).Error
+		}},
+		{"删除消息", func() error { return tx.Unscoped().Where("id IN ?", ids).Delete(&model.Message{}).Error }},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			return fmt.Errorf("%s失败: %w", step.desc, err)
+		}
+	}
+	return nil
+}
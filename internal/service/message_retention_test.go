@@ -0,0 +1,25 @@
+package service
+
+import "testing"
+
+// TestEffectiveRetentionDays 测试全局范围对会话保留天数的约束
+func TestEffectiveRetentionDays(t *testing.T) {
+	cases := []struct {
+		name                   string
+		days, minDays, maxDays int
+		want                   int
+	}{
+		{"永久且无上限", 0, 0, 0, 0},
+		{"永久受上限约束", 0, 7, 365, 365},
+		{"低于下限", 3, 7, 365, 7},
+		{"超过上限", 400, 7, 365, 365},
+		{"范围内", 30, 7, 365, 30},
+		{"未设置范围", 30, 0, 0, 30},
+	}
+
+	for _, tc := range cases {
+		if got := effectiveRetentionDays(tc.days, tc.minDays, tc.maxDays); got != tc.want {
+			t.Errorf("%s: 期望 %d, 实际 %d", tc.name, tc.want, got)
+		}
+	}
+}
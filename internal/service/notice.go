@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// systemConversationTitle 系统通知会话标题
+const systemConversationTitle = "系统通知"
+
+// deliverNotices 在业务事务提交后通过通知中心投递通知，投递失败只记录日志，不影响已完成的操作
+// 通知中心按用户的分类偏好和语言选择渠道和模板，notifier为空时不投递
+func deliverNotices(ctx context.Context, notifier *NotificationService, requests []NotifyRequest) {
+	if notifier == nil {
+		return
+	}
+	for i := range requests {
+		if err := notifier.Notify(ctx, &requests[i]); err != nil {
+			log.Printf("向用户 %d 发送通知 %s 失败: %v", requests[i].UserID, requests[i].Template, err)
+		}
+	}
+}
+
+// teamManagerNotices 为团队所有管理员生成同一模板的通知请求
+func teamManagerNotices(tx *gorm.DB, teamID, senderID uint, template string, data map[string]interface{}) ([]NotifyRequest, error) {
+	managerIDs, err := listTeamManagerIDs(tx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	requests := make([]NotifyRequest, 0, len(managerIDs))
+	for _, managerID := range managerIDs {
+		requests = append(requests, NotifyRequest{UserID: managerID, SenderID: senderID, Template: template, Data: data})
+	}
+	return requests, nil
+}
+
+// noticeContent 通知消息内容
+type noticeContent struct {
+	Category model.NotificationCategory
+	Content  string
+	Metadata string
+}
+
+// createNotice 在用户的系统会话中创建一条指定分类的通知消息
+func createNotice(tx *gorm.DB, recipientID, senderID uint, notice *noticeContent) (*model.Message, error) {
+	conversation, err := getOrCreateSystemConversation(tx, recipientID)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &model.Message{
+		ConversationID: conversation.ID,
+		SenderID:       senderID,
+		Type:           model.MessageTypeNotice,
+		Category:       notice.Category,
+		Content:        notice.Content,
+		Metadata:       notice.Metadata,
+	}
+	if err := tx.Create(message).Error; err != nil {
+		return nil, fmt.Errorf("创建通知消息失败: %w", err)
+	}
+
+	now := time.Now()
+	if err := tx.Model(&model.Conversation{}).Where("id = ?", conversation.ID).
+		Updates(map[string]interface{}{"last_message_id": message.ID, "last_message_at": now}).Error; err != nil {
+		return nil, fmt.Errorf("更新会话最后消息失败: %w", err)
+	}
+	if err := tx.Model(&model.ConversationMember{}).
+		Where("conversation_id = ? AND user_id = ?", conversation.ID, recipientID).
+		UpdateColumn("unread_count", gorm.Expr("unread_count + 1")).Error; err != nil {
+		return nil, fmt.Errorf("更新未读数失败: %w", err)
+	}
+	return message, nil
+}
+
+// getOrCreateSystemConversation 获取或创建用户的系统通知会话
+func getOrCreateSystemConversation(tx *gorm.DB, userID uint) (*model.Conversation, error) {
+	var conversation model.Conversation
+	err := tx.Where("type = ? AND creator_id = ?", model.ConversationTypeSystem, userID).
+		First(&conversation).Error
+	if err == nil {
+		return &conversation, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("查询系统会话失败: %w", err)
+	}
+
+	conversation = model.Conversation{
+		Title:      systemConversationTitle,
+		Type:       model.ConversationTypeSystem,
+		CreatorID:  userID,
+		MaxMembers: 1,
+	}
+	if err := tx.Create(&conversation).Error; err != nil {
+		return nil, fmt.Errorf("创建系统会话失败: %w", err)
+	}
+	member := model.ConversationMember{ConversationID: conversation.ID, UserID: userID}
+	if err := tx.Create(&member).Error; err != nil {
+		return nil, fmt.Errorf("创建系统会话成员失败: %w", err)
+	}
+	return &conversation, nil
+}
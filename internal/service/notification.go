@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/realtime"
+
+	"gorm.io/gorm"
+)
+
+// NotificationService 通知中心服务
+type NotificationService struct {
+	db       *gorm.DB
+	channels []NotificationChannel
+}
+
+// NewNotificationService 创建通知中心服务，channels按顺序投递
+func NewNotificationService(db *gorm.DB, channels ...NotificationChannel) *NotificationService {
+	return &NotificationService{db: db, channels: channels}
+}
+
+// NotifyRequest 发送通知请求，SenderID为0时以接收者本人作为发送者
+type NotifyRequest struct {
+	UserID   uint
+	SenderID uint
+	Template string
+	Data     map[string]interface{}
+}
+
+// NotificationItem 通知列表项
+type NotificationItem struct {
+	ID        uint                       `json:"id"`
+	Category  model.NotificationCategory `json:"category"`
+	Title     string                     `json:"title"`
+	Content   string                     `json:"content"`
+	Read      bool                       `json:"read"`
+	CreatedAt time.Time                  `json:"created_at"`
+}
+
+// NotificationBadge 未读通知数，按分类统计
+type NotificationBadge struct {
+	Total      int64            `json:"total"`
+	Categories map[string]int64 `json:"categories"`
+}
+
+// UpdatePreferenceRequest 更新通知偏好请求，未传的字段保持不变
+type UpdatePreferenceRequest struct {
+	InApp      *bool   `json:"in_app"`
+	Email      *bool   `json:"email"`
+	Webhook    *bool   `json:"webhook"`
+	WebhookURL *string `json:"webhook_url" binding:"omitempty,max=500"`
+}
+
+// Notify 渲染模板并按用户偏好通过各渠道投递，站内通知失败时返回错误，其他渠道失败只记录日志
+func (s *NotificationService) Notify(ctx context.Context, req *NotifyRequest) error {
+	user, err := getUser(s.db, req.UserID)
+	if err != nil {
+		return err
+	}
+	rendered, err := renderNotice(req.Template, user.Language, req.Data)
+	if err != nil {
+		return err
+	}
+	preference, err := getNotificationPreference(s.db, user.ID, rendered.Category)
+	if err != nil {
+		return err
+	}
+
+	senderID := req.SenderID
+	if senderID == 0 {
+		senderID = user.ID
+	}
+	notification := &Notification{
+		UserID:     user.ID,
+		SenderID:   senderID,
+		Email:      user.Email,
+		WebhookURL: preference.WebhookURL,
+		Category:   rendered.Category,
+		Template:   req.Template,
+		Locale:     rendered.Locale,
+		Title:      rendered.Title,
+		Body:       rendered.Body,
+		CreatedAt:  time.Now(),
+	}
+
+	for _, channel := range s.channels {
+		if !preference.Allows(channel.Name()) {
+			continue
+		}
+		if err := channel.Deliver(ctx, notification); err != nil {
+			if channel.Name() == model.NotificationChannelInApp {
+				return err
+			}
+			log.Printf("通过 %s 向用户 %d 投递通知失败: %v", channel.Name(), user.ID, err)
+		}
+	}
+	return nil
+}
+
+// ListNotifications 分页获取用户的通知，category为空时返回全部分类
+func (s *NotificationService) ListNotifications(userID uint, category string, page, pageSize int) ([]NotificationItem, int64, error) {
+	conversation, member, err := findSystemConversation(s.db, userID)
+	if err != nil || conversation == nil {
+		return []NotificationItem{}, 0, err
+	}
+
+	db := s.db.Model(&model.Message{}).Where("conversation_id = ?", conversation.ID)
+	if category != "" {
+		db = db.Where("category = ?", category)
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计通知失败: %w", err)
+	}
+
+	var messages []model.Message
+	if err := db.Order("id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&messages).Error; err != nil {
+		return nil, 0, fmt.Errorf("查询通知失败: %w", err)
+	}
+
+	items := make([]NotificationItem, 0, len(messages))
+	for i := range messages {
+		items = append(items, NotificationItem{
+			ID:        messages[i].ID,
+			Category:  messages[i].Category,
+			Title:     noticeTitle(&messages[i]),
+			Content:   messages[i].Content,
+			Read:      member.LastReadMessageID != nil && *member.LastReadMessageID >= messages[i].ID,
+			CreatedAt: messages[i].CreatedAt,
+		})
+	}
+	return items, total, nil
+}
+
+// GetUnreadBadge 获取未读通知数
+func (s *NotificationService) GetUnreadBadge(userID uint) (*NotificationBadge, error) {
+	badge := &NotificationBadge{Categories: map[string]int64{}}
+	conversation, member, err := findSystemConversation(s.db, userID)
+	if err != nil || conversation == nil {
+		return badge, err
+	}
+
+	var lastReadID uint
+	if member.LastReadMessageID != nil {
+		lastReadID = *member.LastReadMessageID
+	}
+	var rows []struct {
+		Category string
+		Count    int64
+	}
+	if err := s.db.Model(&model.Message{}).
+		Select("category, COUNT(*) AS count").
+		Where("conversation_id = ? AND id > ?", conversation.ID, lastReadID).
+		Group("category").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("统计未读通知失败: %w", err)
+	}
+
+	for _, row := range rows {
+		category := row.Category
+		if category == "" {
+			category = string(model.NotificationCategorySystem)
+		}
+		badge.Categories[category] += row.Count
+		badge.Total += row.Count
+	}
+	return badge, nil
+}
+
+// MarkAllRead 将全部通知标记为已读
+func (s *NotificationService) MarkAllRead(userID uint) error {
+	conversation, _, err := findSystemConversation(s.db, userID)
+	if err != nil || conversation == nil || conversation.LastMessageID == nil {
+		return err
+	}
+	return realtime.MarkRead(s.db, conversation.ID, userID, *conversation.LastMessageID)
+}
+
+// ListPreferences 获取用户全部分类的通知偏好，未设置的分类返回默认值
+func (s *NotificationService) ListPreferences(userID uint) ([]model.NotificationPreference, error) {
+	var saved []model.NotificationPreference
+	if err := s.db.Where("user_id = ?", userID).Find(&saved).Error; err != nil {
+		return nil, fmt.Errorf("查询通知偏好失败: %w", err)
+	}
+	byCategory := make(map[model.NotificationCategory]model.NotificationPreference, len(saved))
+	for _, preference := range saved {
+		byCategory[preference.Category] = preference
+	}
+
+	preferences := make([]model.NotificationPreference, 0, len(model.NotificationCategories))
+	for _, category := range model.NotificationCategories {
+		preference, ok := byCategory[category]
+		if !ok {
+			preference = model.DefaultNotificationPreference(userID, category)
+		}
+		preferences = append(preferences, preference)
+	}
+	return preferences, nil
+}
+
+// UpdatePreference 更新用户某一分类的通知偏好
+func (s *NotificationService) UpdatePreference(userID uint, category string, req *UpdatePreferenceRequest) (*model.NotificationPreference, error) {
+	parsed, ok := model.ParseNotificationCategory(category)
+	if !ok {
+		return nil, ErrInvalidParam
+	}
+	preference, err := getNotificationPreference(s.db, userID, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	applyPreference(preference, req)
+	if preference.WebhookURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+		valid := isWebhookURL(ctx, preference.WebhookURL)
+		cancel()
+		if !valid {
+			return nil, ErrInvalidParam
+		}
+	}
+	if preference.Webhook && preference.WebhookURL == "" {
+		return nil, ErrInvalidParam
+	}
+
+	// 显式指定列，避免布尔字段的零值被数据库默认值覆盖
+	columns := []string{"user_id", "category", "in_app", "email", "webhook", "webhook_url"}
+	if preference.ID == 0 {
+		err = s.db.Select(columns).Create(preference).Error
+	} else {
+		err = s.db.Model(preference).Select(columns[2:]).Updates(preference).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("保存通知偏好失败: %w", err)
+	}
+	return preference, nil
+}
+
+// applyPreference 将请求中的字段写入偏好
+func applyPreference(preference *model.NotificationPreference, req *UpdatePreferenceRequest) {
+	if req.InApp != nil {
+		preference.InApp = *req.InApp
+	}
+	if req.Email != nil {
+		preference.Email = *req.Email
+	}
+	if req.Webhook != nil {
+		preference.Webhook = *req.Webhook
+	}
+	if req.WebhookURL != nil {
+		preference.WebhookURL = strings.TrimSpace(*req.WebhookURL)
+	}
+}
+
+// isWebhookURL 校验Webhook地址为http或https绝对地址，且主机解析出的地址都是公网地址
+func isWebhookURL(ctx context.Context, raw string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return false
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return false
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return false
+		}
+	}
+	return true
+}
+
+// getNotificationPreference 获取用户某一分类的通知偏好，未设置时返回默认值
+func getNotificationPreference(tx *gorm.DB, userID uint, category model.NotificationCategory) (*model.NotificationPreference, error) {
+	var preference model.NotificationPreference
+	err := tx.Where("user_id = ? AND category = ?", userID, category).First(&preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		preference = model.DefaultNotificationPreference(userID, category)
+		return &preference, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询通知偏好失败: %w", err)
+	}
+	return &preference, nil
+}
+
+// findSystemConversation 查找用户的系统通知会话及成员记录，尚未创建时返回nil
+func findSystemConversation(tx *gorm.DB, userID uint) (*model.Conversation, *model.ConversationMember, error) {
+	var conversation model.Conversation
+	err := tx.Where("type = ? AND creator_id = ?", model.ConversationTypeSystem, userID).First(&conversation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询系统会话失败: %w", err)
+	}
+	member, err := getConversationMember(tx, conversation.ID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &conversation, member, nil
+}
+
+// noticeTitle 从通知元数据中读取标题
+func noticeTitle(message *model.Message) string {
+	if message.Metadata == "" {
+		return ""
+	}
+	var metadata struct {
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal([]byte(message.Metadata), &metadata); err != nil {
+		return ""
+	}
+	return metadata.Title
+}
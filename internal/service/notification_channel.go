@@ -0,0 +1,193 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/realtime"
+
+	"gorm.io/gorm"
+)
+
+// webhookTimeout Webhook请求超时时间
+const webhookTimeout = 5 * time.Second
+
+// errWebhookAddress Webhook地址指向本机或内网
+var errWebhookAddress = errors.New("Webhook地址不能指向本机或内网")
+
+// carrierGradeNAT 运营商级NAT地址段，同样不能从公网访问
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Notification 待投递的通知
+type Notification struct {
+	UserID     uint                       `json:"user_id"`
+	SenderID   uint                       `json:"-"`
+	Email      string                     `json:"-"`
+	WebhookURL string                     `json:"-"`
+	Category   model.NotificationCategory `json:"category"`
+	Template   string                     `json:"template"`
+	Locale     string                     `json:"locale"`
+	Title      string                     `json:"title"`
+	Body       string                     `json:"body"`
+	CreatedAt  time.Time                  `json:"created_at"`
+}
+
+// NotificationChannel 通知投递渠道，Name需与偏好设置中的渠道名一致
+type NotificationChannel interface {
+	Name() string
+	Deliver(ctx context.Context, notification *Notification) error
+}
+
+// InAppChannel 站内通知渠道，写入用户的系统会话并实时推送
+type InAppChannel struct {
+	db        *gorm.DB
+	publisher EventPublisher
+}
+
+// NewInAppChannel 创建站内通知渠道
+func NewInAppChannel(db *gorm.DB, publisher EventPublisher) *InAppChannel {
+	return &InAppChannel{db: db, publisher: publisher}
+}
+
+// Name 渠道名称
+func (c *InAppChannel) Name() string {
+	return model.NotificationChannelInApp
+}
+
+// Deliver 写入系统会话并推送新消息事件
+func (c *InAppChannel) Deliver(ctx context.Context, notification *Notification) error {
+	metadata, err := json.Marshal(map[string]string{
+		"title":    notification.Title,
+		"template": notification.Template,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化通知元数据失败: %w", err)
+	}
+
+	var message *model.Message
+	err = c.db.Transaction(func(tx *gorm.DB) error {
+		message, err = createNotice(tx, notification.UserID, notification.SenderID, &noticeContent{
+			Category: notification.Category,
+			Content:  notification.Body,
+			Metadata: string(metadata),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	publishMessageEvent(ctx, c.publisher, realtime.EventMessageNew, message, notification.SenderID)
+	return nil
+}
+
+// EmailChannel 邮件通知渠道，通知写入发件箱后由后台任务投递
+type EmailChannel struct {
+	db *gorm.DB
+}
+
+// NewEmailChannel 创建邮件通知渠道
+func NewEmailChannel(db *gorm.DB) *EmailChannel {
+	return &EmailChannel{db: db}
+}
+
+// Name 渠道名称
+func (c *EmailChannel) Name() string {
+	return model.NotificationChannelEmail
+}
+
+// Deliver 将通知写入发件箱
+func (c *EmailChannel) Deliver(_ context.Context, notification *Notification) error {
+	if notification.Email == "" {
+		return nil
+	}
+	userID := notification.UserID
+	_, err := saveOutbox(c.db, notification.Email, &userID, notification.Template, &renderedEmail{
+		Subject: notification.Title,
+		Text:    notification.Body,
+	})
+	return err
+}
+
+// WebhookChannel Webhook通知渠道，以JSON格式POST到用户配置的地址
+type WebhookChannel struct {
+	client *http.Client
+}
+
+// NewWebhookChannel 创建Webhook通知渠道，client为空时使用只能访问公网地址的默认客户端
+func NewWebhookChannel(client *http.Client) *WebhookChannel {
+	if client == nil {
+		client = newWebhookClient()
+	}
+	return &WebhookChannel{client: client}
+}
+
+// newWebhookClient 创建Webhook客户端
+// 保存地址时的解析结果可能在投递时已经改变(DNS重绑定)，因此在建立连接时再次校验实际连接的IP；
+// 不跟随重定向，也不使用环境变量中的代理，避免绕过校验
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return errWebhookAddress
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: webhookTimeout},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// isPublicIP 检查IP是否为公网地址
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified() && !carrierGradeNAT.Contains(ip)
+}
+
+// Name 渠道名称
+func (c *WebhookChannel) Name() string {
+	return model.NotificationChannelWebhook
+}
+
+// Deliver 推送通知到Webhook地址，非2xx响应(包括重定向)视为失败
+func (c *WebhookChannel) Deliver(ctx context.Context, notification *Notification) error {
+	if notification.WebhookURL == "" {
+		return nil
+	}
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("序列化Webhook通知失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建Webhook请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("推送Webhook失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("推送Webhook失败: 状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"ycg_cloud/internal/model"
+)
+
+// 通知模板键
+const (
+	NoticeSystemAnnouncement = "system.announcement"
+	NoticeStorageQuota       = "storage.quota_warning"
+	NoticeShareAccessed      = "share.accessed"
+	NoticeRecycleExpiring    = "recycle.expiring"
+	NoticeTeamJoined         = "team.joined"
+	NoticeTeamJoinRejected   = "team.join_rejected"
+	NoticeTeamJoinRequested  = "team.join_requested"
+	NoticeTeamStorageAlert   = "team.storage_alert"
+	NoticeMessageRecalled    = "im.message_recalled"
+	NoticeSecurityLogin      = "security.login"
+	NoticePermissionExpiring = "permission.expiring"
+	NoticeGrantExpiring      = "permission.grant_expiring"
+)
+
+// localizedText 某一语言下的通知标题和正文模板
+type localizedText struct {
+	Title string
+	Body  string
+}
+
+// noticeTemplate 通知模板定义
+type noticeTemplate struct {
+	Category model.NotificationCategory
+	Texts    map[string]localizedText
+}
+
+// renderedNotice 渲染后的通知
+type renderedNotice struct {
+	Category model.NotificationCategory
+	Locale   string
+	Title    string
+	Body     string
+}
+
+// noticeTemplates 内置的通知模板，缺少用户语言时回退到默认语言
+var noticeTemplates = map[string]noticeTemplate{
+	NoticeSystemAnnouncement: {
+		Category: model.NotificationCategorySystem,
+		Texts: map[string]localizedText{
+			model.LocaleZhCN: {Title: "{{.Title}}", Body: "{{.Content}}"},
+			model.LocaleEnUS: {Title: "{{.Title}}", Body: "{{.Content}}"},
+		},
+	},
+	NoticeStorageQuota: {
+		Category: model.NotificationCategoryStorage,
+		Texts: map[string]localizedText{
+			model.LocaleZhCN: {
+				Title: "存储空间即将用尽",
+				Body:  "你的存储空间已使用 {{.Percent}}%，请及时清理文件或申请扩容。",
+			},
+			model.LocaleEnUS: {
+				Title: "Storage almost full",
+				Body:  "You have used {{.Percent}}% of your storage. Please free up space or request more quota.",
+			},
+		},
+	},
+	NoticeShareAccessed: {
+		Category: model.NotificationCategoryShare,
+		Texts: map[string]localizedText{
+			model.LocaleZhCN: {
+				Title: "分享被访问",
+				Body:  "{{.Visitor}} 访问了你分享的「{{.FileName}}」。",
+			},
+			model.LocaleEnUS: {
+				Title: "Share accessed",
+				Body:  "{{.Visitor}} accessed your shared file \"{{.FileName}}\".",
+			},
+		},
+	},
+	NoticeRecycleExpiring: {
+		Category: model.NotificationCategoryRecycle,
+		Texts: map[string]localizedText{
+			model.LocaleZhCN: {
+				Title: "回收站文件即将清除",
+				Body:  "回收站中的「{{.FileName}}」将在 {{.Days}} 天后被永久删除。",
+			},
+			model.LocaleEnUS: {
+				Title: "Recycle bin item expiring",
+				Body:  "\"{{.FileName}}\" will be permanently deleted from the recycle bin in {{.Days}} day(s).",
+			},
+		},
+	},
+	NoticeTeamJoined: {
+		Category: model.NotificationCategoryTeam,
+		Texts: map[string]localizedText{
+			model.LocaleZhCN: {
+				Title: "已加入团队",
+				Body:  "你已加入团队「{{.TeamName}}」。",
+			},
+			model.LocaleEnUS: {
+				Title: "Joined team",
+				Body:  "You have joined the team \"{{.TeamName}}\".",
+			},
+		},
+	},
+	NoticeTeamJoinRejected: {
+		Category: model.NotificationCategoryTeam,
+		Texts: map[string]localizedText{
+			model.LocaleZhCN: {
+				Title: "入队申请未通过",
+				Body:  "你加入团队「{{.TeamName}}」的申请已被拒绝。",
+			},
+			model.LocaleEnUS: {
+				Title: "Join request declined",
+				Body:  "Your request to join the team \"{{.TeamName}}\" was declined.",
+			},
+		},
+	},
+	NoticeTeamJoinRequested: {
+		Category: model.NotificationCategoryTeam,
+		Texts: map[string]localizedText{
+			model.LocaleZhCN: {
+				Title: "新的入队申请",
+				Body:  "用户 {{.Applicant}} 申请加入团队「{{.TeamName}}」，请及时审批。",
+			},
+			model.LocaleEnUS: {
+				Title: "New join request",
+				Body:  "{{.Applicant}} asked to join the team \"{{.TeamName}}\". Please review the request.",
+			},
+		},
+	},
+	NoticeTeamStorageAlert: {
+		Category: model.NotificationCategoryStorage,
+		Texts: map[string]localizedText{
+			model.LocaleZhCN: {
+				Title: "团队存储空间告警",
+				Body:  "团队「{{.TeamName}}」存储用量已达到 {{.Percent}}%，超过 {{.Threshold}}% 告警线。",
+			},
+			model.LocaleEnUS: {
+				Title: "Team storage alert",
+				Body:  "The team \"{{.TeamName}}\" has used {{.Percent}}% of its storage, above the {{.Threshold}}% threshold.",
+			},
+		},
+	},
+	NoticeMessageRecalled: {
+		Category: model.NotificationCategorySystem,
+		Texts: map[string]localizedText{
+			model.LocaleZhCN: {
+				Title: "消息已被撤回",
+				Body:  "你在会话中发送的一条消息已被管理员撤回(消息ID: {{.MessageID}})。",
+			},
+			model.LocaleEnUS: {
+				Title: "Message recalled",
+				Body:  "A message you sent was recalled by an admin (message ID: {{.MessageID}}).",
+			},
+		},
+	},
+	NoticeSecurityLogin: {
+		Category: model.NotificationCategorySecurity,
+		Texts: map[string]localizedText{
+			model.LocaleZhCN: {
+				Title: "新的登录",
+				Body:  "你的账号于 {{.Time}} 在 {{.IP}} 登录，如非本人操作请立即修改密码。",
+			},
+			model.LocaleEnUS: {
+				Title: "New sign-in",
+				Body:  "Your account signed in from {{.IP}} at {{.Time}}. If this wasn't you, change your password now.",
+			},
+		},
+	},
+	NoticePermissionExpiring: {
+		Category: model.NotificationCategorySecurity,
+		Texts: map[string]localizedText{
+			model.LocaleZhCN: {
+				Title: "权限即将到期",
+				Body:  "你的权限「{{.Grant}}」将在 {{.Days}} 天后({{.ExpiresAt}})到期，如需继续使用请联系授权人续期。",
+			},
+			model.LocaleEnUS: {
+				Title: "Access expiring soon",
+				Body:  "Your access \"{{.Grant}}\" expires in {{.Days}} day(s) ({{.ExpiresAt}}). Contact the grantor if you still need it.",
+			},
+		},
+	},
+	NoticeGrantExpiring: {
+		Category: model.NotificationCategorySecurity,
+		Texts: map[string]localizedText{
+			model.LocaleZhCN: {
+				Title: "你授予的权限即将到期",
+				Body:  "你授予 {{.Grantee}} 的权限「{{.Grant}}」将在 {{.Days}} 天后({{.ExpiresAt}})到期。",
+			},
+			model.LocaleEnUS: {
+				Title: "A grant you made is expiring",
+				Body:  "The access \"{{.Grant}}\" you granted to {{.Grantee}} expires in {{.Days}} day(s) ({{.ExpiresAt}}).",
+			},
+		},
+	},
+}
+
+// renderNotice 按用户语言渲染通知模板
+func renderNotice(key, locale string, data map[string]interface{}) (*renderedNotice, error) {
+	tmpl, ok := noticeTemplates[key]
+	if !ok {
+		return nil, ErrNotificationTemplate
+	}
+	text, ok := tmpl.Texts[locale]
+	if !ok {
+		locale = model.DefaultLocale
+		text = tmpl.Texts[locale]
+	}
+
+	title, err := executeText(key+".title", text.Title, data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := executeText(key+".body", text.Body, data)
+	if err != nil {
+		return nil, err
+	}
+	return &renderedNotice{Category: tmpl.Category, Locale: locale, Title: title, Body: body}, nil
+}
+
+// executeText 执行单个文本模板，缺少变量时报错而不是输出空值
+func executeText(name, text string, data map[string]interface{}) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("解析通知模板失败: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染通知模板 %s 失败: %w", name, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ycg_cloud/internal/model"
+)
+
+// TestRenderNotice 测试通知模板的本地化渲染
+func TestRenderNotice(t *testing.T) {
+	data := map[string]interface{}{"Percent": 90}
+
+	zh, err := renderNotice(NoticeStorageQuota, model.LocaleZhCN, data)
+	if err != nil {
+		t.Fatalf("渲染中文模板失败: %v", err)
+	}
+	if zh.Category != model.NotificationCategoryStorage || zh.Body != "你的存储空间已使用 90%，请及时清理文件或申请扩容。" {
+		t.Errorf("中文模板渲染结果不正确: %+v", zh)
+	}
+
+	en, err := renderNotice(NoticeStorageQuota, model.LocaleEnUS, data)
+	if err != nil {
+		t.Fatalf("渲染英文模板失败: %v", err)
+	}
+	if en.Title != "Storage almost full" || en.Locale != model.LocaleEnUS {
+		t.Errorf("英文模板渲染结果不正确: %+v", en)
+	}
+
+	fallback, err := renderNotice(NoticeStorageQuota, "fr-FR", data)
+	if err != nil || fallback.Locale != model.DefaultLocale || fallback.Title != zh.Title {
+		t.Errorf("未知语言应回退到默认语言: %+v, %v", fallback, err)
+	}
+
+	if _, err := renderNotice(NoticeStorageQuota, model.LocaleZhCN, nil); err == nil {
+		t.Error("缺少模板变量时应返回错误")
+	}
+	if _, err := renderNotice("unknown", model.LocaleZhCN, data); err != ErrNotificationTemplate {
+		t.Errorf("期望 ErrNotificationTemplate, 实际 %v", err)
+	}
+}
+
+// TestNoticeTemplatesLocalized 测试每个通知模板都提供全部支持语言的文本
+func TestNoticeTemplatesLocalized(t *testing.T) {
+	for key, tmpl := range noticeTemplates {
+		for _, locale := range []string{model.LocaleZhCN, model.LocaleEnUS} {
+			text, ok := tmpl.Texts[locale]
+			if !ok || text.Title == "" || text.Body == "" {
+				t.Errorf("通知模板 %s 缺少 %s 文本", key, locale)
+			}
+		}
+	}
+
+	rendered, err := renderNotice(NoticeTeamStorageAlert, model.LocaleEnUS,
+		map[string]interface{}{"TeamName": "design", "Percent": "91.5", "Threshold": 90})
+	if err != nil {
+		t.Fatalf("渲染团队存储告警失败: %v", err)
+	}
+	if rendered.Category != model.NotificationCategoryStorage ||
+		rendered.Body != `The team "design" has used 91.5% of its storage, above the 90% threshold.` {
+		t.Errorf("团队存储告警渲染结果不正确: %+v", rendered)
+	}
+}
+
+// TestWebhookChannel 测试Webhook渠道的投递和失败判定
+func TestWebhookChannel(t *testing.T) {
+	var received Notification
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("解析Webhook请求失败: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	channel := NewWebhookChannel(server.Client())
+	notification := &Notification{
+		UserID:     1,
+		WebhookURL: server.URL,
+		Category:   model.NotificationCategoryShare,
+		Title:      "分享被访问",
+		Body:       "bob 访问了你分享的「报告.pdf」。",
+	}
+	if err := channel.Deliver(context.Background(), notification); err != nil {
+		t.Fatalf("投递Webhook失败: %v", err)
+	}
+	if received.UserID != 1 || received.Title != notification.Title || received.Category != notification.Category {
+		t.Errorf("Webhook内容不正确: %+v", received)
+	}
+
+	status = http.StatusInternalServerError
+	if err := channel.Deliver(context.Background(), notification); err == nil {
+		t.Error("非2xx响应应视为投递失败")
+	}
+}
+
+// TestWebhookAddressRestricted 测试Webhook不能指向本机或内网地址
+func TestWebhookAddressRestricted(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"http://93.184.216.34/hook", true},
+		{"ftp://93.184.216.34/hook", false},
+		{"http://127.0.0.1:8080/hook", false},
+		{"http://localhost/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://10.0.0.8/hook", false},
+		{"http://100.64.1.1/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://0.0.0.0/hook", false},
+	}
+	for _, tt := range tests {
+		if got := isWebhookURL(context.Background(), tt.url); got != tt.want {
+			t.Errorf("isWebhookURL(%q) = %v, 期望 %v", tt.url, got, tt.want)
+		}
+	}
+
+	// 默认客户端在连接时拒绝本机地址，并且不跟随重定向
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	err := NewWebhookChannel(nil).Deliver(context.Background(), &Notification{UserID: 1, WebhookURL: server.URL})
+	if err == nil || !errors.Is(err, errWebhookAddress) {
+		t.Errorf("投递到本机地址 = %v, 期望 errWebhookAddress", err)
+	}
+
+	redirect := httptest.NewServer(http.RedirectHandler(server.URL, http.StatusFound))
+	defer redirect.Close()
+	channel := NewWebhookChannel(redirect.Client())
+	channel.client.CheckRedirect = newWebhookClient().CheckRedirect
+	if err := channel.Deliver(context.Background(), &Notification{UserID: 1, WebhookURL: redirect.URL}); err == nil {
+		t.Error("重定向响应应视为投递失败")
+	}
+}
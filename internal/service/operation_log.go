@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 操作日志管道默认值
+const (
+	defaultOperationLogBuffer        = 4096
+	defaultOperationLogBatch         = 100
+	defaultOperationLogFlushInterval = 2 * time.Second
+	operationLogWriteTimeout         = 10 * time.Second
+	operationLogDropReportEvery      = 1000
+)
+
+// OperationLogStats 操作日志管道的运行指标
+type OperationLogStats struct {
+	Queued        int    `json:"queued"`         // 缓冲区中等待写入的条目数
+	Capacity      int    `json:"capacity"`       // 缓冲区容量
+	HighWatermark int    `json:"high_watermark"` // 缓冲区占用的历史最高值
+	Enqueued      uint64 `json:"enqueued"`       // 成功进入缓冲区的条目数
+	Dropped       uint64 `json:"dropped"`        // 缓冲区已满被丢弃的条目数
+	Written       uint64 `json:"written"`        // 成功写入数据库的条目数
+	Failed        uint64 `json:"failed"`         // 写入数据库失败的条目数
+	Batches       uint64 `json:"batches"`        // 已执行的批量写入次数
+}
+
+// OperationLogger 操作日志异步写入管道
+// 请求处理只向有界缓冲区投递，缓冲区已满时直接丢弃并计数，保证记录日志不会阻塞请求
+type OperationLogger struct {
+	db            *gorm.DB
+	entries       chan *model.OperationLog
+	batchSize     int
+	flushInterval time.Duration
+
+	highWatermark atomic.Int64
+	enqueued      atomic.Uint64
+	dropped       atomic.Uint64
+	written       atomic.Uint64
+	failed        atomic.Uint64
+	batches       atomic.Uint64
+}
+
+// NewOperationLogger 创建操作日志管道，参数不大于0时使用默认值
+func NewOperationLogger(db *gorm.DB, bufferSize, batchSize int, flushInterval time.Duration) *OperationLogger {
+	if bufferSize <= 0 {
+		bufferSize = defaultOperationLogBuffer
+	}
+	if batchSize <= 0 {
+		batchSize = defaultOperationLogBatch
+	}
+	if flushInterval <= 0 {
+		flushInterval = defaultOperationLogFlushInterval
+	}
+	return &OperationLogger{
+		db:            db,
+		entries:       make(chan *model.OperationLog, bufferSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+	}
+}
+
+// Record 投递一条操作日志，缓冲区已满时丢弃并返回false
+func (l *OperationLogger) Record(entry *model.OperationLog) bool {
+	select {
+	case l.entries <- entry:
+		l.enqueued.Add(1)
+		if queued := int64(len(l.entries)); queued > l.highWatermark.Load() {
+			l.highWatermark.Store(queued)
+		}
+		return true
+	default:
+		if dropped := l.dropped.Add(1); dropped%operationLogDropReportEvery == 1 {
+			log.Printf("操作日志缓冲区已满，累计丢弃 %d 条", dropped)
+		}
+		return false
+	}
+}
+
+// Run 批量写入操作日志，直到ctx取消；取消后写出缓冲区中剩余的条目再返回
+func (l *OperationLogger) Run(ctx context.Context) {
+	ticker := time.NewTicker(l.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*model.OperationLog, 0, l.batchSize)
+	for {
+		select {
+		case entry := <-l.entries:
+			batch = append(batch, entry)
+			if len(batch) >= l.batchSize {
+				batch = l.flush(batch)
+			}
+		case <-ticker.C:
+			batch = l.flush(batch)
+		case <-ctx.Done():
+			l.drain(batch)
+			return
+		}
+	}
+}
+
+// Stats 获取管道的运行指标
+func (l *OperationLogger) Stats() OperationLogStats {
+	return OperationLogStats{
+		Queued:        len(l.entries),
+		Capacity:      cap(l.entries),
+		HighWatermark: int(l.highWatermark.Load()),
+		Enqueued:      l.enqueued.Load(),
+		Dropped:       l.dropped.Load(),
+		Written:       l.written.Load(),
+		Failed:        l.failed.Load(),
+		Batches:       l.batches.Load(),
+	}
+}
+
+// drain 写出缓冲区中剩余的条目
+func (l *OperationLogger) drain(batch []*model.OperationLog) {
+	for {
+		select {
+		case entry := <-l.entries:
+			batch = append(batch, entry)
+			if len(batch) >= l.batchSize {
+				batch = l.flush(batch)
+			}
+		default:
+			l.flush(batch)
+			return
+		}
+	}
+}
+
+// flush 批量写入一批日志，返回清空后的切片供复用；写入失败只计数和记录，不重试
+func (l *OperationLogger) flush(batch []*model.OperationLog) []*model.OperationLog {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), operationLogWriteTimeout)
+	defer cancel()
+	l.batches.Add(1)
+	if err := l.db.WithContext(ctx).CreateInBatches(batch, len(batch)).Error; err != nil {
+		l.failed.Add(uint64(len(batch)))
+		log.Printf("批量写入 %d 条操作日志失败: %v", len(batch), err)
+	} else {
+		l.written.Add(uint64(len(batch)))
+	}
+	clear(batch)
+	return batch[:0]
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"testing"
+
+	"ycg_cloud/internal/model"
+)
+
+// TestOperationLoggerBackPressure 测试缓冲区已满时丢弃日志而不是阻塞
+func TestOperationLoggerBackPressure(t *testing.T) {
+	logger := NewOperationLogger(nil, 2, 0, 0)
+	for i := 0; i < 5; i++ {
+		accepted := logger.Record(&model.OperationLog{Title: "test"})
+		if accepted != (i < 2) {
+			t.Errorf("第 %d 条日志 accepted = %v", i, accepted)
+		}
+	}
+
+	stats := logger.Stats()
+	if stats.Queued != 2 || stats.Capacity != 2 || stats.HighWatermark != 2 {
+		t.Errorf("缓冲区指标不正确: %+v", stats)
+	}
+	if stats.Enqueued != 2 || stats.Dropped != 3 {
+		t.Errorf("投递计数不正确: %+v", stats)
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 密码重置配置
+const (
+	configKeyResetTTL         = "security.password_reset.ttl_minutes"
+	configKeyResetWindow      = "security.password_reset.window_minutes"
+	configKeyResetMaxPerEmail = "security.password_reset.max_per_email"
+	configKeyResetMaxPerIP    = "security.password_reset.max_per_ip"
+	defaultResetTTLMinutes    = 30
+	defaultResetWindowMinutes = 60
+	defaultResetMaxPerEmail   = 3
+	defaultResetMaxPerIP      = 10
+)
+
+// ResetPasswordRequest 重置密码请求
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RequestPasswordReset 申请重置密码，按邮箱和IP限流，邮箱不存在时同样返回成功以避免泄露账号信息
+func (s *AuthService) RequestPasswordReset(email, ip string) error {
+	email = normalizeEmail(email)
+	limited, err := s.rateLimited(securityEventResetRequest, email, ip)
+	if err != nil {
+		return err
+	}
+	if limited {
+		if err := writeSecurityLog(s.db, &model.SecurityLog{
+			Username:    email,
+			EventType:   securityEventResetRateLimited,
+			Title:       "密码重置请求过于频繁",
+			SourceIP:    ip,
+			ThreatLevel: "medium",
+			ThreatType:  "brute_force",
+			BlockedFlag: true,
+		}); err != nil {
+			return err
+		}
+		return ErrTooManyRequests
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		entry := &model.SecurityLog{
+			Username:  email,
+			EventType: securityEventResetRequest,
+			Severity:  model.LogLevelInfo,
+			Title:     "申请重置密码",
+			SourceIP:  ip,
+		}
+		var user model.User
+		err := tx.Where("email = ?", email).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && user.Status == model.UserStatusDeleted) {
+			entry.Description = "邮箱未注册"
+			return writeSecurityLog(tx, entry)
+		}
+		if err != nil {
+			return fmt.Errorf("查询用户失败: %w", err)
+		}
+
+		entry.UserID = &user.ID
+		if err := writeSecurityLog(tx, entry); err != nil {
+			return err
+		}
+		return sendResetEmail(tx, &user, ip)
+	})
+}
+
+// ResetPassword 使用一次性令牌重置密码，成功后此前签发的所有登录令牌失效
+func (s *AuthService) ResetPassword(req *ResetPasswordRequest, ip string) error {
+	if err := validatePassword(req.Password); err != nil {
+		return err
+	}
+	hash, err := hashPassword(req.Password)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := consumeUserToken(tx, req.Token, model.UserTokenPasswordReset)
+		if err != nil {
+			return err
+		}
+		user, err := getUser(tx, record.UserID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"password_hash":       hash,
+			"password_changed_at": now,
+			"token_version":       gorm.Expr("token_version + 1"),
+			"login_fail_count":    0,
+			"locked_until":        nil,
+		}).Error; err != nil {
+			return fmt.Errorf("更新密码失败: %w", err)
+		}
+		if err := invalidateUserTokens(tx, user.ID, model.UserTokenPasswordReset); err != nil {
+			return err
+		}
+		if err := revokeSessions(tx, user.ID, model.SessionRevokePasswordReset); err != nil {
+			return err
+		}
+		// 能通过邮件链接重置密码即证明拥有该邮箱
+		if !user.EmailVerified {
+			if err := markEmailVerified(tx, user.ID); err != nil {
+				return err
+			}
+		}
+		return recordPasswordReset(tx, user, ip)
+	})
+	if errors.Is(err, ErrInvalidToken) {
+		if logErr := writeSecurityLog(s.db, &model.SecurityLog{
+			EventType: securityEventInvalidResetToken,
+			Title:     "使用无效的密码重置令牌",
+			SourceIP:  ip,
+		}); logErr != nil {
+			return logErr
+		}
+	}
+	return err
+}
+
+// rateLimited 检查时间窗口内同一邮箱或同一IP的请求数是否超限
+func (s *AuthService) rateLimited(eventType, email, ip string) (bool, error) {
+	window := getConfigInt(s.db, configKeyResetWindow, defaultResetWindowMinutes)
+	since := time.Now().Add(-time.Duration(window) * time.Minute)
+
+	byEmail, err := countSecurityEvents(s.db, eventType, "username", email, since)
+	if err != nil {
+		return false, err
+	}
+	if byEmail >= int64(getConfigInt(s.db, configKeyResetMaxPerEmail, defaultResetMaxPerEmail)) {
+		return true, nil
+	}
+	byIP, err := countSecurityEvents(s.db, eventType, "source_ip", ip, since)
+	if err != nil {
+		return false, err
+	}
+	return byIP >= int64(getConfigInt(s.db, configKeyResetMaxPerIP, defaultResetMaxPerIP)), nil
+}
+
+// sendResetEmail 作废旧的重置令牌，签发新令牌并写入重置邮件
+func sendResetEmail(tx *gorm.DB, user *model.User, ip string) error {
+	if err := invalidateUserTokens(tx, user.ID, model.UserTokenPasswordReset); err != nil {
+		return err
+	}
+	ttl := getConfigInt(tx, configKeyResetTTL, defaultResetTTLMinutes)
+	token, err := issueUserToken(tx, user.ID, model.UserTokenPasswordReset, time.Duration(ttl)*time.Minute, ip)
+	if err != nil {
+		return err
+	}
+	_, err = enqueueEmail(tx, &EmailRequest{
+		To:       user.Email,
+		UserID:   &user.ID,
+		Template: EmailPasswordReset,
+		Locale:   user.Language,
+		Data: map[string]interface{}{
+			"Username":      displayName(user),
+			"Link":          siteLink(tx, "/reset-password", token),
+			"ExpireMinutes": ttl,
+		},
+	})
+	return err
+}
+
+// recordPasswordReset 记录密码重置的安全日志和操作日志
+func recordPasswordReset(tx *gorm.DB, user *model.User, ip string) error {
+	if err := writeSecurityLog(tx, &model.SecurityLog{
+		UserID:    &user.ID,
+		Username:  user.Username,
+		EventType: securityEventPasswordReset,
+		Severity:  model.LogLevelInfo,
+		Status:    "resolved",
+		Title:     "密码已重置，全部登录会话已失效",
+		SourceIP:  ip,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Create(&model.OperationLog{
+		UserID:        &user.ID,
+		Username:      user.Username,
+		Type:          model.LogTypeAuth,
+		Action:        model.ActionPasswordReset,
+		Module:        "auth",
+		Title:         "重置密码",
+		IPAddress:     ip,
+		ImportantFlag: true,
+	}).Error; err != nil {
+		return fmt.Errorf("写入操作日志失败: %w", err)
+	}
+	return nil
+}
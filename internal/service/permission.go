@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// PermissionService 权限判定服务，供路由中间件使用
+type PermissionService struct {
+	db *gorm.DB
+}
+
+// NewPermissionService 创建权限判定服务
+func NewPermissionService(db *gorm.DB) *PermissionService {
+	return &PermissionService{db: db}
+}
+
+// HasPermission 检查用户是否拥有某类资源上的全局权限，用户不存在时视为没有权限
+func (s *PermissionService) HasPermission(userID uint, resourceType model.ResourceType, action model.PermissionAction) (bool, error) {
+	user, err := getUser(s.db, userID)
+	if errors.Is(err, ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return hasGlobalPermission(s.db, user, resourceType, action)
+}
+
+// hasSystemPermission 检查用户是否拥有系统权限
+func hasSystemPermission(tx *gorm.DB, user *model.User, action model.PermissionAction) (bool, error) {
+	return hasGlobalPermission(tx, user, model.ResourceTypeSystem, action)
+}
+
+// hasGlobalPermission 检查用户是否拥有某类资源上的全局权限
+// 管理员拥有全部权限；单独授权(包括显式拒绝)优先于来自权限模板的授权，都没有时检查全局角色
+func hasGlobalPermission(tx *gorm.DB, user *model.User, resourceType model.ResourceType, action model.PermissionAction) (bool, error) {
+	if !user.IsActive() {
+		return false, nil
+	}
+	if user.IsAdmin() {
+		return true, nil
+	}
+
+	var grant model.UserPermission
+	err := tx.Where("user_id = ? AND resource_type = ? AND resource_id IS NULL AND action = ?",
+		user.ID, resourceType, action).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("template_id IS NULL DESC, id DESC").First(&grant).Error
+	if err == nil {
+		return grant.Allowed, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, fmt.Errorf("查询用户权限失败: %w", err)
+	}
+	return hasRolePermission(tx, user.ID, []RoleScope{globalScope}, resourceType, action)
+}
+
+// requireSystemPermission 校验用户拥有系统权限，返回该用户
+func requireSystemPermission(tx *gorm.DB, userID uint, action model.PermissionAction) (*model.User, error) {
+	user, err := getUser(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := hasSystemPermission(tx, user, action)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrForbidden
+	}
+	return user, nil
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 权限到期相关配置
+const (
+	configKeyExpiryNoticeDays = "permission.expiry.notice_days" // 到期前提前提醒的天数，0表示不提醒
+	defaultExpiryNoticeDays   = 3
+	expiryBatchSize           = 200
+)
+
+// systemOperatorName 系统自动操作在日志中记录的操作人
+const systemOperatorName = "system"
+
+// 带有过期时间的授权类型，同时作为操作日志中的资源类型
+const (
+	grantKindUserPermission = "user_permission"
+	grantKindFilePermission = "file_permission"
+	grantKindUserRole       = "user_role"
+)
+
+// expiringGrant 统一表示三类带有过期时间的授权
+type expiringGrant struct {
+	ID        uint
+	UserID    *uint
+	GrantedBy *uint
+	ExpiresAt time.Time
+	Grantee   string
+	Label     string
+}
+
+// grantSource 一类授权的表模型和查询方式，query已带好过期条件、排序和数量限制
+type grantSource struct {
+	kind     string
+	newModel func() interface{}
+	find     func(query *gorm.DB) ([]expiringGrant, error)
+}
+
+// grantSources 需要清理和提醒的授权
+var grantSources = []grantSource{
+	{
+		kind:     grantKindUserPermission,
+		newModel: func() interface{} { return &model.UserPermission{} },
+		find:     findUserPermissionGrants,
+	},
+	{
+		kind:     grantKindFilePermission,
+		newModel: func() interface{} { return &model.FilePermission{} },
+		find:     findFilePermissionGrants,
+	},
+	{
+		kind:     grantKindUserRole,
+		newModel: func() interface{} { return &model.UserRole{} },
+		find:     findUserRoleGrants,
+	},
+}
+
+// PermissionExpiryService 权限到期服务，清理过期授权并在到期前提醒
+type PermissionExpiryService struct {
+	db       *gorm.DB
+	notifier *NotificationService
+}
+
+// NewPermissionExpiryService 创建权限到期服务
+func NewPermissionExpiryService(db *gorm.DB, notifier *NotificationService) *PermissionExpiryService {
+	return &PermissionExpiryService{db: db, notifier: notifier}
+}
+
+// SweepExpiredGrants 软删除已过期的用户权限、文件权限和角色授予，每条撤销记录一条系统操作日志
+func (s *PermissionExpiryService) SweepExpiredGrants(ctx context.Context) error {
+	now := time.Now()
+	for _, source := range grantSources {
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			grants, err := source.find(s.db.WithContext(ctx).
+				Where("expires_at IS NOT NULL AND expires_at <= ?", now).
+				Order("id ASC").Limit(expiryBatchSize))
+			if err != nil {
+				return err
+			}
+			for i := range grants {
+				if err := s.revokeExpired(source, &grants[i], now); err != nil {
+					return err
+				}
+			}
+			if len(grants) < expiryBatchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// revokeExpired 撤销单条过期授权，期间被续期的授权保持不变
+func (s *PermissionExpiryService) revokeExpired(source grantSource, grant *expiringGrant, now time.Time) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND expires_at <= ?", grant.ID, now).Delete(source.newModel())
+		if result.Error != nil {
+			return fmt.Errorf("撤销过期授权失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		entry := &model.OperationLog{
+			Username:     systemOperatorName,
+			Type:         model.LogTypeSecurity,
+			Action:       model.ActionPermissionRevoke,
+			Module:       "permission",
+			Title:        "权限到期自动撤销",
+			Description:  fmt.Sprintf("被授权人: %s, 到期时间: %s", grant.Grantee, grant.ExpiresAt.Format(time.DateTime)),
+			ResourceType: source.kind,
+			ResourceID:   &grant.ID,
+			ResourceName: grant.Label,
+		}
+		return appendAuditLog(tx, entry)
+	})
+}
+
+// NotifyExpiringGrants 在授权到期前按配置天数提醒被授权人和授权人，每条授权只提醒一次
+func (s *PermissionExpiryService) NotifyExpiringGrants(ctx context.Context) error {
+	days := getConfigInt(s.db, configKeyExpiryNoticeDays, defaultExpiryNoticeDays)
+	if days <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	deadline := now.AddDate(0, 0, days)
+	for _, source := range grantSources {
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			grants, err := source.find(s.db.WithContext(ctx).
+				Where("expires_at > ? AND expires_at <= ? AND expiry_notified_at IS NULL", now, deadline).
+				Order("id ASC").Limit(expiryBatchSize))
+			if err != nil {
+				return err
+			}
+			for i := range grants {
+				s.notifyExpiring(ctx, &grants[i], now)
+				if err := s.db.Model(source.newModel()).Where("id = ?", grants[i].ID).
+					Update("expiry_notified_at", now).Error; err != nil {
+					return fmt.Errorf("更新到期提醒时间失败: %w", err)
+				}
+			}
+			if len(grants) < expiryBatchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// notifyExpiring 发送到期提醒，投递失败只记录日志，避免单个用户的问题阻塞其他提醒
+func (s *PermissionExpiryService) notifyExpiring(ctx context.Context, grant *expiringGrant, now time.Time) {
+	data := map[string]interface{}{
+		"Grant":     grant.Label,
+		"Grantee":   grant.Grantee,
+		"Days":      daysUntil(grant.ExpiresAt, now),
+		"ExpiresAt": grant.ExpiresAt.Format(time.DateTime),
+	}
+	for _, req := range expiryRecipients(grant) {
+		req.Data = data
+		if err := s.notifier.Notify(ctx, &req); err != nil {
+			log.Printf("向用户 %d 发送权限到期提醒失败: %v", req.UserID, err)
+		}
+	}
+}
+
+// expiryRecipients 到期提醒的接收人：被授权用户和授权人，授权人给自己授权时只提醒一次
+func expiryRecipients(grant *expiringGrant) []NotifyRequest {
+	var requests []NotifyRequest
+	if grant.UserID != nil {
+		requests = append(requests, NotifyRequest{UserID: *grant.UserID, Template: NoticePermissionExpiring})
+	}
+	if grant.GrantedBy != nil && (grant.UserID == nil || *grant.GrantedBy != *grant.UserID) {
+		requests = append(requests, NotifyRequest{UserID: *grant.GrantedBy, Template: NoticeGrantExpiring})
+	}
+	return requests
+}
+
+// daysUntil 距离到期的天数，不足一天按一天计
+func daysUntil(expiresAt, now time.Time) int {
+	return max(1, int(math.Ceil(expiresAt.Sub(now).Hours()/24)))
+}
+
+// findUserPermissionGrants 查询用户权限授权
+func findUserPermissionGrants(query *gorm.DB) ([]expiringGrant, error) {
+	var permissions []model.UserPermission
+	if err := query.Preload("User").Find(&permissions).Error; err != nil {
+		return nil, fmt.Errorf("查询用户权限失败: %w", err)
+	}
+
+	grants := make([]expiringGrant, 0, len(permissions))
+	for i := range permissions {
+		p := &permissions[i]
+		label := fmt.Sprintf("%s:%s", p.ResourceType, p.Action)
+		if p.ResourceID != nil {
+			label = fmt.Sprintf("%s#%d:%s", p.ResourceType, *p.ResourceID, p.Action)
+		}
+		grants = append(grants, expiringGrant{
+			ID: p.ID, UserID: &p.UserID, GrantedBy: p.GrantedBy, ExpiresAt: *p.ExpiresAt,
+			Grantee: p.User.Username, Label: label,
+		})
+	}
+	return grants, nil
+}
+
+// findFilePermissionGrants 查询文件权限授权，授予团队的权限只提醒授权人
+func findFilePermissionGrants(query *gorm.DB) ([]expiringGrant, error) {
+	var permissions []model.FilePermission
+	if err := query.Preload("File").Preload("User").Preload("Team").Find(&permissions).Error; err != nil {
+		return nil, fmt.Errorf("查询文件权限失败: %w", err)
+	}
+
+	grants := make([]expiringGrant, 0, len(permissions))
+	for i := range permissions {
+		p := &permissions[i]
+		fileName := p.File.Name
+		if fileName == "" {
+			fileName = fmt.Sprintf("file#%d", p.FileID)
+		}
+		grantee := ""
+		switch {
+		case p.User != nil:
+			grantee = p.User.Username
+		case p.Team != nil:
+			grantee = p.Team.Name
+		}
+		grants = append(grants, expiringGrant{
+			ID: p.ID, UserID: p.UserID, GrantedBy: p.GrantedBy, ExpiresAt: *p.ExpiresAt,
+			Grantee: grantee, Label: fmt.Sprintf("%s:%s", fileName, p.Action),
+		})
+	}
+	return grants, nil
+}
+
+// findUserRoleGrants 查询角色授予
+func findUserRoleGrants(query *gorm.DB) ([]expiringGrant, error) {
+	var assignments []model.UserRole
+	if err := query.Preload("Role").Preload("User").Find(&assignments).Error; err != nil {
+		return nil, fmt.Errorf("查询角色授予失败: %w", err)
+	}
+
+	grants := make([]expiringGrant, 0, len(assignments))
+	for i := range assignments {
+		a := &assignments[i]
+		label := "role:" + a.Role.Name
+		if a.ScopeID != nil {
+			label = fmt.Sprintf("role:%s@%s#%d", a.Role.Name, a.ScopeType, *a.ScopeID)
+		}
+		grants = append(grants, expiringGrant{
+			ID: a.ID, UserID: &a.UserID, GrantedBy: a.GrantedBy, ExpiresAt: *a.ExpiresAt,
+			Grantee: a.User.Username, Label: label,
+		})
+	}
+	return grants, nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// TestExpiryRecipients 测试到期提醒接收人
+func TestExpiryRecipients(t *testing.T) {
+	grantee, granter := uint(1), uint(2)
+	tests := []struct {
+		name      string
+		grant     expiringGrant
+		templates []string
+	}{
+		{"被授权人和授权人", expiringGrant{UserID: &grantee, GrantedBy: &granter}, []string{NoticePermissionExpiring, NoticeGrantExpiring}},
+		{"给自己授权", expiringGrant{UserID: &grantee, GrantedBy: &grantee}, []string{NoticePermissionExpiring}},
+		{"授予团队", expiringGrant{GrantedBy: &granter}, []string{NoticeGrantExpiring}},
+		{"没有授权人", expiringGrant{UserID: &grantee}, []string{NoticePermissionExpiring}},
+	}
+	for _, tt := range tests {
+		requests := expiryRecipients(&tt.grant)
+		if len(requests) != len(tt.templates) {
+			t.Errorf("%s: 期望 %d 个接收人, 实际 %d", tt.name, len(tt.templates), len(requests))
+			continue
+		}
+		for i, req := range requests {
+			if req.Template != tt.templates[i] {
+				t.Errorf("%s: 第 %d 个提醒模板 = %s, 期望 %s", tt.name, i, req.Template, tt.templates[i])
+			}
+		}
+	}
+}
+
+// TestDaysUntil 测试到期天数按整天向上取整
+func TestDaysUntil(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expiresAt time.Time
+		want      int
+	}{
+		{now.Add(time.Hour), 1},
+		{now.Add(24 * time.Hour), 1},
+		{now.Add(25 * time.Hour), 2},
+		{now.AddDate(0, 0, 3), 3},
+	}
+	for _, tt := range tests {
+		if got := daysUntil(tt.expiresAt, now); got != tt.want {
+			t.Errorf("daysUntil(%v) = %d, want %d", tt.expiresAt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 权限模板配置
+const (
+	maxTemplateAssignUsers = 500
+	templateSyncBatchSize  = 200
+)
+
+// PermissionTemplateService 权限模板服务
+type PermissionTemplateService struct {
+	db *gorm.DB
+}
+
+// NewPermissionTemplateService 创建权限模板服务
+func NewPermissionTemplateService(db *gorm.DB) *PermissionTemplateService {
+	return &PermissionTemplateService{db: db}
+}
+
+// TemplatePermissionItem 模板中的一项权限
+type TemplatePermissionItem struct {
+	ResourceType model.ResourceType     `json:"resource_type" binding:"required,oneof=file folder team system"`
+	Action       model.PermissionAction `json:"action" binding:"required,max=50"`
+	Allowed      bool                   `json:"allowed"`
+}
+
+// SaveTemplateRequest 创建或编辑权限模板请求
+type SaveTemplateRequest struct {
+	Name         string                   `json:"name" binding:"required,max=100"`
+	Description  string                   `json:"description"`
+	IsDefault    bool                     `json:"is_default"`
+	StorageQuota int64                    `json:"storage_quota" binding:"required,gt=0"`
+	Permissions  []TemplatePermissionItem `json:"permissions" binding:"dive"`
+}
+
+// TemplateSyncResult 模板同步结果
+type TemplateSyncResult struct {
+	Template *model.PermissionTemplate `json:"template"`
+	Users    int                       `json:"users"`
+}
+
+// ListTemplates 获取全部权限模板及其权限
+func (s *PermissionTemplateService) ListTemplates(operatorID uint) ([]model.PermissionTemplate, error) {
+	if _, err := requireSystemPermission(s.db, operatorID, model.PermissionUserManage); err != nil {
+		return nil, err
+	}
+	var templates []model.PermissionTemplate
+	if err := s.db.Preload("TemplatePermissions").Order("id ASC").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("查询权限模板失败: %w", err)
+	}
+	return templates, nil
+}
+
+// CreateTemplate 创建权限模板
+func (s *PermissionTemplateService) CreateTemplate(operatorID uint, req *SaveTemplateRequest, ip string) (*model.PermissionTemplate, error) {
+	template := &model.PermissionTemplate{
+		Name:         strings.TrimSpace(req.Name),
+		Description:  req.Description,
+		IsDefault:    req.IsDefault,
+		StorageQuota: req.StorageQuota,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		operator, err := requireSystemPermission(tx, operatorID, model.PermissionUserManage)
+		if err != nil {
+			return err
+		}
+		if err := requireGrantable(tx, operator, req.Permissions); err != nil {
+			return err
+		}
+		if err := ensureTemplateNameUnique(tx, template.Name, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(template).Error; err != nil {
+			return fmt.Errorf("创建权限模板失败: %w", err)
+		}
+		if err := saveTemplatePermissions(tx, template, req.Permissions); err != nil {
+			return err
+		}
+		if template.IsDefault {
+			if err := clearOtherDefaults(tx, template.ID); err != nil {
+				return err
+			}
+		}
+		return recordTemplateAction(tx, operator, template, &model.OperationLog{
+			Action:    model.ActionConfigUpdate,
+			Title:     "创建权限模板",
+			IPAddress: ip,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// UpdateTemplate 编辑权限模板，并将配额和权限同步到所有使用该模板的用户
+func (s *PermissionTemplateService) UpdateTemplate(operatorID, templateID uint, req *SaveTemplateRequest, ip string) (*TemplateSyncResult, error) {
+	result := &TemplateSyncResult{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		operator, err := requireSystemPermission(tx, operatorID, model.PermissionUserManage)
+		if err != nil {
+			return err
+		}
+		if err := requireGrantable(tx, operator, req.Permissions); err != nil {
+			return err
+		}
+		template, err := getPermissionTemplate(tx, templateID)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSpace(req.Name)
+		if err := ensureTemplateNameUnique(tx, name, template.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Model(template).Updates(map[string]interface{}{
+			"name":          name,
+			"description":   req.Description,
+			"is_default":    req.IsDefault,
+			"storage_quota": req.StorageQuota,
+		}).Error; err != nil {
+			return fmt.Errorf("更新权限模板失败: %w", err)
+		}
+		if err := saveTemplatePermissions(tx, template, req.Permissions); err != nil {
+			return err
+		}
+		if req.IsDefault {
+			if err := clearOtherDefaults(tx, template.ID); err != nil {
+				return err
+			}
+		}
+
+		if template, err = loadTemplate(tx, template.ID); err != nil {
+			return err
+		}
+		if result.Users, err = resyncTemplate(tx, template); err != nil {
+			return err
+		}
+		result.Template = template
+		return recordTemplateAction(tx, operator, template, &model.OperationLog{
+			Action:      model.ActionConfigUpdate,
+			Title:       "编辑权限模板",
+			Description: fmt.Sprintf("已同步 %d 个用户", result.Users),
+			IPAddress:   ip,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AssignTemplate 批量为用户应用权限模板，管理员账号会被跳过，操作人不能为自己分配模板
+func (s *PermissionTemplateService) AssignTemplate(operatorID, templateID uint, userIDs []uint, ip string) (*TemplateSyncResult, error) {
+	if len(userIDs) == 0 || len(userIDs) > maxTemplateAssignUsers {
+		return nil, ErrInvalidParam
+	}
+	if slices.Contains(userIDs, operatorID) {
+		return nil, ErrForbidden
+	}
+
+	result := &TemplateSyncResult{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		operator, err := requireSystemPermission(tx, operatorID, model.PermissionUserManage)
+		if err != nil {
+			return err
+		}
+		template, err := loadTemplate(tx, templateID)
+		if err != nil {
+			return err
+		}
+		if err := requireGrantable(tx, operator, templatePermissionItems(template)); err != nil {
+			return err
+		}
+
+		var users []model.User
+		if err := tx.Where("id IN ? AND user_type <> ? AND status <> ?",
+			userIDs, model.UserTypeAdmin, model.UserStatusDeleted).Find(&users).Error; err != nil {
+			return fmt.Errorf("查询用户失败: %w", err)
+		}
+		for i := range users {
+			if err := applyTemplate(tx, &users[i], template); err != nil {
+				return err
+			}
+		}
+
+		result.Template = template
+		result.Users = len(users)
+		return recordTemplateAction(tx, operator, template, &model.OperationLog{
+			Action:      model.ActionAdminUserUpdate,
+			Title:       "批量分配权限模板",
+			Description: fmt.Sprintf("已分配给 %d 个用户", len(users)),
+			IPAddress:   ip,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyDefaultTemplate 为新用户应用默认权限模板，未设置默认模板时不做处理
+func applyDefaultTemplate(tx *gorm.DB, user *model.User) error {
+	var template model.PermissionTemplate
+	err := tx.Preload("TemplatePermissions").Where("is_default = ?", true).Order("id ASC").First(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询默认权限模板失败: %w", err)
+	}
+	return applyTemplate(tx, user, &template)
+}
+
+// applyTemplate 为用户应用权限模板，单独设置过的配额和单独授权的权限保持不变
+func applyTemplate(tx *gorm.DB, user *model.User, template *model.PermissionTemplate) error {
+	updates := map[string]interface{}{"permission_template_id": template.ID}
+	if !user.StorageQuotaCustom {
+		updates["storage_quota"] = template.StorageQuota
+	}
+	if err := tx.Model(user).Updates(updates).Error; err != nil {
+		return fmt.Errorf("应用权限模板失败: %w", err)
+	}
+	return syncTemplatePermissions(tx, user.ID, template)
+}
+
+// syncTemplatePermissions 用模板重建用户来自模板的权限，template为nil时只清除
+func syncTemplatePermissions(tx *gorm.DB, userID uint, template *model.PermissionTemplate) error {
+	if err := tx.Unscoped().Where("user_id = ? AND template_id IS NOT NULL", userID).
+		Delete(&model.UserPermission{}).Error; err != nil {
+		return fmt.Errorf("清除模板权限失败: %w", err)
+	}
+	if template == nil {
+		return nil
+	}
+
+	var overrides []model.UserPermission
+	if err := tx.Where("user_id = ? AND template_id IS NULL AND resource_id IS NULL", userID).
+		Find(&overrides).Error; err != nil {
+		return fmt.Errorf("查询用户权限失败: %w", err)
+	}
+	grants := templateGrants(userID, template, overrides)
+	if len(grants) == 0 {
+		return nil
+	}
+	if err := tx.Create(&grants).Error; err != nil {
+		return fmt.Errorf("写入模板权限失败: %w", err)
+	}
+	return nil
+}
+
+// templateGrants 根据模板生成用户权限，已有单独授权的权限不生成
+func templateGrants(userID uint, template *model.PermissionTemplate, overrides []model.UserPermission) []model.UserPermission {
+	overridden := make(map[string]bool, len(overrides))
+	for _, override := range overrides {
+		overridden[permissionKey(override.ResourceType, override.Action)] = true
+	}
+
+	grants := make([]model.UserPermission, 0, len(template.TemplatePermissions))
+	for _, item := range template.TemplatePermissions {
+		if overridden[permissionKey(item.ResourceType, item.Action)] {
+			continue
+		}
+		grants = append(grants, model.UserPermission{
+			UserID:       userID,
+			ResourceType: item.ResourceType,
+			Action:       item.Action,
+			Allowed:      item.Allowed,
+			TemplateID:   &template.ID,
+		})
+	}
+	return grants
+}
+
+// resyncTemplate 将模板同步到所有使用该模板的用户，返回同步的用户数
+func resyncTemplate(tx *gorm.DB, template *model.PermissionTemplate) (int, error) {
+	count := 0
+	var users []model.User
+	result := tx.Where("permission_template_id = ?", template.ID).
+		FindInBatches(&users, templateSyncBatchSize, func(batch *gorm.DB, _ int) error {
+			for i := range users {
+				if err := applyTemplate(batch, &users[i], template); err != nil {
+					return err
+				}
+			}
+			count += len(users)
+			return nil
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("同步权限模板失败: %w", result.Error)
+	}
+	return count, nil
+}
+
+// saveTemplatePermissions 替换模板的权限列表
+func saveTemplatePermissions(tx *gorm.DB, template *model.PermissionTemplate, items []TemplatePermissionItem) error {
+	if err := tx.Where("template_id = ?", template.ID).Delete(&model.TemplatePermission{}).Error; err != nil {
+		return fmt.Errorf("清除模板权限失败: %w", err)
+	}
+
+	seen := make(map[string]bool, len(items))
+	permissions := make([]model.TemplatePermission, 0, len(items))
+	for _, item := range items {
+		key := permissionKey(item.ResourceType, item.Action)
+		if seen[key] {
+			return ErrInvalidParam
+		}
+		seen[key] = true
+		permissions = append(permissions, model.TemplatePermission{
+			TemplateID:   template.ID,
+			ResourceType: item.ResourceType,
+			Action:       item.Action,
+			Allowed:      item.Allowed,
+		})
+	}
+	if len(permissions) == 0 {
+		return nil
+	}
+	if err := tx.Create(&permissions).Error; err != nil {
+		return fmt.Errorf("保存模板权限失败: %w", err)
+	}
+	return nil
+}
+
+// loadTemplate 获取权限模板及其权限
+func loadTemplate(tx *gorm.DB, templateID uint) (*model.PermissionTemplate, error) {
+	var template model.PermissionTemplate
+	if err := tx.Preload("TemplatePermissions").First(&template, templateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, fmt.Errorf("查询权限模板失败: %w", err)
+	}
+	return &template, nil
+}
+
+// ensureTemplateNameUnique 校验模板名称未被其他模板使用
+func ensureTemplateNameUnique(tx *gorm.DB, name string, excludeID uint) error {
+	var count int64
+	if err := tx.Model(&model.PermissionTemplate{}).
+		Where("name = ? AND id <> ?", name, excludeID).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询权限模板失败: %w", err)
+	}
+	if count > 0 {
+		return ErrTemplateExists
+	}
+	return nil
+}
+
+// clearOtherDefaults 保证只有一个默认模板
+func clearOtherDefaults(tx *gorm.DB, templateID uint) error {
+	if err := tx.Model(&model.PermissionTemplate{}).
+		Where("id <> ? AND is_default = ?", templateID, true).
+		Update("is_default", false).Error; err != nil {
+		return fmt.Errorf("更新默认权限模板失败: %w", err)
+	}
+	return nil
+}
+
+// recordTemplateAction 写入权限模板相关的操作日志，追加到审计哈希链
+func recordTemplateAction(tx *gorm.DB, operator *model.User, template *model.PermissionTemplate, entry *model.OperationLog) error {
+	entry.UserID = &operator.ID
+	entry.Username = operator.Username
+	entry.Type = model.LogTypeAdmin
+	entry.Module = "permission"
+	entry.ResourceType = "permission_template"
+	entry.ResourceID = &template.ID
+	entry.ResourceName = template.Name
+	return appendAuditLog(tx, entry)
+}
+
+// requireGrantable 校验操作人自己拥有要授予的全部权限，显式拒绝的权限不需要校验
+// 防止只有用户管理权限的操作人通过模板为自己或他人授予超出自身的权限
+func requireGrantable(tx *gorm.DB, operator *model.User, items []TemplatePermissionItem) error {
+	for _, item := range items {
+		if !item.Allowed {
+			continue
+		}
+		allowed, err := hasGlobalPermission(tx, operator, item.ResourceType, item.Action)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
+
+// templatePermissionItems 模板中的权限列表
+func templatePermissionItems(template *model.PermissionTemplate) []TemplatePermissionItem {
+	items := make([]TemplatePermissionItem, 0, len(template.TemplatePermissions))
+	for _, permission := range template.TemplatePermissions {
+		items = append(items, TemplatePermissionItem{
+			ResourceType: permission.ResourceType,
+			Action:       permission.Action,
+			Allowed:      permission.Allowed,
+		})
+	}
+	return items
+}
+
+// permissionKey 权限去重键
+func permissionKey(resourceType model.ResourceType, action model.PermissionAction) string {
+	return string(resourceType) + ":" + string(action)
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"ycg_cloud/internal/model"
+)
+
+// TestTemplateGrants 测试模板权限生成时保留单独授权
+func TestTemplateGrants(t *testing.T) {
+	template := &model.PermissionTemplate{
+		ID: 3,
+		TemplatePermissions: []model.TemplatePermission{
+			{ResourceType: model.ResourceTypeSystem, Action: model.PermissionLogView, Allowed: true},
+			{ResourceType: model.ResourceTypeSystem, Action: model.PermissionUserManage, Allowed: true},
+			{ResourceType: model.ResourceTypeFile, Action: model.PermissionShare, Allowed: false},
+		},
+	}
+	overrides := []model.UserPermission{
+		{ResourceType: model.ResourceTypeSystem, Action: model.PermissionUserManage, Allowed: false},
+	}
+
+	grants := templateGrants(7, template, overrides)
+	if len(grants) != 2 {
+		t.Fatalf("期望生成 2 条权限, 实际 %d", len(grants))
+	}
+	for _, grant := range grants {
+		if grant.Action == model.PermissionUserManage {
+			t.Error("单独授权的权限不应被模板覆盖")
+		}
+		if grant.UserID != 7 || grant.TemplateID == nil || *grant.TemplateID != 3 {
+			t.Errorf("权限来源不正确: %+v", grant)
+		}
+	}
+	if grants[1].Allowed {
+		t.Error("模板中的拒绝应原样保留")
+	}
+}
+
+// TestRequireGrantable 测试操作人只能授予自己拥有的权限，显式拒绝不受限制
+func TestRequireGrantable(t *testing.T) {
+	// 管理员拥有全部权限、停用的用户没有任何权限，这两种情况都不需要查询数据库
+	admin := &model.User{UserType: model.UserTypeAdmin, Status: model.UserStatusActive}
+	inactive := &model.User{UserType: model.UserTypeNormal, Status: model.UserStatusSuspended}
+	grant := []TemplatePermissionItem{{ResourceType: model.ResourceTypeSystem, Action: model.PermissionSystemConfig, Allowed: true}}
+	deny := []TemplatePermissionItem{{ResourceType: model.ResourceTypeSystem, Action: model.PermissionSystemConfig}}
+
+	if err := requireGrantable(nil, admin, grant); err != nil {
+		t.Errorf("管理员授予系统权限 = %v, 期望通过", err)
+	}
+	if err := requireGrantable(nil, inactive, grant); !errors.Is(err, ErrForbidden) {
+		t.Errorf("没有权限的操作人授予系统权限 = %v, 期望 ErrForbidden", err)
+	}
+	if err := requireGrantable(nil, inactive, deny); err != nil {
+		t.Errorf("显式拒绝 = %v, 期望通过", err)
+	}
+}
+
+// TestAssignTemplateExcludesOperator 测试操作人不能为自己批量分配模板
+func TestAssignTemplateExcludesOperator(t *testing.T) {
+	_, err := NewPermissionTemplateService(nil).AssignTemplate(3, 1, []uint{2, 3}, "")
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("为自己分配模板 = %v, 期望 ErrForbidden", err)
+	}
+}
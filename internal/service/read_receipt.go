@@ -0,0 +1,195 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// maxReceiptQuery 单次查询回执的最大消息数
+const maxReceiptQuery = 100
+
+// memberWatermark 成员的已读、送达位置
+type memberWatermark struct {
+	UserID                 uint
+	LastReadMessageID      *uint
+	LastDeliveredMessageID *uint
+	JoinedAt               time.Time
+}
+
+// MessageReceipt 消息的送达、已读统计
+type MessageReceipt struct {
+	MessageID      uint   `json:"message_id"`
+	Status         string `json:"status"`
+	RecipientCount int    `json:"recipient_count"`
+	DeliveredCount int    `json:"delivered_count"`
+	ReadCount      int    `json:"read_count"`
+}
+
+// ReceiptMember 回执中的成员信息
+type ReceiptMember struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+}
+
+// MessageReaders 消息的已读、未读成员
+type MessageReaders struct {
+	MessageID uint            `json:"message_id"`
+	Read      []ReceiptMember `json:"read"`
+	Delivered []ReceiptMember `json:"delivered"`
+	Unread    []ReceiptMember `json:"unread"`
+}
+
+// isRecipient 成员是否为消息的接收者(排除发送者和消息发出后才加入的成员)
+func (w *memberWatermark) isRecipient(message *model.Message) bool {
+	return w.UserID != message.SenderID && !w.JoinedAt.After(message.CreatedAt)
+}
+
+// hasRead 成员是否已读到该消息
+func (w *memberWatermark) hasRead(messageID uint) bool {
+	return w.LastReadMessageID != nil && *w.LastReadMessageID >= messageID
+}
+
+// hasDelivered 成员是否已收到该消息
+func (w *memberWatermark) hasDelivered(messageID uint) bool {
+	return w.hasRead(messageID) || (w.LastDeliveredMessageID != nil && *w.LastDeliveredMessageID >= messageID)
+}
+
+// computeReceipts 根据成员的已读、送达位置计算每条消息的回执，不依赖逐条回执记录
+func computeReceipts(messages []model.Message, watermarks []memberWatermark) []MessageReceipt {
+	receipts := make([]MessageReceipt, 0, len(messages))
+	for i := range messages {
+		receipt := MessageReceipt{MessageID: messages[i].ID}
+		for j := range watermarks {
+			if !watermarks[j].isRecipient(&messages[i]) {
+				continue
+			}
+			receipt.RecipientCount++
+			if watermarks[j].hasDelivered(messages[i].ID) {
+				receipt.DeliveredCount++
+			}
+			if watermarks[j].hasRead(messages[i].ID) {
+				receipt.ReadCount++
+			}
+		}
+
+		switch {
+		case receipt.RecipientCount > 0 && receipt.ReadCount == receipt.RecipientCount:
+			receipt.Status = string(model.MessageStatusRead)
+		case receipt.RecipientCount > 0 && receipt.DeliveredCount == receipt.RecipientCount:
+			receipt.Status = string(model.MessageStatusDelivered)
+		default:
+			receipt.Status = string(model.MessageStatusSent)
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts
+}
+
+// loadWatermarks 读取会话全部成员的已读、送达位置，成员数受Conversation.MaxMembers限制
+func loadWatermarks(tx *gorm.DB, conversationID uint) ([]memberWatermark, error) {
+	var watermarks []memberWatermark
+	if err := tx.Model(&model.ConversationMember{}).
+		Select("user_id, last_read_message_id, last_delivered_message_id, joined_at").
+		Where("conversation_id = ?", conversationID).
+		Scan(&watermarks).Error; err != nil {
+		return nil, fmt.Errorf("查询成员已读位置失败: %w", err)
+	}
+	return watermarks, nil
+}
+
+// GetReceipts 批量查询消息的送达、已读统计
+func (s *MessageService) GetReceipts(conversationID, userID uint, messageIDs []uint) ([]MessageReceipt, error) {
+	messageIDs = uniqueIDs(messageIDs)
+	if len(messageIDs) == 0 || len(messageIDs) > maxReceiptQuery {
+		return nil, ErrInvalidParam
+	}
+	if _, err := getConversationMember(s.db, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	var messages []model.Message
+	if err := s.db.Select("id, sender_id, created_at").
+		Where("conversation_id = ? AND id IN ?", conversationID, messageIDs).
+		Order("id").Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("查询消息失败: %w", err)
+	}
+	watermarks, err := loadWatermarks(s.db, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return computeReceipts(messages, watermarks), nil
+}
+
+// GetReaders 查询消息的已读、已送达和未读成员
+func (s *MessageService) GetReaders(conversationID, messageID, userID uint) (*MessageReaders, error) {
+	if _, err := getConversationMember(s.db, conversationID, userID); err != nil {
+		return nil, err
+	}
+	message, err := getConversationMessage(s.db, conversationID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	watermarks, err := loadWatermarks(s.db, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var recipientIDs []uint
+	for i := range watermarks {
+		if watermarks[i].isRecipient(message) {
+			recipientIDs = append(recipientIDs, watermarks[i].UserID)
+		}
+	}
+	profiles, err := loadReceiptMembers(s.db, recipientIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	readers := &MessageReaders{
+		MessageID: messageID,
+		Read:      []ReceiptMember{},
+		Delivered: []ReceiptMember{},
+		Unread:    []ReceiptMember{},
+	}
+	for i := range watermarks {
+		profile, ok := profiles[watermarks[i].UserID]
+		if !ok || !watermarks[i].isRecipient(message) {
+			continue
+		}
+		switch {
+		case watermarks[i].hasRead(messageID):
+			readers.Read = append(readers.Read, profile)
+		case watermarks[i].hasDelivered(messageID):
+			readers.Delivered = append(readers.Delivered, profile)
+		default:
+			readers.Unread = append(readers.Unread, profile)
+		}
+	}
+	return readers, nil
+}
+
+// loadReceiptMembers 批量读取成员的展示信息
+func loadReceiptMembers(tx *gorm.DB, userIDs []uint) (map[uint]ReceiptMember, error) {
+	profiles := make(map[uint]ReceiptMember, len(userIDs))
+	if len(userIDs) == 0 {
+		return profiles, nil
+	}
+
+	var members []ReceiptMember
+	if err := tx.Model(&model.User{}).
+		Select("id AS user_id, username, nickname, avatar").
+		Where("id IN ?", userIDs).
+		Scan(&members).Error; err != nil {
+		return nil, fmt.Errorf("查询成员信息失败: %w", err)
+	}
+	for _, member := range members {
+		profiles[member.UserID] = member
+	}
+	return profiles, nil
+}
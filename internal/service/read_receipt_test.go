@@ -0,0 +1,42 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"ycg_cloud/internal/model"
+)
+
+// TestComputeReceipts 测试基于成员已读、送达位置计算消息回执
+func TestComputeReceipts(t *testing.T) {
+	base := time.Now()
+	id := func(v uint) *uint { return &v }
+
+	messages := []model.Message{
+		{ID: 10, SenderID: 1, CreatedAt: base},
+		{ID: 20, SenderID: 1, CreatedAt: base.Add(time.Minute)},
+		{ID: 30, SenderID: 2, CreatedAt: base.Add(2 * time.Minute)},
+	}
+	watermarks := []memberWatermark{
+		{UserID: 1, LastReadMessageID: id(30), JoinedAt: base.Add(-time.Hour)},
+		{UserID: 2, LastReadMessageID: id(20), JoinedAt: base.Add(-time.Hour)},
+		{UserID: 3, LastReadMessageID: id(10), LastDeliveredMessageID: id(30), JoinedAt: base.Add(-time.Hour)},
+		// 消息10发出后才加入的成员不计入消息10的接收者
+		{UserID: 4, JoinedAt: base.Add(30 * time.Second)},
+	}
+
+	want := []MessageReceipt{
+		{MessageID: 10, Status: "read", RecipientCount: 2, DeliveredCount: 2, ReadCount: 2},
+		{MessageID: 20, Status: "sent", RecipientCount: 3, DeliveredCount: 2, ReadCount: 1},
+		{MessageID: 30, Status: "sent", RecipientCount: 3, DeliveredCount: 2, ReadCount: 1},
+	}
+	got := computeReceipts(messages, watermarks)
+	if len(got) != len(want) {
+		t.Fatalf("回执数量错误: 期望 %d, 实际 %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("消息 %d 回执错误: 期望 %+v, 实际 %+v", want[i].MessageID, want[i], got[i])
+		}
+	}
+}
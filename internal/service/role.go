@@ -0,0 +1,524 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// maxFolderDepth 向上查找文件夹祖先的最大层数，防止异常数据导致死循环
+const maxFolderDepth = 64
+
+// RoleService 角色服务
+type RoleService struct {
+	db *gorm.DB
+}
+
+// NewRoleService 创建角色服务
+func NewRoleService(db *gorm.DB) *RoleService {
+	return &RoleService{db: db}
+}
+
+// RoleScope 角色生效范围，ID为nil表示全局
+type RoleScope struct {
+	Type model.ResourceType
+	ID   *uint
+}
+
+// globalScope 全局范围
+var globalScope = RoleScope{Type: model.ResourceTypeSystem}
+
+// AssignRoleRequest 授予角色请求，ScopeType为空时授予全局角色
+type AssignRoleRequest struct {
+	UserID    uint               `json:"user_id" binding:"required"`
+	RoleID    uint               `json:"role_id" binding:"required"`
+	ScopeType model.ResourceType `json:"scope_type" binding:"omitempty,oneof=system team folder"`
+	ScopeID   *uint              `json:"scope_id"`
+	ExpiresAt *time.Time         `json:"expires_at"`
+}
+
+// ListRoles 获取角色列表，按级别从高到低排列
+func (s *RoleService) ListRoles() ([]model.Role, error) {
+	var roles []model.Role
+	if err := s.db.Order("level DESC, id ASC").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	return roles, nil
+}
+
+// ListUserRoles 获取用户当前生效的角色，只能查看自己的角色或由用户管理员查看
+func (s *RoleService) ListUserRoles(operatorID, userID uint) ([]model.UserRole, error) {
+	if operatorID != userID {
+		if _, err := requireSystemPermission(s.db, operatorID, model.PermissionUserManage); err != nil {
+			return nil, err
+		}
+	}
+
+	var assignments []model.UserRole
+	if err := activeUserRoles(s.db).Preload("Role").
+		Where("user_roles.user_id = ?", userID).
+		Order("user_roles.id DESC").Find(&assignments).Error; err != nil {
+		return nil, fmt.Errorf("查询用户角色失败: %w", err)
+	}
+	return assignments, nil
+}
+
+// AssignRole 在指定范围内授予角色，不能授予级别高于自己的角色或包含自己没有的权限的角色，也不能授予自己
+// 同一用户在同一范围内重复授予同一角色时更新过期时间
+func (s *RoleService) AssignRole(operatorID uint, req *AssignRoleRequest, ip string) (*model.UserRole, error) {
+	if operatorID == req.UserID {
+		return nil, ErrForbidden
+	}
+	scope, err := normalizeScope(req.ScopeType, req.ScopeID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidParam
+	}
+
+	var assignment *model.UserRole
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		operator, err := getUser(tx, operatorID)
+		if err != nil {
+			return err
+		}
+		role, err := getRole(tx, req.RoleID)
+		if err != nil {
+			return err
+		}
+		target, err := getUser(tx, req.UserID)
+		if err != nil {
+			return err
+		}
+		if err := validateScope(tx, scope); err != nil {
+			return err
+		}
+		if err := requireRoleGranter(tx, operator, role, scope); err != nil {
+			return err
+		}
+
+		if assignment, err = saveUserRole(tx, operator.ID, req, scope); err != nil {
+			return err
+		}
+		return recordAdminAction(tx, operator, target, &model.OperationLog{
+			Type:        model.LogTypeSecurity,
+			Action:      model.ActionPermissionGrant,
+			Title:       "授予角色",
+			Description: fmt.Sprintf("角色: %s, 范围: %s", role.Name, scopeLabel(scope)),
+			IPAddress:   ip,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// RevokeRole 撤销角色授予，撤销人同样受级别限制
+func (s *RoleService) RevokeRole(operatorID, assignmentID uint, ip string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var assignment model.UserRole
+		err := tx.Preload("Role").Preload("User").First(&assignment, assignmentID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleAssignmentNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("查询角色授予失败: %w", err)
+		}
+		operator, err := getUser(tx, operatorID)
+		if err != nil {
+			return err
+		}
+		scope := RoleScope{Type: assignment.ScopeType, ID: assignment.ScopeID}
+		if err := requireRoleGranter(tx, operator, &assignment.Role, scope); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&assignment).Error; err != nil {
+			return fmt.Errorf("撤销角色失败: %w", err)
+		}
+		return recordAdminAction(tx, operator, &assignment.User, &model.OperationLog{
+			Type:        model.LogTypeSecurity,
+			Action:      model.ActionPermissionRevoke,
+			Title:       "撤销角色",
+			Description: fmt.Sprintf("角色: %s, 范围: %s", assignment.Role.Name, scopeLabel(scope)),
+			IPAddress:   ip,
+		})
+	})
+}
+
+// requireRoleGranter 校验操作人可以在该范围内授予或撤销角色
+// 系统管理员不受限制；其他人需要拥有该范围的管理权，角色级别不能高于自己在该范围内的级别，
+// 且自己拥有角色包含的全部权限
+func requireRoleGranter(tx *gorm.DB, operator *model.User, role *model.Role, scope RoleScope) error {
+	if operator.IsAdmin() && operator.IsActive() {
+		return nil
+	}
+	if err := requireScopeManager(tx, operator, scope); err != nil {
+		return err
+	}
+
+	scopes, err := expandScope(tx, scope)
+	if err != nil {
+		return err
+	}
+	held, err := userScopeRoles(tx, operator.ID, scopes)
+	if err != nil {
+		return err
+	}
+	// 没有任何角色的管理者视为基础级别0
+	level, _ := maxLevel(held)
+	if role.Level > level {
+		return ErrRoleLevelTooHigh
+	}
+	return requireRoleGrantable(tx, operator, role, scope)
+}
+
+// requireRoleGrantable 校验操作人自己拥有角色包含的全部权限，防止通过角色授予超出自身的权限
+// 范围内的资源权限按操作人在该范围内的权限校验，其他权限按全局权限校验
+func requireRoleGrantable(tx *gorm.DB, operator *model.User, role *model.Role, scope RoleScope) error {
+	permissions, err := role.ParsePermissions()
+	if err != nil {
+		return ErrInvalidParam
+	}
+	var global []TemplatePermissionItem
+	for _, permission := range permissions {
+		allowed, local, err := hasScopePermission(tx, operator.ID, scope, permission.ResourceType, permission.Action)
+		if err != nil {
+			return err
+		}
+		if !local {
+			global = append(global, TemplatePermissionItem{
+				ResourceType: permission.ResourceType, Action: permission.Action, Allowed: true,
+			})
+			continue
+		}
+		if !allowed {
+			return ErrForbidden
+		}
+	}
+	return requireGrantable(tx, operator, global)
+}
+
+// hasScopePermission 检查用户在团队或文件夹范围内是否拥有该范围内资源的权限，local为false表示权限不属于该范围
+// 团队管理员拥有团队上的全部权限，文件夹所有者拥有文件夹子树上的全部权限，其他人检查该范围内的角色
+func hasScopePermission(tx *gorm.DB, userID uint, scope RoleScope, resourceType model.ResourceType,
+	action model.PermissionAction) (allowed, local bool, err error) {
+	switch {
+	case scope.Type == model.ResourceTypeTeam && resourceType == model.ResourceTypeTeam:
+		allowed, err = hasTeamPermission(tx, *scope.ID, userID, action)
+		return allowed, true, err
+	case scope.Type == model.ResourceTypeFolder &&
+		(resourceType == model.ResourceTypeFile || resourceType == model.ResourceTypeFolder):
+		folder, err := getFolder(tx, *scope.ID)
+		if err != nil {
+			return false, true, err
+		}
+		if folder.OwnerID == userID {
+			return true, true, nil
+		}
+		scopes, err := expandScope(tx, scope)
+		if err != nil {
+			return false, true, err
+		}
+		allowed, err = hasRolePermission(tx, userID, scopes, resourceType, action)
+		return allowed, true, err
+	default:
+		return false, false, nil
+	}
+}
+
+// requireScopeManager 校验操作人对范围拥有管理权：全局需要用户管理权限，团队需要团队管理员，文件夹需要所有者
+func requireScopeManager(tx *gorm.DB, operator *model.User, scope RoleScope) error {
+	allowed, err := hasSystemPermission(tx, operator, model.PermissionUserManage)
+	if err != nil || allowed {
+		return err
+	}
+
+	switch scope.Type {
+	case model.ResourceTypeTeam:
+		return requireTeamManager(tx, *scope.ID, operator.ID)
+	case model.ResourceTypeFolder:
+		folder, err := getFolder(tx, *scope.ID)
+		if err != nil {
+			return err
+		}
+		if folder.OwnerID != operator.ID {
+			return ErrForbidden
+		}
+		return nil
+	default:
+		return ErrForbidden
+	}
+}
+
+// saveUserRole 创建或续期角色授予
+func saveUserRole(tx *gorm.DB, granterID uint, req *AssignRoleRequest, scope RoleScope) (*model.UserRole, error) {
+	var assignment model.UserRole
+	err := scopeQuery(tx.Where("user_id = ? AND role_id = ?", req.UserID, req.RoleID), "", []RoleScope{scope}).
+		First(&assignment).Error
+	if err == nil {
+		if err := tx.Model(&assignment).Updates(map[string]interface{}{
+			"expires_at":         req.ExpiresAt,
+			"expiry_notified_at": nil,
+			"granted_by":         granterID,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("更新角色授予失败: %w", err)
+		}
+		return &assignment, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("查询角色授予失败: %w", err)
+	}
+
+	assignment = model.UserRole{
+		UserID:    req.UserID,
+		RoleID:    req.RoleID,
+		ScopeType: scope.Type,
+		ScopeID:   scope.ID,
+		GrantedBy: &granterID,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := tx.Create(&assignment).Error; err != nil {
+		return nil, fmt.Errorf("授予角色失败: %w", err)
+	}
+	return &assignment, nil
+}
+
+// hasRolePermission 检查用户在给定范围内的角色是否包含权限
+// 角色按级别继承：持有的角色之外，还拥有所有级别低于所持最高级别的角色的权限
+func hasRolePermission(tx *gorm.DB, userID uint, scopes []RoleScope, resourceType model.ResourceType, action model.PermissionAction) (bool, error) {
+	held, err := userScopeRoles(tx, userID, scopes)
+	if err != nil {
+		return false, err
+	}
+	level, found := maxLevel(held)
+	if !found {
+		return false, nil
+	}
+
+	var lower []model.Role
+	if err := tx.Where("level < ?", level).Find(&lower).Error; err != nil {
+		return false, fmt.Errorf("查询角色失败: %w", err)
+	}
+	return rolesGrant(effectiveRoles(held, lower), resourceType, action), nil
+}
+
+// effectiveRoles 持有的角色加上级别低于所持最高级别的角色，同级别的其他角色不被继承
+func effectiveRoles(held, candidates []model.Role) []model.Role {
+	level, found := maxLevel(held)
+	if !found {
+		return nil
+	}
+	roles := append([]model.Role{}, held...)
+	for _, role := range candidates {
+		if role.Level < level {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// maxLevel 角色列表中的最高级别，列表为空时found为false
+func maxLevel(roles []model.Role) (level int, found bool) {
+	level = math.MinInt
+	for _, role := range roles {
+		if role.Level > level {
+			level = role.Level
+		}
+	}
+	if len(roles) == 0 {
+		return 0, false
+	}
+	return level, true
+}
+
+// rolesGrant 检查角色列表中是否有角色包含权限，权限配置无法解析的角色被忽略
+func rolesGrant(roles []model.Role, resourceType model.ResourceType, action model.PermissionAction) bool {
+	for i := range roles {
+		permissions, err := roles[i].ParsePermissions()
+		if err != nil {
+			continue
+		}
+		for _, permission := range permissions {
+			if permission.ResourceType == resourceType && permission.Action == action {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// userScopeRoles 获取用户在给定范围内生效的角色，包括所在团队被授予的团队角色
+// 团队范围的角色只对团队的活跃成员生效
+func userScopeRoles(tx *gorm.DB, userID uint, scopes []RoleScope) ([]model.Role, error) {
+	var roleIDs []uint
+	if err := scopeQuery(activeUserRoles(tx), "user_roles.", scopes).
+		Where("user_roles.user_id = ?", userID).
+		Where("user_roles.scope_type <> ? OR EXISTS (SELECT 1 FROM team_members WHERE team_members.team_id = user_roles.scope_id "+
+			"AND team_members.user_id = user_roles.user_id AND team_members.status = ?)",
+			model.ResourceTypeTeam, model.TeamMemberStatusActive).
+		Pluck("user_roles.role_id", &roleIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询用户角色失败: %w", err)
+	}
+
+	if teamIDs := scopeIDs(scopes, model.ResourceTypeTeam); len(teamIDs) > 0 {
+		var teamRoleIDs []uint
+		if err := tx.Model(&model.TeamRole{}).
+			Joins("JOIN team_members ON team_members.team_id = team_roles.team_id").
+			Where("team_roles.team_id IN ? AND team_members.user_id = ? AND team_members.status = ?",
+				teamIDs, userID, model.TeamMemberStatusActive).
+			Pluck("team_roles.role_id", &teamRoleIDs).Error; err != nil {
+			return nil, fmt.Errorf("查询团队角色失败: %w", err)
+		}
+		roleIDs = append(roleIDs, teamRoleIDs...)
+	}
+
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+	var roles []model.Role
+	if err := tx.Where("id IN ?", roleIDs).Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	return roles, nil
+}
+
+// activeUserRoles 未过期的角色授予查询
+func activeUserRoles(tx *gorm.DB) *gorm.DB {
+	return tx.Model(&model.UserRole{}).
+		Joins("JOIN roles ON roles.id = user_roles.role_id AND roles.deleted_at IS NULL").
+		Where("user_roles.expires_at IS NULL OR user_roles.expires_at > ?", time.Now())
+}
+
+// scopeQuery 限定查询在任一范围内，prefix为列名的表前缀
+func scopeQuery(db *gorm.DB, prefix string, scopes []RoleScope) *gorm.DB {
+	conditions := make([]string, 0, len(scopes))
+	args := make([]interface{}, 0, len(scopes)*2)
+	for _, scope := range scopes {
+		if scope.ID == nil {
+			conditions = append(conditions, "("+prefix+"scope_type = ? AND "+prefix+"scope_id IS NULL)")
+			args = append(args, scope.Type)
+			continue
+		}
+		conditions = append(conditions, "("+prefix+"scope_type = ? AND "+prefix+"scope_id = ?)")
+		args = append(args, scope.Type, *scope.ID)
+	}
+	return db.Where(strings.Join(conditions, " OR "), args...)
+}
+
+// expandScope 展开范围：总是包含全局范围，文件夹范围包含其所有祖先文件夹
+func expandScope(tx *gorm.DB, scope RoleScope) ([]RoleScope, error) {
+	scopes := []RoleScope{globalScope}
+	switch scope.Type {
+	case model.ResourceTypeTeam:
+		scopes = append(scopes, scope)
+	case model.ResourceTypeFolder:
+		folders, err := folderScopes(tx, scope.ID)
+		if err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, folders...)
+	}
+	return scopes, nil
+}
+
+// folderScopes 获取从folderID开始向上的所有文件夹范围
+func folderScopes(tx *gorm.DB, folderID *uint) ([]RoleScope, error) {
+	var scopes []RoleScope
+	for depth := 0; folderID != nil && depth < maxFolderDepth; depth++ {
+		var folder model.File
+		if err := tx.Select("id, parent_id").First(&folder, *folderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				break
+			}
+			return nil, fmt.Errorf("查询文件夹失败: %w", err)
+		}
+		id := folder.ID
+		scopes = append(scopes, RoleScope{Type: model.ResourceTypeFolder, ID: &id})
+		folderID = folder.ParentID
+	}
+	return scopes, nil
+}
+
+// scopeIDs 提取指定类型的范围ID
+func scopeIDs(scopes []RoleScope, scopeType model.ResourceType) []uint {
+	var ids []uint
+	for _, scope := range scopes {
+		if scope.Type == scopeType && scope.ID != nil {
+			ids = append(ids, *scope.ID)
+		}
+	}
+	return ids
+}
+
+// normalizeScope 校验并规范化范围，全局范围不能带ID，团队和文件夹范围必须带ID
+func normalizeScope(scopeType model.ResourceType, scopeID *uint) (RoleScope, error) {
+	switch scopeType {
+	case "", model.ResourceTypeSystem:
+		if scopeID != nil {
+			return RoleScope{}, ErrInvalidParam
+		}
+		return globalScope, nil
+	case model.ResourceTypeTeam, model.ResourceTypeFolder:
+		if scopeID == nil || *scopeID == 0 {
+			return RoleScope{}, ErrInvalidParam
+		}
+		return RoleScope{Type: scopeType, ID: scopeID}, nil
+	default:
+		return RoleScope{}, ErrInvalidParam
+	}
+}
+
+// validateScope 校验范围指向的团队或文件夹存在
+func validateScope(tx *gorm.DB, scope RoleScope) error {
+	switch scope.Type {
+	case model.ResourceTypeTeam:
+		_, err := getTeam(tx, *scope.ID)
+		return err
+	case model.ResourceTypeFolder:
+		_, err := getFolder(tx, *scope.ID)
+		return err
+	default:
+		return nil
+	}
+}
+
+// scopeLabel 范围的日志描述
+func scopeLabel(scope RoleScope) string {
+	if scope.ID == nil {
+		return "全局"
+	}
+	return fmt.Sprintf("%s#%d", scope.Type, *scope.ID)
+}
+
+// getRole 获取角色
+func getRole(tx *gorm.DB, roleID uint) (*model.Role, error) {
+	var role model.Role
+	if err := tx.First(&role, roleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	return &role, nil
+}
+
+// getFolder 获取正常状态的文件夹
+func getFolder(tx *gorm.DB, folderID uint) (*model.File, error) {
+	folder, err := getFile(tx, folderID)
+	if err != nil {
+		return nil, err
+	}
+	if !folder.IsFolder() {
+		return nil, ErrInvalidParam
+	}
+	return folder, nil
+}
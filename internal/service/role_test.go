@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"ycg_cloud/internal/model"
+)
+
+// TestRolesGrant 测试角色权限匹配，无法解析的权限配置被忽略
+func TestRolesGrant(t *testing.T) {
+	roles := []model.Role{
+		{Name: "broken", Permissions: "not-json"},
+		{Name: "auditor", Permissions: `[{"resource_type":"system","action":"log_view"}]`},
+	}
+
+	if !rolesGrant(roles, model.ResourceTypeSystem, model.PermissionLogView) {
+		t.Error("角色包含的权限应被授予")
+	}
+	if rolesGrant(roles, model.ResourceTypeSystem, model.PermissionUserManage) {
+		t.Error("角色不包含的权限不应被授予")
+	}
+	if rolesGrant(roles, model.ResourceTypeFile, model.PermissionLogView) {
+		t.Error("资源类型不同的权限不应被授予")
+	}
+}
+
+// TestNormalizeScope 测试角色范围校验
+func TestNormalizeScope(t *testing.T) {
+	id := uint(5)
+	zero := uint(0)
+	tests := []struct {
+		scopeType model.ResourceType
+		scopeID   *uint
+		wantErr   bool
+	}{
+		{"", nil, false},
+		{model.ResourceTypeSystem, nil, false},
+		{model.ResourceTypeSystem, &id, true},
+		{model.ResourceTypeTeam, &id, false},
+		{model.ResourceTypeTeam, nil, true},
+		{model.ResourceTypeFolder, &zero, true},
+		{model.ResourceTypeFile, &id, true},
+	}
+	for _, tt := range tests {
+		scope, err := normalizeScope(tt.scopeType, tt.scopeID)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeScope(%q) err = %v, wantErr %v", tt.scopeType, err, tt.wantErr)
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrInvalidParam) {
+			t.Errorf("normalizeScope(%q) 应返回参数错误, 实际 %v", tt.scopeType, err)
+		}
+		if err == nil && tt.scopeID == nil && scope != globalScope {
+			t.Errorf("normalizeScope(%q) 应返回全局范围, 实际 %+v", tt.scopeType, scope)
+		}
+	}
+}
+
+// TestEffectiveRoles 测试角色按级别继承：低级别角色被继承，同级别的其他角色不被继承
+func TestEffectiveRoles(t *testing.T) {
+	auditor := model.Role{Name: "auditor", Permissions: `[{"resource_type":"system","action":"log_view"}]`}
+	operator := model.Role{Name: "operator", Permissions: `[{"resource_type":"system","action":"system_config"}]`}
+	viewer := model.Role{Name: "viewer", Level: -1, Permissions: `[{"resource_type":"file","action":"read"}]`}
+	candidates := []model.Role{auditor, operator, viewer}
+
+	roles := effectiveRoles([]model.Role{auditor}, candidates)
+	if !rolesGrant(roles, model.ResourceTypeSystem, model.PermissionLogView) {
+		t.Error("持有的角色权限应被授予")
+	}
+	if rolesGrant(roles, model.ResourceTypeSystem, model.PermissionSystemConfig) {
+		t.Error("同级别的其他角色权限不应被继承")
+	}
+	if !rolesGrant(roles, model.ResourceTypeFile, model.PermissionRead) {
+		t.Error("低级别角色的权限应被继承")
+	}
+	if roles := effectiveRoles(nil, candidates); len(roles) != 0 {
+		t.Errorf("没有持有角色时不应继承任何角色, 实际 %d 个", len(roles))
+	}
+}
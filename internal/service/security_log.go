@@ -0,0 +1,56 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 安全事件类型
+const (
+	securityEventLoginFailed        = "login_failed"
+	securityEventAccountLocked      = "account_locked"
+	securityEventResetRequest       = "password_reset_request"
+	securityEventResetRateLimited   = "password_reset_rate_limited"
+	securityEventPasswordReset      = "password_reset"
+	securityEventInvalidResetToken  = "password_reset_invalid_token"
+	securityEventVerificationResend = "email_verification_resend"
+	securityEventLogoutAll          = "logout_all"
+	securityEventForceLogout        = "force_logout"
+
+	// 安全规则检测到的事件
+	securityEventImpossibleTravel  = "impossible_travel"
+	securityEventMassDownload      = "mass_download"
+	securityEventPasswordGuess     = "password_guess"
+	securityEventPrivilegeEscalate = "permission_escalation"
+	securityEventMassDeletion      = "mass_deletion"
+	securityEventIPBlocked         = "ip_blocked"
+	securityEventUserBlocked       = "user_blocked"
+)
+
+// writeSecurityLog 写入安全日志
+func writeSecurityLog(tx *gorm.DB, entry *model.SecurityLog) error {
+	if err := tx.Create(entry).Error; err != nil {
+		return fmt.Errorf("写入安全日志失败: %w", err)
+	}
+	return nil
+}
+
+// countSecurityEvents 统计时间窗口内指定类型的安全事件数，column为用于区分来源的列
+func countSecurityEvents(tx *gorm.DB, eventType, column, value string, since time.Time) (int64, error) {
+	source, err := logSource(tx, &model.SecurityLog{}, since)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	if err := source.
+		Where("event_type = ? AND created_at > ?", eventType, since).
+		Where(column+" = ?", value).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("统计安全事件失败: %w", err)
+	}
+	return count, nil
+}
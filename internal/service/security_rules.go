@@ -0,0 +1,530 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 安全规则相关配置，规则阈值为0表示停用该规则
+const (
+	configKeySecurityRulePrefix = "security.rules."
+	configKeyIPBlockMinutes     = "security.rules.block_minutes" // 自动封禁IP的时长
+	defaultIPBlockMinutes       = 60
+	securityRuleBatchSize       = 500
+	securityRuleLookback        = 10 * time.Minute // 首次运行时回看的时间，重复的事件由冷却期去重
+	securityRuleOverlap         = 5 * time.Minute  // 每次运行重新检查的时间窗口，覆盖在较长事务中写入、晚于更大ID提交的日志
+	ipBlockCacheTTL             = 30 * time.Second
+)
+
+// 自动处置方式
+const (
+	securityBlockNone = "none"
+	securityBlockUser = "user"
+	securityBlockIP   = "ip"
+)
+
+// 规则按用户还是按IP统计
+const (
+	ruleSubjectUser = "user"
+	ruleSubjectIP   = "ip"
+)
+
+// securityRule 安全检测规则，name同时作为写入安全日志的事件类型
+type securityRule struct {
+	name        string
+	title       string
+	subject     string
+	severity    model.LogLevel
+	threatLevel string
+	threatType  string
+	// 默认配置，可通过 security.rules.<name>.threshold/window_minutes/auto_block 覆盖
+	threshold     int
+	windowMinutes int
+	autoBlock     string
+	// matches 判断操作日志是否需要经过该规则
+	matches func(entry *model.OperationLog) bool
+	// detect 返回命中说明，未命中时返回空字符串
+	detect func(tx *gorm.DB, settings *ruleSettings, entry *model.OperationLog) (string, error)
+}
+
+// ruleSettings 规则的生效配置
+type ruleSettings struct {
+	threshold int
+	window    time.Duration
+	autoBlock string
+}
+
+// securityRules 内置的安全检测规则
+// 规则的输入都是已有处理器产生的操作日志: 登录接口、权限变更、保存会话文件到网盘和撤回消息
+var securityRules = []securityRule{
+	{
+		name: securityEventImpossibleTravel, title: "短时间内异地登录", subject: ruleSubjectUser,
+		severity: model.LogLevelWarn, threatLevel: "high", threatType: "account_takeover",
+		threshold: 1, windowMinutes: 120, autoBlock: securityBlockNone,
+		matches: actionMatcher("success", string(model.ActionLogin)),
+		detect:  detectImpossibleTravel,
+	},
+	{
+		name: securityEventMassDownload, title: "短时间内大量下载", subject: ruleSubjectUser,
+		severity: model.LogLevelWarn, threatLevel: "medium", threatType: "data_exfiltration",
+		threshold: 100, windowMinutes: 10, autoBlock: securityBlockNone,
+		matches: actionMatcher("success", string(model.ActionFileDownload), string(model.ActionFileCopy)),
+		detect:  countDetector("user_id", "success", string(model.ActionFileDownload), string(model.ActionFileCopy)),
+	},
+	{
+		name: securityEventPasswordGuess, title: "密码或提取码多次错误", subject: ruleSubjectIP,
+		severity: model.LogLevelWarn, threatLevel: "medium", threatType: "brute_force",
+		threshold: 10, windowMinutes: 15, autoBlock: securityBlockIP,
+		matches: actionMatcher("failed", string(model.ActionLogin), string(model.ActionShareAccess)),
+		detect:  countDetector("ip_address", "failed", string(model.ActionLogin), string(model.ActionShareAccess)),
+	},
+	{
+		name: securityEventPrivilegeEscalate, title: "可疑的权限提升", subject: ruleSubjectUser,
+		severity: model.LogLevelError, threatLevel: "high", threatType: "privilege_escalation",
+		threshold: 20, windowMinutes: 10, autoBlock: securityBlockNone,
+		matches: actionMatcher("success", string(model.ActionPermissionGrant), string(model.ActionPermissionUpdate)),
+		detect:  detectPermissionEscalation,
+	},
+	{
+		name: securityEventMassDeletion, title: "短时间内大量删除", subject: ruleSubjectUser,
+		severity: model.LogLevelError, threatLevel: "high", threatType: "data_destruction",
+		threshold: 50, windowMinutes: 10, autoBlock: securityBlockNone,
+		matches: actionMatcher("success", string(model.ActionFileDelete), string(model.ActionFolderDelete),
+			string(model.ActionMessageRecall)),
+		detect: countDetector("user_id", "success", string(model.ActionFileDelete), string(model.ActionFolderDelete),
+			string(model.ActionMessageRecall)),
+	},
+}
+
+// SecurityRuleEngine 安全规则引擎，按顺序检查新写入的操作日志，命中规则时写入安全日志并按配置自动处置
+type SecurityRuleEngine struct {
+	db     *gorm.DB
+	cursor uint
+	// checked 重新检查窗口内已检查过的操作日志及其写入时间，重启后丢失时由冷却期去重
+	checked map[uint]time.Time
+}
+
+// NewSecurityRuleEngine 创建安全规则引擎
+func NewSecurityRuleEngine(db *gorm.DB) *SecurityRuleEngine {
+	return &SecurityRuleEngine{db: db, checked: make(map[uint]time.Time)}
+}
+
+// Evaluate 检查上次运行之后写入的操作日志，单条规则出错只记录日志，不影响其他规则
+// 审计日志可能在较长的事务中写入，提交时ID更大的日志已被检查过，因此每次运行还会按写入时间
+// 重新检查最近一段时间内ID不超过游标、尚未检查过的日志
+func (e *SecurityRuleEngine) Evaluate(ctx context.Context) error {
+	if e.cursor == 0 {
+		if err := e.initCursor(ctx); err != nil {
+			return err
+		}
+	}
+	settings := e.loadSettings()
+	since := time.Now().Add(-securityRuleOverlap)
+
+	// 滚动后当前表从更大的ID开始，未检查的日志可能已滚动到最近的历史表中
+	source, err := logSource(e.db.WithContext(ctx), &model.OperationLog{}, time.Now().Add(-securityRuleLookback))
+	if err != nil {
+		return err
+	}
+	if err := e.evaluateBatches(ctx, source.Where("id <= ? AND created_at >= ?", e.cursor, since), 0, settings); err != nil {
+		return err
+	}
+	if err := e.evaluateBatches(ctx, source, e.cursor, settings); err != nil {
+		return err
+	}
+
+	for id, createdAt := range e.checked {
+		if createdAt.Before(since) {
+			delete(e.checked, id)
+		}
+	}
+	return nil
+}
+
+// evaluateBatches 按ID顺序分批检查query中ID大于after且尚未检查过的日志，并推进游标
+func (e *SecurityRuleEngine) evaluateBatches(ctx context.Context, query *gorm.DB, after uint, settings map[string]*ruleSettings) error {
+	query = query.Session(&gorm.Session{})
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var entries []model.OperationLog
+		if err := query.Where("id > ?", after).
+			Order("id ASC").Limit(securityRuleBatchSize).Find(&entries).Error; err != nil {
+			return fmt.Errorf("查询操作日志失败: %w", err)
+		}
+		for i := range entries {
+			after = entries[i].ID
+			if _, ok := e.checked[after]; ok {
+				continue
+			}
+			e.evaluateEntry(ctx, &entries[i], settings)
+			e.checked[after] = entries[i].CreatedAt
+			e.cursor = max(e.cursor, after)
+		}
+		if len(entries) < securityRuleBatchSize {
+			return nil
+		}
+	}
+}
+
+// initCursor 首次运行时从最近一段时间的日志开始检查
+func (e *SecurityRuleEngine) initCursor(ctx context.Context) error {
+	since := time.Now().Add(-securityRuleLookback)
+	source, err := logSource(e.db.WithContext(ctx), &model.OperationLog{}, since)
+	if err != nil {
+		return err
+	}
+	var cursor *uint
+	if err := source.
+		Where("created_at < ?", since).
+		Select("MAX(id)").Scan(&cursor).Error; err != nil {
+		return fmt.Errorf("初始化安全规则游标失败: %w", err)
+	}
+	if cursor != nil {
+		e.cursor = *cursor
+	}
+	return nil
+}
+
+// loadSettings 读取各规则的生效配置
+func (e *SecurityRuleEngine) loadSettings() map[string]*ruleSettings {
+	settings := make(map[string]*ruleSettings, len(securityRules))
+	for i := range securityRules {
+		rule := &securityRules[i]
+		prefix := configKeySecurityRulePrefix + rule.name + "."
+		settings[rule.name] = &ruleSettings{
+			threshold: getConfigInt(e.db, prefix+"threshold", rule.threshold),
+			window:    time.Duration(getConfigInt(e.db, prefix+"window_minutes", rule.windowMinutes)) * time.Minute,
+			autoBlock: strings.ToLower(getConfigValue(e.db, prefix+"auto_block", rule.autoBlock)),
+		}
+	}
+	return settings
+}
+
+// evaluateEntry 用全部匹配的规则检查一条操作日志
+func (e *SecurityRuleEngine) evaluateEntry(ctx context.Context, entry *model.OperationLog, settings map[string]*ruleSettings) {
+	for _, rule := range matchingRules(entry, settings) {
+		if err := e.applyRule(ctx, rule, settings[rule.name], entry); err != nil {
+			log.Printf("安全规则 %s 处理操作日志 %d 失败: %v", rule.name, entry.ID, err)
+		}
+	}
+}
+
+// matchingRules 返回需要检查该操作日志的已启用规则
+func matchingRules(entry *model.OperationLog, settings map[string]*ruleSettings) []*securityRule {
+	var rules []*securityRule
+	for i := range securityRules {
+		rule := &securityRules[i]
+		current := settings[rule.name]
+		if current.threshold <= 0 || current.window <= 0 || !rule.matches(entry) || ruleSubject(rule, entry) == "" {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// applyRule 检测并写入安全事件，同一对象在统计窗口内只告警一次
+func (e *SecurityRuleEngine) applyRule(ctx context.Context, rule *securityRule, settings *ruleSettings, entry *model.OperationLog) error {
+	return e.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		detail, err := rule.detect(tx, settings, entry)
+		if err != nil || detail == "" {
+			return err
+		}
+
+		column, subject := "source_ip", ruleSubject(rule, entry)
+		if rule.subject == ruleSubjectUser {
+			column = "user_id"
+		}
+		recent, err := countSecurityEvents(tx, rule.name, column, subject, time.Now().Add(-settings.window))
+		if err != nil || recent > 0 {
+			return err
+		}
+
+		event := newRuleEvent(rule, entry, detail)
+		if err := writeSecurityLog(tx, event); err != nil {
+			return err
+		}
+		return autoBlock(tx, settings.autoBlock, event)
+	})
+}
+
+// newRuleEvent 根据命中的规则和触发的操作日志生成安全事件
+func newRuleEvent(rule *securityRule, entry *model.OperationLog, detail string) *model.SecurityLog {
+	return &model.SecurityLog{
+		UserID:      entry.UserID,
+		Username:    entry.Username,
+		EventType:   rule.name,
+		Severity:    rule.severity,
+		Status:      "open",
+		Title:       rule.title,
+		Description: detail,
+		Details:     fmt.Sprintf("operation_log_id=%d action=%s", entry.ID, entry.Action),
+		ThreatLevel: rule.threatLevel,
+		ThreatType:  rule.threatType,
+		SourceIP:    entry.IPAddress,
+		Country:     entry.Country,
+		Region:      entry.Region,
+		City:        entry.City,
+	}
+}
+
+// ruleSubject 规则统计的对象，缺少用户或IP时返回空字符串
+func ruleSubject(rule *securityRule, entry *model.OperationLog) string {
+	if rule.subject == ruleSubjectIP {
+		return entry.IPAddress
+	}
+	if entry.UserID == nil {
+		return ""
+	}
+	return fmt.Sprint(*entry.UserID)
+}
+
+// autoBlock 按配置停用用户或封禁IP，并把安全事件标记为已处置
+func autoBlock(tx *gorm.DB, mode string, event *model.SecurityLog) error {
+	switch mode {
+	case securityBlockUser:
+		if event.UserID == nil {
+			return nil
+		}
+		blocked, err := suspendUser(tx, *event.UserID, event)
+		if err != nil || !blocked {
+			return err
+		}
+	case securityBlockIP:
+		if event.SourceIP == "" {
+			return nil
+		}
+		if err := writeSecurityLog(tx, &model.SecurityLog{
+			EventType:   securityEventIPBlocked,
+			Severity:    model.LogLevelWarn,
+			Status:      "open",
+			Title:       "自动封禁IP",
+			Description: fmt.Sprintf("触发规则: %s", event.EventType),
+			ThreatLevel: event.ThreatLevel,
+			ThreatType:  event.ThreatType,
+			SourceIP:    event.SourceIP,
+			BlockedFlag: true,
+		}); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	if err := tx.Model(event).Update("blocked_flag", true).Error; err != nil {
+		return fmt.Errorf("更新安全事件失败: %w", err)
+	}
+	return nil
+}
+
+// suspendUser 停用触发规则的用户并使其会话失效，管理员不自动停用以免系统无人可管
+func suspendUser(tx *gorm.DB, userID uint, event *model.SecurityLog) (bool, error) {
+	var user model.User
+	if err := tx.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if user.IsAdmin() || user.Status != model.UserStatusActive {
+		return false, nil
+	}
+
+	if err := tx.Model(&user).Update("status", model.UserStatusSuspended).Error; err != nil {
+		return false, fmt.Errorf("停用用户失败: %w", err)
+	}
+	if err := revokeAllSessions(tx, user.ID, model.SessionRevokeByAdmin); err != nil {
+		return false, err
+	}
+	if err := writeSecurityLog(tx, &model.SecurityLog{
+		UserID:      &user.ID,
+		Username:    user.Username,
+		EventType:   securityEventUserBlocked,
+		Severity:    model.LogLevelWarn,
+		Status:      "open",
+		Title:       "自动停用用户",
+		Description: fmt.Sprintf("触发规则: %s", event.EventType),
+		ThreatLevel: event.ThreatLevel,
+		ThreatType:  event.ThreatType,
+		SourceIP:    event.SourceIP,
+		BlockedFlag: true,
+	}); err != nil {
+		return false, err
+	}
+	return true, appendAuditLog(tx, &model.OperationLog{
+		Username:     systemOperatorName,
+		Type:         model.LogTypeSecurity,
+		Level:        model.LogLevelWarn,
+		Action:       model.ActionAdminUserBlock,
+		Module:       "security",
+		Title:        "安全规则自动停用用户",
+		Description:  fmt.Sprintf("触发规则: %s, %s", event.EventType, event.Description),
+		ResourceType: "user",
+		ResourceID:   &user.ID,
+		ResourceName: user.Username,
+		IPAddress:    event.SourceIP,
+	})
+}
+
+// actionMatcher 按操作类型和操作状态匹配操作日志
+func actionMatcher(status string, actions ...string) func(entry *model.OperationLog) bool {
+	return func(entry *model.OperationLog) bool {
+		return entry.Status == status && containsString(actions, string(entry.Action))
+	}
+}
+
+// containsString 检查切片中是否包含指定字符串
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// countDetector 统计窗口内同一用户或IP的同类操作次数，达到阈值时命中
+func countDetector(column, status string, actions ...string) func(*gorm.DB, *ruleSettings, *model.OperationLog) (string, error) {
+	return func(tx *gorm.DB, settings *ruleSettings, entry *model.OperationLog) (string, error) {
+		value := interface{}(entry.IPAddress)
+		if column == "user_id" {
+			value = *entry.UserID
+		}
+		count, err := countOperations(tx, column, value, status, actions, entry.CreatedAt.Add(-settings.window), entry.ID)
+		if err != nil || count < int64(settings.threshold) {
+			return "", err
+		}
+		return fmt.Sprintf("%d 分钟内 %d 次 %s", int(settings.window.Minutes()), count, strings.Join(actions, "/")), nil
+	}
+}
+
+// countOperations 统计时间窗口内截至当前日志的同类操作数
+func countOperations(tx *gorm.DB, column string, value interface{}, status string, actions []string,
+	since time.Time, untilID uint) (int64, error) {
+	source, err := logSource(tx, &model.OperationLog{}, since)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	if err := source.
+		Where(column+" = ? AND status = ? AND action IN ?", value, status, actions).
+		Where("created_at >= ? AND id <= ?", since, untilID).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("统计操作日志失败: %w", err)
+	}
+	return count, nil
+}
+
+// detectImpossibleTravel 同一用户在窗口内先后从不同国家登录
+// 操作日志不含地理位置，位置取自登录时创建的会话，命中时补充到操作日志上以便写入安全事件
+func detectImpossibleTravel(tx *gorm.DB, settings *ruleSettings, entry *model.OperationLog) (string, error) {
+	var current model.UserSession
+	err := tx.Where("user_id = ? AND created_at <= ?", *entry.UserID, entry.CreatedAt).Order("id DESC").First(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("查询登录会话失败: %w", err)
+	}
+	if !isKnownCountry(current.Country) {
+		return "", nil
+	}
+
+	var previous model.UserSession
+	err = tx.Where("user_id = ? AND id < ? AND created_at >= ?", current.UserID, current.ID,
+		current.CreatedAt.Add(-settings.window)).
+		Where("country NOT IN ?", []string{"", localNetworkLocation, current.Country}).
+		Order("id DESC").First(&previous).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("查询上次登录会话失败: %w", err)
+	}
+	entry.Country, entry.Region, entry.City = current.Country, current.Region, current.City
+	return fmt.Sprintf("%d 分钟内先后从 %s(%s) 和 %s(%s) 登录", int(current.CreatedAt.Sub(previous.CreatedAt).Minutes()),
+		previous.Country, previous.IPAddress, current.Country, current.IPAddress), nil
+}
+
+// isKnownCountry 是否为已解析出的公网地理位置
+func isKnownCountry(country string) bool {
+	return country != "" && country != localNetworkLocation
+}
+
+// detectPermissionEscalation 给自己授权立即命中，否则统计窗口内同一操作人的授权次数
+func detectPermissionEscalation(tx *gorm.DB, settings *ruleSettings, entry *model.OperationLog) (string, error) {
+	if entry.ResourceType == "user" && entry.ResourceID != nil && *entry.ResourceID == *entry.UserID {
+		return fmt.Sprintf("为自己授权: %s", entry.Title), nil
+	}
+	actions := []string{string(model.ActionPermissionGrant), string(model.ActionPermissionUpdate)}
+	count, err := countOperations(tx, "user_id", *entry.UserID, "success", actions,
+		entry.CreatedAt.Add(-settings.window), entry.ID)
+	if err != nil || count < int64(settings.threshold) {
+		return "", err
+	}
+	return fmt.Sprintf("%d 分钟内授权 %d 次", int(settings.window.Minutes()), count), nil
+}
+
+// IPBlocklist 查询被安全规则自动封禁的IP，结果短暂缓存以免每个请求都查库
+type IPBlocklist struct {
+	db *gorm.DB
+
+	mu    sync.Mutex
+	cache map[string]ipBlockCacheEntry
+}
+
+// ipBlockCacheEntry IP封禁状态缓存
+type ipBlockCacheEntry struct {
+	blocked   bool
+	expiresAt time.Time
+}
+
+// NewIPBlocklist 创建IP封禁查询
+func NewIPBlocklist(db *gorm.DB) *IPBlocklist {
+	return &IPBlocklist{db: db, cache: make(map[string]ipBlockCacheEntry)}
+}
+
+// IsBlocked 检查IP是否处于封禁期内，封禁事件被标记为已解决时提前解封
+func (b *IPBlocklist) IsBlocked(ip string) (bool, error) {
+	now := time.Now()
+	b.mu.Lock()
+	cached, ok := b.cache[ip]
+	b.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.blocked, nil
+	}
+
+	minutes := getConfigInt(b.db, configKeyIPBlockMinutes, defaultIPBlockMinutes)
+	since := now.Add(-time.Duration(minutes) * time.Minute)
+	source, err := logSource(b.db, &model.SecurityLog{}, since)
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	if err := source.
+		Where("event_type = ? AND source_ip = ? AND blocked_flag = ? AND resolved_flag = ? AND created_at > ?",
+			securityEventIPBlocked, ip, true, false, since).
+		Count(&count).Error; err != nil {
+		return false, fmt.Errorf("查询IP封禁状态失败: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key, entry := range b.cache {
+		if now.After(entry.expiresAt) {
+			delete(b.cache, key)
+		}
+	}
+	b.cache[ip] = ipBlockCacheEntry{blocked: count > 0, expiresAt: now.Add(ipBlockCacheTTL)}
+	return count > 0, nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recorderStub 记录操作日志中间件投递的日志
+type recorderStub struct {
+	entries []*model.OperationLog
+}
+
+// Record 保存日志
+func (r *recorderStub) Record(entry *model.OperationLog) bool {
+	r.entries = append(r.entries, entry)
+	return true
+}
+
+// TestActionMatcher 测试规则按操作类型和状态匹配
+func TestActionMatcher(t *testing.T) {
+	matches := actionMatcher("success", string(model.ActionFileDelete), string(model.ActionFolderDelete))
+	tests := []struct {
+		entry model.OperationLog
+		want  bool
+	}{
+		{model.OperationLog{Action: model.ActionFileDelete, Status: "success"}, true},
+		{model.OperationLog{Action: model.ActionFolderDelete, Status: "success"}, true},
+		{model.OperationLog{Action: model.ActionFileDelete, Status: "failed"}, false},
+		{model.OperationLog{Action: model.ActionFileDownload, Status: "success"}, false},
+	}
+	for _, tt := range tests {
+		if got := matches(&tt.entry); got != tt.want {
+			t.Errorf("匹配 %s/%s = %v, 期望 %v", tt.entry.Action, tt.entry.Status, got, tt.want)
+		}
+	}
+}
+
+// TestRuleSubject 测试规则统计对象，缺少用户或IP时跳过
+func TestRuleSubject(t *testing.T) {
+	userID := uint(42)
+	byUser := &securityRule{subject: ruleSubjectUser}
+	byIP := &securityRule{subject: ruleSubjectIP}
+
+	if got := ruleSubject(byUser, &model.OperationLog{UserID: &userID, IPAddress: "192.0.2.1"}); got != "42" {
+		t.Errorf("按用户统计的对象 = %q, 期望 42", got)
+	}
+	if got := ruleSubject(byUser, &model.OperationLog{IPAddress: "192.0.2.1"}); got != "" {
+		t.Errorf("缺少用户时统计对象 = %q, 期望为空", got)
+	}
+	if got := ruleSubject(byIP, &model.OperationLog{UserID: &userID, IPAddress: "192.0.2.1"}); got != "192.0.2.1" {
+		t.Errorf("按IP统计的对象 = %q, 期望 192.0.2.1", got)
+	}
+}
+
+// TestSecurityRules 测试内置规则配置完整且事件类型不重复
+func TestSecurityRules(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := range securityRules {
+		rule := &securityRules[i]
+		if seen[rule.name] {
+			t.Errorf("规则 %s 重复", rule.name)
+		}
+		seen[rule.name] = true
+		if rule.matches == nil || rule.detect == nil || rule.threshold <= 0 || rule.windowMinutes <= 0 {
+			t.Errorf("规则 %s 配置不完整", rule.name)
+		}
+		switch rule.autoBlock {
+		case securityBlockNone, securityBlockUser, securityBlockIP:
+		default:
+			t.Errorf("规则 %s 的自动处置方式 %q 无效", rule.name, rule.autoBlock)
+		}
+		if rule.autoBlock == securityBlockUser && rule.subject != ruleSubjectUser {
+			t.Errorf("规则 %s 按IP统计, 不能自动停用用户", rule.name)
+		}
+	}
+}
+
+// TestFailedLoginRuleEvent 测试登录失败产生的操作日志进入密码猜测规则，安全事件的来源IP不受伪造的X-Forwarded-For影响
+func TestFailedLoginRuleEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := &recorderStub{}
+	router := gin.New()
+	if err := router.SetTrustedProxies(nil); err != nil {
+		t.Fatalf("设置可信代理失败: %v", err)
+	}
+	router.Use(middleware.OperationLog(recorder))
+	router.POST("/auth/login", func(ctx *gin.Context) {
+		middleware.RecordOperation(ctx, &model.OperationLog{
+			Type: model.LogTypeAuth, Action: model.ActionLogin, Module: "auth", Title: "用户登录", Username: "alice",
+		})
+		ctx.Status(http.StatusUnauthorized)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+	req.RemoteAddr = "198.51.100.7:40000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if len(recorder.entries) != 1 {
+		t.Fatalf("期望记录 1 条操作日志, 实际 %d", len(recorder.entries))
+	}
+	entry := recorder.entries[0]
+	entry.ID, entry.CreatedAt = 7, time.Now()
+
+	settings := make(map[string]*ruleSettings, len(securityRules))
+	for i := range securityRules {
+		rule := &securityRules[i]
+		settings[rule.name] = &ruleSettings{
+			threshold: rule.threshold,
+			window:    time.Duration(rule.windowMinutes) * time.Minute,
+			autoBlock: rule.autoBlock,
+		}
+	}
+	rules := matchingRules(entry, settings)
+	if len(rules) != 1 || rules[0].name != securityEventPasswordGuess {
+		t.Fatalf("登录失败匹配的规则 = %d 条, 期望只有 %s", len(rules), securityEventPasswordGuess)
+	}
+
+	event := newRuleEvent(rules[0], entry, "15 分钟内 10 次 login")
+	if event.EventType != securityEventPasswordGuess || event.SourceIP != "198.51.100.7" || event.ThreatType != "brute_force" {
+		t.Errorf("安全事件不正确: %+v", event)
+	}
+	if event.Details != "operation_log_id=7 action=login" {
+		t.Errorf("安全事件详情 = %q", event.Details)
+	}
+
+	settings[securityEventPasswordGuess].threshold = 0
+	if rules := matchingRules(entry, settings); len(rules) != 0 {
+		t.Errorf("停用的规则不应匹配")
+	}
+}
+
+// TestRuleProducers 测试已有接口产生的操作日志都有规则检查
+func TestRuleProducers(t *testing.T) {
+	userID := uint(42)
+	tests := []struct {
+		entry model.OperationLog
+		rule  string
+	}{
+		{model.OperationLog{Action: model.ActionLogin, Status: "success"}, securityEventImpossibleTravel},
+		{model.OperationLog{Action: model.ActionFileCopy, Status: "success"}, securityEventMassDownload},
+		{model.OperationLog{Action: model.ActionMessageRecall, Status: "success"}, securityEventMassDeletion},
+		{model.OperationLog{Action: model.ActionPermissionGrant, Status: "success"}, securityEventPrivilegeEscalate},
+	}
+	for _, tt := range tests {
+		entry := tt.entry
+		entry.UserID, entry.IPAddress = &userID, "198.51.100.7"
+		matched := false
+		for i := range securityRules {
+			if securityRules[i].name == tt.rule && securityRules[i].matches(&entry) {
+				matched = true
+			}
+		}
+		if !matched {
+			t.Errorf("操作 %s 没有进入规则 %s", entry.Action, tt.rule)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// 会话相关配置
+const (
+	defaultSessionTTL      = 7 * 24 * time.Hour
+	sessionRetentionPeriod = 30 * 24 * time.Hour
+	localNetworkLocation   = "局域网"
+)
+
+// ClientInfo 发起请求的客户端信息
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// IPLocation IP地址对应的地理位置
+type IPLocation struct {
+	Country string
+	Region  string
+	City    string
+}
+
+// IPLocator IP地理位置解析器，未配置时只识别内网地址
+type IPLocator interface {
+	Locate(ip string) IPLocation
+}
+
+// SessionView 会话列表项，Current标记发起请求的会话
+type SessionView struct {
+	*model.UserSession
+	Current bool `json:"current"`
+}
+
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Refresh 使用刷新令牌换取新的访问令牌，刷新令牌同时轮换
+func (s *AuthService) Refresh(refreshToken string, client *ClientInfo) (*LoginResult, error) {
+	var result *LoginResult
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var session model.UserSession
+		err := tx.Where("refresh_token_hash = ?", hashToken(strings.TrimSpace(refreshToken))).First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTokenRevoked
+		}
+		if err != nil {
+			return fmt.Errorf("查询会话失败: %w", err)
+		}
+		if !session.IsActive(time.Now()) {
+			return ErrTokenRevoked
+		}
+		user, err := getUser(tx, session.UserID)
+		if err != nil {
+			return err
+		}
+		if !user.IsActive() {
+			return ErrTokenRevoked
+		}
+
+		token, err := randomToken()
+		if err != nil {
+			return err
+		}
+		// 条件更新保证同一刷新令牌只能被使用一次
+		update := tx.Model(&model.UserSession{}).
+			Where("id = ? AND refresh_token_hash = ?", session.ID, session.RefreshTokenHash).
+			Updates(map[string]interface{}{
+				"refresh_token_hash": hashToken(token),
+				"last_active_at":     time.Now(),
+				"ip_address":         client.IP,
+			})
+		if update.Error != nil {
+			return fmt.Errorf("轮换刷新令牌失败: %w", update.Error)
+		}
+		if update.RowsAffected == 0 {
+			return ErrTokenRevoked
+		}
+
+		result, err = buildLoginResult(user, &session, token)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Logout 退出当前会话
+func (s *AuthService) Logout(userID, sessionID uint) error {
+	return revokeSessions(s.db.Where("id = ?", sessionID), userID, model.SessionRevokeLogout)
+}
+
+// ListSessions 列出用户的有效会话，按最后活跃时间倒序
+func (s *AuthService) ListSessions(userID, currentSessionID uint) ([]SessionView, error) {
+	var sessions []*model.UserSession
+	if err := s.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_active_at DESC").Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("查询会话失败: %w", err)
+	}
+
+	views := make([]SessionView, 0, len(sessions))
+	for _, session := range sessions {
+		views = append(views, SessionView{UserSession: session, Current: session.ID == currentSessionID})
+	}
+	return views, nil
+}
+
+// RevokeSession 注销用户的指定会话
+func (s *AuthService) RevokeSession(userID, sessionID uint) error {
+	var session model.UserSession
+	err := s.db.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("查询会话失败: %w", err)
+	}
+	return revokeSessions(s.db.Where("id = ?", session.ID), userID, model.SessionRevokeByUser)
+}
+
+// LogoutAll 退出全部设备，已签发的访问令牌同时失效
+func (s *AuthService) LogoutAll(userID uint, ip string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := getUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := revokeAllSessions(tx, userID, model.SessionRevokeLogoutAll); err != nil {
+			return err
+		}
+		return writeSecurityLog(tx, &model.SecurityLog{
+			UserID:    &user.ID,
+			Username:  user.Username,
+			EventType: securityEventLogoutAll,
+			Severity:  model.LogLevelInfo,
+			Status:    "resolved",
+			Title:     "退出全部设备",
+			SourceIP:  ip,
+		})
+	})
+}
+
+// ForceLogout 管理员强制用户下线，需要用户管理权限，非管理员不能强制管理员下线
+func (s *AuthService) ForceLogout(operatorID, userID uint, ip string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		operator, err := requireSystemPermission(tx, operatorID, model.PermissionUserManage)
+		if err != nil {
+			return err
+		}
+		user, err := getUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := checkManageTarget(operator, user); err != nil {
+			return err
+		}
+		if err := revokeAllSessions(tx, userID, model.SessionRevokeByAdmin); err != nil {
+			return err
+		}
+
+		if err := writeSecurityLog(tx, &model.SecurityLog{
+			UserID:      &user.ID,
+			Username:    user.Username,
+			EventType:   securityEventForceLogout,
+			Severity:    model.LogLevelWarn,
+			Status:      "resolved",
+			Title:       "管理员强制下线",
+			Description: fmt.Sprintf("操作人: %s", operator.Username),
+			SourceIP:    ip,
+		}); err != nil {
+			return err
+		}
+		if err := appendAuditLog(tx, &model.OperationLog{
+			UserID:        &operator.ID,
+			Username:      operator.Username,
+			Type:          model.LogTypeSecurity,
+			Action:        model.ActionLogout,
+			Module:        "auth",
+			Title:         "强制用户下线",
+			ResourceType:  "user",
+			ResourceID:    &user.ID,
+			ResourceName:  user.Username,
+			IPAddress:     ip,
+			ImportantFlag: true,
+		}); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// PurgeSessions 清理过期或已注销超过保留期的会话
+func (s *AuthService) PurgeSessions(ctx context.Context) error {
+	cutoff := time.Now().Add(-sessionRetentionPeriod)
+	if err := s.db.WithContext(ctx).
+		Where("expires_at < ? OR revoked_at < ?", cutoff, cutoff).
+		Delete(&model.UserSession{}).Error; err != nil {
+		return fmt.Errorf("清理会话失败: %w", err)
+	}
+	return nil
+}
+
+// createSession 创建登录会话并返回登录结果
+func (s *AuthService) createSession(tx *gorm.DB, user *model.User, client *ClientInfo) (*LoginResult, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	agent := utils.ParseUserAgent(client.UserAgent)
+	location := s.locate(client.IP)
+	session := &model.UserSession{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(token),
+		LastActiveAt:     now,
+		ExpiresAt:        now.Add(sessionTTL()),
+		IPAddress:        client.IP,
+		UserAgent:        truncate(client.UserAgent, 500),
+		Country:          location.Country,
+		Region:           location.Region,
+		City:             location.City,
+		Device:           agent.Device,
+		OS:               agent.OS,
+		Browser:          agent.Browser,
+	}
+	if err := tx.Create(session).Error; err != nil {
+		return nil, fmt.Errorf("创建会话失败: %w", err)
+	}
+	return buildLoginResult(user, session, token)
+}
+
+// locate 解析IP地址的地理位置
+func (s *AuthService) locate(ip string) IPLocation {
+	if parsed := net.ParseIP(ip); parsed != nil && (parsed.IsLoopback() || parsed.IsPrivate()) {
+		return IPLocation{Country: localNetworkLocation}
+	}
+	if s.locator == nil {
+		return IPLocation{}
+	}
+	return s.locator.Locate(ip)
+}
+
+// buildLoginResult 为会话签发访问令牌
+func buildLoginResult(user *model.User, session *model.UserSession, refreshToken string) (*LoginResult, error) {
+	accessToken, err := utils.GenerateToken(user.ID, user.Username, string(user.UserType), user.TokenVersion, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+		SessionID:        session.ID,
+		User:             user,
+	}, nil
+}
+
+// revokeAllSessions 注销用户全部会话并提升令牌版本，使已签发的访问令牌立即失效
+func revokeAllSessions(tx *gorm.DB, userID uint, reason string) error {
+	if err := revokeSessions(tx, userID, reason); err != nil {
+		return err
+	}
+	if err := tx.Model(&model.User{}).Where("id = ?", userID).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+		return fmt.Errorf("更新令牌版本失败: %w", err)
+	}
+	return nil
+}
+
+// revokeSessions 注销用户在scope范围内的有效会话
+func revokeSessions(scope *gorm.DB, userID uint, reason string) error {
+	if err := scope.Model(&model.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"revoked_at":    time.Now(),
+			"revoke_reason": reason,
+		}).Error; err != nil {
+		return fmt.Errorf("注销会话失败: %w", err)
+	}
+	return nil
+}
+
+// sessionTTL 刷新令牌有效期，读取JWT配置
+func sessionTTL() time.Duration {
+	if utils.GlobalConfig != nil && utils.GlobalConfig.JWT.RefreshExpireTime > 0 {
+		return utils.GlobalConfig.JWT.RefreshExpireTime
+	}
+	return defaultSessionTTL
+}
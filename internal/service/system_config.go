@@ -0,0 +1,47 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// getConfigValue 读取激活状态的系统配置值，不存在或读取失败时返回默认值
+func getConfigValue(db *gorm.DB, key, defaultValue string) string {
+	var config model.SystemConfig
+	err := db.Where("`key` = ? AND status = ?", key, model.ConfigStatusActive).First(&config).Error
+	if err != nil || config.Value == "" {
+		return defaultValue
+	}
+	return config.Value
+}
+
+// getConfigInt 读取整数类型的系统配置
+func getConfigInt(db *gorm.DB, key string, defaultValue int) int {
+	value, err := strconv.Atoi(strings.TrimSpace(getConfigValue(db, key, "")))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getConfigIntList 读取逗号分隔的整数列表配置
+func getConfigIntList(db *gorm.DB, key string, defaultValue []int) []int {
+	raw := getConfigValue(db, key, "")
+	if raw == "" {
+		return defaultValue
+	}
+
+	var values []int
+	for _, part := range strings.Split(raw, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return defaultValue
+		}
+		values = append(values, value)
+	}
+	return values
+}
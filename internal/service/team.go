@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// TeamService 团队服务
+type TeamService struct {
+	db        *gorm.DB
+	publisher EventPublisher
+	notifier  *NotificationService
+}
+
+// NewTeamService 创建团队服务，notifier用于向申请人和团队管理员发送通知
+func NewTeamService(db *gorm.DB, publisher EventPublisher, notifier *NotificationService) *TeamService {
+	return &TeamService{db: db, publisher: publisher, notifier: notifier}
+}
+
+// PublicTeamQuery 公开团队查询条件
+type PublicTeamQuery struct {
+	Keyword  string
+	Page     int
+	PageSize int
+}
+
+// SearchPublicTeams 搜索公开团队目录
+func (s *TeamService) SearchPublicTeams(query PublicTeamQuery) ([]model.Team, int64, error) {
+	db := s.db.Model(&model.Team{}).
+		Where("is_public = ? AND status = ?", true, model.TeamStatusActive)
+	if keyword := strings.TrimSpace(query.Keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		db = db.Where("name LIKE ? OR description LIKE ?", like, like)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计公开团队失败: %w", err)
+	}
+
+	var teams []model.Team
+	err := db.Order("member_count DESC, id DESC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&teams).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询公开团队失败: %w", err)
+	}
+	return teams, total, nil
+}
+
+// getTeam 获取团队
+func getTeam(tx *gorm.DB, teamID uint) (*model.Team, error) {
+	var team model.Team
+	if err := tx.First(&team, teamID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("查询团队失败: %w", err)
+	}
+	return &team, nil
+}
+
+// getTeamMember 获取团队成员记录，不存在时返回nil
+func getTeamMember(tx *gorm.DB, teamID, userID uint) (*model.TeamMember, error) {
+	var member model.TeamMember
+	err := tx.Where("team_id = ? AND user_id = ?", teamID, userID).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询团队成员失败: %w", err)
+	}
+	return &member, nil
+}
+
+// requireTeamManager 校验操作人是否可以管理团队
+func requireTeamManager(tx *gorm.DB, teamID, operatorID uint) error {
+	allowed, err := hasTeamPermission(tx, teamID, operatorID, model.PermissionTeamManage)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// hasTeamPermission 检查团队的活跃成员是否拥有团队上的权限
+// 团队所有者和管理员拥有全部权限，其他成员检查全局角色、团队范围的角色和团队被授予的角色
+func hasTeamPermission(tx *gorm.DB, teamID, userID uint, action model.PermissionAction) (bool, error) {
+	member, err := getTeamMember(tx, teamID, userID)
+	if err != nil || member == nil || !member.IsActive() {
+		return false, err
+	}
+	if member.CanManageTeam() {
+		return true, nil
+	}
+	scopes, err := expandScope(tx, RoleScope{Type: model.ResourceTypeTeam, ID: &teamID})
+	if err != nil {
+		return false, err
+	}
+	return hasRolePermission(tx, userID, scopes, model.ResourceTypeTeam, action)
+}
+
+// listTeamManagerIDs 获取团队所有管理员的用户ID
+func listTeamManagerIDs(tx *gorm.DB, teamID uint) ([]uint, error) {
+	var userIDs []uint
+	err := tx.Model(&model.TeamMember{}).
+		Where("team_id = ? AND status = ? AND role IN ?", teamID, model.TeamMemberStatusActive,
+			[]string{string(model.TeamMemberRoleOwner), string(model.TeamMemberRoleAdmin)}).
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询团队管理员失败: %w", err)
+	}
+	return userIDs, nil
+}
+
+// addTeamMember 将用户加入团队，曾经离开的成员会被重新激活
+func addTeamMember(tx *gorm.DB, team *model.Team, userID uint, invitedBy *uint) (*model.TeamMember, error) {
+	member, err := getTeamMember(tx, team.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member != nil && member.IsActive() {
+		return nil, ErrAlreadyTeamMember
+	}
+	if team.IsFull() {
+		return nil, ErrTeamFull
+	}
+
+	now := time.Now()
+	if member == nil {
+		member = &model.TeamMember{
+			TeamID:    team.ID,
+			UserID:    userID,
+			Role:      model.TeamMemberRoleMember,
+			Status:    model.TeamMemberStatusActive,
+			InvitedBy: invitedBy,
+			JoinedAt:  &now,
+		}
+		if err := tx.Create(member).Error; err != nil {
+			return nil, fmt.Errorf("创建团队成员失败: %w", err)
+		}
+	} else {
+		err := tx.Model(member).Updates(map[string]interface{}{
+			"role":      model.TeamMemberRoleMember,
+			"status":    model.TeamMemberStatusActive,
+			"joined_at": now,
+			"left_at":   nil,
+		}).Error
+		if err != nil {
+			return nil, fmt.Errorf("重新激活团队成员失败: %w", err)
+		}
+	}
+
+	if err := tx.Model(&model.Team{}).Where("id = ?", team.ID).
+		UpdateColumn("member_count", gorm.Expr("member_count + 1")).Error; err != nil {
+		return nil, fmt.Errorf("更新团队成员数失败: %w", err)
+	}
+	team.MemberCount++
+	return member, nil
+}
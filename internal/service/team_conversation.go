@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+	"ycg_cloud/internal/realtime"
+
+	"gorm.io/gorm"
+)
+
+// CreateTeamRequest 创建团队请求
+type CreateTeamRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+	Avatar      string `json:"avatar" binding:"max=500"`
+	IsPublic    bool   `json:"is_public"`
+	MaxMembers  int    `json:"max_members" binding:"min=0"`
+}
+
+// CreateTeam 创建团队，创建者成为所有者，并同时创建团队会话
+func (s *TeamService) CreateTeam(creatorID uint, req *CreateTeamRequest) (*model.Team, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrInvalidParam
+	}
+
+	team := &model.Team{
+		Name:        name,
+		Description: req.Description,
+		Avatar:      req.Avatar,
+		IsPublic:    req.IsPublic,
+		MaxMembers:  req.MaxMembers,
+		CreatorID:   creatorID,
+		MemberCount: 1,
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := getUser(tx, creatorID); err != nil {
+			return err
+		}
+		if err := tx.Create(team).Error; err != nil {
+			return fmt.Errorf("创建团队失败: %w", err)
+		}
+
+		now := time.Now()
+		owner := &model.TeamMember{
+			TeamID:   team.ID,
+			UserID:   creatorID,
+			Role:     model.TeamMemberRoleOwner,
+			Status:   model.TeamMemberStatusActive,
+			JoinedAt: &now,
+		}
+		if err := tx.Create(owner).Error; err != nil {
+			return fmt.Errorf("创建团队所有者失败: %w", err)
+		}
+		_, err := createTeamConversation(tx, team, creatorID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+// LeaveTeam 成员主动退出团队，所有者不能退出
+func (s *TeamService) LeaveTeam(teamID, userID uint) error {
+	var notice *model.Message
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		team, err := getTeam(tx, teamID)
+		if err != nil {
+			return err
+		}
+		member, err := getActiveTeamMember(tx, teamID, userID)
+		if err != nil {
+			return err
+		}
+		if member.IsOwner() {
+			return ErrTeamOwnerCannotLeave
+		}
+		notice, err = removeTeamMember(tx, team, member, userID, "%s 离开了团队")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.publishNotice(notice)
+	return nil
+}
+
+// RemoveTeamMember 团队管理员移除成员，只有所有者可以移除管理员
+func (s *TeamService) RemoveTeamMember(teamID, operatorID, userID uint) error {
+	if operatorID == userID {
+		return ErrInvalidParam
+	}
+
+	var notice *model.Message
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		team, err := getTeam(tx, teamID)
+		if err != nil {
+			return err
+		}
+		operator, err := getActiveTeamMember(tx, teamID, operatorID)
+		if err != nil {
+			return ErrForbidden
+		}
+		member, err := getActiveTeamMember(tx, teamID, userID)
+		if err != nil {
+			return err
+		}
+		canManage, err := hasTeamPermission(tx, teamID, operatorID, model.PermissionTeamManage)
+		if err != nil {
+			return err
+		}
+		if !canManage || member.IsOwner() || (member.IsAdmin() && !operator.IsOwner()) {
+			return ErrForbidden
+		}
+		notice, err = removeTeamMember(tx, team, member, operatorID, "%s 被移出了团队")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.publishNotice(notice)
+	return nil
+}
+
+// ArchiveTeam 归档团队，团队会话同时归档为只读
+func (s *TeamService) ArchiveTeam(teamID, operatorID uint) error {
+	var notice *model.Message
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		team, err := getTeam(tx, teamID)
+		if err != nil {
+			return err
+		}
+		operator, err := getActiveTeamMember(tx, teamID, operatorID)
+		if err != nil || !operator.IsOwner() {
+			return ErrForbidden
+		}
+		if team.Status == model.TeamStatusArchived {
+			return ErrTeamInactive
+		}
+
+		if err := tx.Model(team).Update("status", model.TeamStatusArchived).Error; err != nil {
+			return fmt.Errorf("归档团队失败: %w", err)
+		}
+		conversation, err := getTeamConversation(tx, teamID)
+		if err != nil || conversation == nil {
+			return err
+		}
+		notice, err = postSystemMessage(tx, conversation, operatorID, "团队已归档，团队会话已转为只读")
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(conversation).Update("status", model.ConversationStatusArchived).Error; err != nil {
+			return fmt.Errorf("归档团队会话失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.publishNotice(notice)
+	return nil
+}
+
+// publishNotice 推送团队会话中的系统通知
+func (s *TeamService) publishNotice(notice *model.Message) {
+	if notice != nil {
+		publishMessageEvent(context.Background(), s.publisher, realtime.EventMessageNew, notice, notice.SenderID)
+	}
+}
+
+// getActiveTeamMember 获取在队成员，不存在或已离开时返回ErrNotTeamMember
+func getActiveTeamMember(tx *gorm.DB, teamID, userID uint) (*model.TeamMember, error) {
+	member, err := getTeamMember(tx, teamID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil || !member.IsActive() {
+		return nil, ErrNotTeamMember
+	}
+	return member, nil
+}
+
+// removeTeamMember 将成员标记为已离开并同步移出团队会话，format为包含成员名称的通知模板
+func removeTeamMember(tx *gorm.DB, team *model.Team, member *model.TeamMember, operatorID uint, format string) (*model.Message, error) {
+	if err := tx.Model(member).Updates(map[string]interface{}{
+		"status":  model.TeamMemberStatusLeft,
+		"left_at": time.Now(),
+	}).Error; err != nil {
+		return nil, fmt.Errorf("更新团队成员失败: %w", err)
+	}
+	if err := tx.Model(&model.Team{}).Where("id = ? AND member_count > 0", team.ID).
+		UpdateColumn("member_count", gorm.Expr("member_count - 1")).Error; err != nil {
+		return nil, fmt.Errorf("更新团队成员数失败: %w", err)
+	}
+	return leaveTeamConversation(tx, team.ID, member.UserID, operatorID, format)
+}
+
+// getTeamConversation 获取团队会话，尚未创建时返回nil
+func getTeamConversation(tx *gorm.DB, teamID uint) (*model.Conversation, error) {
+	var conversation model.Conversation
+	err := tx.Where("type = ? AND team_id = ?", model.ConversationTypeTeam, teamID).First(&conversation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询团队会话失败: %w", err)
+	}
+	return &conversation, nil
+}
+
+// joinTeamConversation 将新成员加入团队会话并发布加入通知，团队尚无会话时补建
+func joinTeamConversation(tx *gorm.DB, team *model.Team, userID uint) (*model.Message, error) {
+	conversation, err := getTeamConversation(tx, team.ID)
+	if err != nil {
+		return nil, err
+	}
+	if conversation == nil {
+		if conversation, err = createTeamConversation(tx, team, team.CreatorID); err != nil {
+			return nil, err
+		}
+	} else if err := ensureConversationMember(tx, conversation, userID); err != nil {
+		return nil, err
+	}
+
+	name, err := userDisplayName(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return postSystemMessage(tx, conversation, userID, fmt.Sprintf("%s 加入了团队", name))
+}
+
+// leaveTeamConversation 将成员移出团队会话并发布离开通知
+func leaveTeamConversation(tx *gorm.DB, teamID, userID, operatorID uint, format string) (*model.Message, error) {
+	conversation, err := getTeamConversation(tx, teamID)
+	if err != nil || conversation == nil {
+		return nil, err
+	}
+
+	member, err := getConversationMember(tx, conversation.ID, userID)
+	if err != nil && !errors.Is(err, ErrNotConversationMember) {
+		return nil, err
+	}
+	if member != nil {
+		if err := removeConversationMember(tx, member); err != nil {
+			return nil, err
+		}
+	}
+
+	name, err := userDisplayName(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return postSystemMessage(tx, conversation, operatorID, fmt.Sprintf(format, name))
+}
+
+// ensureConversationMember 确保用户是会话成员，曾经退出的成员会被恢复
+func ensureConversationMember(tx *gorm.DB, conversation *model.Conversation, userID uint) error {
+	var member model.ConversationMember
+	err := tx.Unscoped().Where("conversation_id = ? AND user_id = ?", conversation.ID, userID).
+		Order("id DESC").First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return addConversationMembers(tx, conversation, []uint{userID}, conversation.CreatorID)
+	}
+	if err != nil {
+		return fmt.Errorf("查询会话成员失败: %w", err)
+	}
+	if !member.DeletedAt.Valid {
+		return nil
+	}
+
+	if err := tx.Unscoped().Model(&member).Updates(map[string]interface{}{
+		"deleted_at":   nil,
+		"unread_count": 0,
+		"joined_at":    time.Now(),
+	}).Error; err != nil {
+		return fmt.Errorf("恢复会话成员失败: %w", err)
+	}
+	return nil
+}
+
+// postSystemMessage 在会话中发布系统消息
+func postSystemMessage(tx *gorm.DB, conversation *model.Conversation, senderID uint, content string) (*model.Message, error) {
+	message := &model.Message{
+		ConversationID: conversation.ID,
+		SenderID:       senderID,
+		Type:           model.MessageTypeSystem,
+		Content:        content,
+	}
+	if err := saveMessage(tx, conversation, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// userDisplayName 获取用户的展示名称，优先使用昵称
+func userDisplayName(tx *gorm.DB, userID uint) (string, error) {
+	user, err := getUser(tx, userID)
+	if err != nil {
+		return "", err
+	}
+	return displayName(user), nil
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateJoinRequest 申请加入公开团队，命中自动审批规则时直接入队
+func (s *TeamService) CreateJoinRequest(teamID, userID uint, message string) (*model.TeamJoinRequest, error) {
+	var (
+		request *model.TeamJoinRequest
+		notice  *model.Message
+		notices []NotifyRequest
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		team, err := getJoinableTeam(tx, teamID)
+		if err != nil {
+			return err
+		}
+		if err := checkCanRequestJoin(tx, teamID, userID); err != nil {
+			return err
+		}
+
+		var user model.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("查询用户失败: %w", err)
+		}
+
+		request = &model.TeamJoinRequest{
+			TeamID:  teamID,
+			UserID:  userID,
+			Message: strings.TrimSpace(message),
+			Status:  model.TeamJoinRequestStatusPending,
+		}
+
+		autoApprove, err := matchAutoApproveRules(tx, teamID, user.Email)
+		if err != nil {
+			return err
+		}
+		if autoApprove {
+			if _, err := addTeamMember(tx, team, userID, nil); err != nil {
+				return err
+			}
+			if notice, err = joinTeamConversation(tx, team, userID); err != nil {
+				return err
+			}
+			now := time.Now()
+			request.Status = model.TeamJoinRequestStatusApproved
+			request.AutoApproved = true
+			request.ReviewedAt = &now
+		} else {
+			pending := true
+			request.PendingFlag = &pending
+		}
+		// 并发提交时由待审批唯一索引拒绝重复的申请
+		if err := tx.Create(request).Error; err != nil {
+			if isDuplicateKey(err) {
+				return ErrJoinRequestExists
+			}
+			return fmt.Errorf("创建入队申请失败: %w", err)
+		}
+
+		if autoApprove {
+			return nil
+		}
+		notices, err = teamManagerNotices(tx, team.ID, userID, NoticeTeamJoinRequested,
+			map[string]interface{}{"Applicant": user.Username, "TeamName": team.Name})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.publishNotice(notice)
+	deliverNotices(context.Background(), s.notifier, notices)
+	return request, nil
+}
+
+// ListJoinRequests 团队管理员查看入队申请
+func (s *TeamService) ListJoinRequests(teamID, operatorID uint, status string) ([]model.TeamJoinRequest, error) {
+	if err := requireTeamManager(s.db, teamID, operatorID); err != nil {
+		return nil, err
+	}
+
+	db := s.db.Preload("User").Where("team_id = ?", teamID)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var requests []model.TeamJoinRequest
+	if err := db.Order("id DESC").Find(&requests).Error; err != nil {
+		return nil, fmt.Errorf("查询入队申请失败: %w", err)
+	}
+	return requests, nil
+}
+
+// ReviewJoinRequest 团队管理员审批入队申请，团队已归档、转为私有或已满时不能再通过申请
+func (s *TeamService) ReviewJoinRequest(teamID, requestID, operatorID uint, approve bool, note string) (*model.TeamJoinRequest, error) {
+	var (
+		request model.TeamJoinRequest
+		notice  *model.Message
+		result  NotifyRequest
+	)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := requireTeamManager(tx, teamID, operatorID); err != nil {
+			return err
+		}
+		if err := getPendingJoinRequest(tx, teamID, requestID, &request); err != nil {
+			return err
+		}
+
+		// 通过申请前重新校验团队仍可加入，拒绝申请不受限制
+		var (
+			team *model.Team
+			err  error
+		)
+		if approve {
+			team, err = getJoinableTeam(tx, teamID)
+		} else {
+			team, err = getTeam(tx, teamID)
+		}
+		if err != nil {
+			return err
+		}
+
+		status := model.TeamJoinRequestStatusRejected
+		result = NotifyRequest{UserID: request.UserID, SenderID: operatorID, Template: NoticeTeamJoinRejected,
+			Data: map[string]interface{}{"TeamName": team.Name}}
+		if approve {
+			if _, err := addTeamMember(tx, team, request.UserID, &operatorID); err != nil {
+				return err
+			}
+			if notice, err = joinTeamConversation(tx, team, request.UserID); err != nil {
+				return err
+			}
+			status = model.TeamJoinRequestStatusApproved
+			result.Template = NoticeTeamJoined
+		}
+
+		now := time.Now()
+		request.Status = status
+		request.ReviewedBy = &operatorID
+		request.ReviewedAt = &now
+		request.ReviewNote = strings.TrimSpace(note)
+		if err := tx.Model(&request).Updates(map[string]interface{}{
+			"status":       request.Status,
+			"pending_flag": nil,
+			"reviewed_by":  operatorID,
+			"reviewed_at":  now,
+			"review_note":  request.ReviewNote,
+		}).Error; err != nil {
+			return fmt.Errorf("更新入队申请失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.publishNotice(notice)
+	deliverNotices(context.Background(), s.notifier, []NotifyRequest{result})
+	return &request, nil
+}
+
+// CancelJoinRequest 申请人撤回待审批的入队申请
+func (s *TeamService) CancelJoinRequest(teamID, requestID, userID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var request model.TeamJoinRequest
+		if err := getPendingJoinRequest(tx, teamID, requestID, &request); err != nil {
+			return err
+		}
+		if request.UserID != userID {
+			return ErrForbidden
+		}
+		if err := tx.Model(&request).Updates(map[string]interface{}{
+			"status":       model.TeamJoinRequestStatusCancelled,
+			"pending_flag": nil,
+		}).Error; err != nil {
+			return fmt.Errorf("撤回入队申请失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListAutoApproveRules 查看团队的自动审批规则
+func (s *TeamService) ListAutoApproveRules(teamID, operatorID uint) ([]model.TeamAutoApproveRule, error) {
+	if err := requireTeamManager(s.db, teamID, operatorID); err != nil {
+		return nil, err
+	}
+
+	var rules []model.TeamAutoApproveRule
+	if err := s.db.Where("team_id = ?", teamID).Order("id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("查询自动审批规则失败: %w", err)
+	}
+	return rules, nil
+}
+
+// CreateAutoApproveRule 新增自动审批规则
+func (s *TeamService) CreateAutoApproveRule(teamID, operatorID uint, ruleType, ruleValue string) (*model.TeamAutoApproveRule, error) {
+	if err := requireTeamManager(s.db, teamID, operatorID); err != nil {
+		return nil, err
+	}
+
+	rule := &model.TeamAutoApproveRule{
+		TeamID:    teamID,
+		RuleType:  model.AutoApproveRuleEmailDomain,
+		RuleValue: strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ruleValue), "@")),
+		Enabled:   true,
+		CreatedBy: operatorID,
+	}
+	if ruleType != string(model.AutoApproveRuleEmailDomain) || rule.RuleValue == "" || strings.Contains(rule.RuleValue, "@") {
+		return nil, ErrAutoApproveRuleInvalid
+	}
+
+	if err := s.db.Create(rule).Error; err != nil {
+		return nil, fmt.Errorf("创建自动审批规则失败: %w", err)
+	}
+	return rule, nil
+}
+
+// DeleteAutoApproveRule 删除自动审批规则
+func (s *TeamService) DeleteAutoApproveRule(teamID, ruleID, operatorID uint) error {
+	if err := requireTeamManager(s.db, teamID, operatorID); err != nil {
+		return err
+	}
+
+	result := s.db.Where("id = ? AND team_id = ?", ruleID, teamID).Delete(&model.TeamAutoApproveRule{})
+	if result.Error != nil {
+		return fmt.Errorf("删除自动审批规则失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrAutoApproveRuleMissing
+	}
+	return nil
+}
+
+// getJoinableTeam 获取可申请加入的公开团队
+func getJoinableTeam(tx *gorm.DB, teamID uint) (*model.Team, error) {
+	team, err := getTeam(tx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if !team.IsActive() {
+		return nil, ErrTeamInactive
+	}
+	if !team.IsPublic {
+		return nil, ErrTeamNotPublic
+	}
+	if team.IsFull() {
+		return nil, ErrTeamFull
+	}
+	return team, nil
+}
+
+// checkCanRequestJoin 检查用户是否可以提交入队申请
+func checkCanRequestJoin(tx *gorm.DB, teamID, userID uint) error {
+	member, err := getTeamMember(tx, teamID, userID)
+	if err != nil {
+		return err
+	}
+	if member != nil && member.IsActive() {
+		return ErrAlreadyTeamMember
+	}
+
+	var pending int64
+	if err := tx.Model(&model.TeamJoinRequest{}).
+		Where("team_id = ? AND user_id = ? AND status = ?", teamID, userID, model.TeamJoinRequestStatusPending).
+		Count(&pending).Error; err != nil {
+		return fmt.Errorf("查询入队申请失败: %w", err)
+	}
+	if pending > 0 {
+		return ErrJoinRequestExists
+	}
+	return nil
+}
+
+// getPendingJoinRequest 获取并锁定待审批的入队申请，并发的审批和撤回在锁上排队，后到的看到已处理状态
+func getPendingJoinRequest(tx *gorm.DB, teamID, requestID uint, request *model.TeamJoinRequest) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND team_id = ?", requestID, teamID).First(request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrJoinRequestNotFound
+		}
+		return fmt.Errorf("查询入队申请失败: %w", err)
+	}
+	if !request.IsPending() {
+		return ErrJoinRequestProcessed
+	}
+	return nil
+}
+
+// matchAutoApproveRules 检查用户邮箱是否命中团队任一自动审批规则
+func matchAutoApproveRules(tx *gorm.DB, teamID uint, email string) (bool, error) {
+	var rules []model.TeamAutoApproveRule
+	if err := tx.Where("team_id = ? AND enabled = ?", teamID, true).Find(&rules).Error; err != nil {
+		return false, fmt.Errorf("查询自动审批规则失败: %w", err)
+	}
+	for i := range rules {
+		if rules[i].Match(email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// mysqlErrDuplicateEntry MySQL唯一索引冲突的错误码
+const mysqlErrDuplicateEntry = 1062
+
+// isDuplicateKey 检查错误是否为唯一索引冲突
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry
+}
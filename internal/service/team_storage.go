@@ -0,0 +1,479 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 团队存储相关配置键
+const (
+	configKeyTeamStorageWarnings = "team.storage.warning_thresholds" // 告警阈值(百分比,逗号分隔)
+)
+
+// 团队存储报表默认值
+const (
+	defaultStorageReportDays = 30
+	maxStorageReportDays     = 365
+	storageReportTopFolders  = 20
+)
+
+// defaultStorageWarningThresholds 默认告警阈值(百分比)
+var defaultStorageWarningThresholds = []int{80, 90, 100}
+
+// MemberStorageUsage 成员存储用量
+type MemberStorageUsage struct {
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	Size      int64  `json:"size"`
+	FileCount int64  `json:"file_count"`
+}
+
+// FileTypeStorageUsage 文件类型存储用量
+type FileTypeStorageUsage struct {
+	FileType  string `json:"file_type"`
+	Size      int64  `json:"size"`
+	FileCount int64  `json:"file_count"`
+}
+
+// FolderStorageUsage 文件夹存储用量
+type FolderStorageUsage struct {
+	FolderID   *uint  `json:"folder_id"`
+	FolderName string `json:"folder_name"`
+	Size       int64  `json:"size"`
+	FileCount  int64  `json:"file_count"`
+}
+
+// StorageForecast 存储增长预测
+type StorageForecast struct {
+	DailyGrowth       float64    `json:"daily_growth"`
+	DaysUntilFull     *int       `json:"days_until_full"`
+	EstimatedFullDate *time.Time `json:"estimated_full_date"`
+}
+
+// TeamStorageReport 团队存储报表
+type TeamStorageReport struct {
+	TeamID       uint                        `json:"team_id"`
+	StorageUsed  int64                       `json:"storage_used"`
+	StorageLimit int64                       `json:"storage_limit"`
+	UsagePercent float64                     `json:"usage_percent"`
+	FileCount    int                         `json:"file_count"`
+	ByMember     []MemberStorageUsage        `json:"by_member"`
+	ByFileType   []FileTypeStorageUsage      `json:"by_file_type"`
+	ByFolder     []FolderStorageUsage        `json:"by_folder"`
+	Trend        []model.TeamStorageSnapshot `json:"trend"`
+	Forecast     StorageForecast             `json:"forecast"`
+}
+
+// GetStorageReport 获取团队存储报表，仅团队管理员可查看
+func (s *TeamService) GetStorageReport(teamID, operatorID uint, days int) (*TeamStorageReport, error) {
+	if err := requireTeamManager(s.db, teamID, operatorID); err != nil {
+		return nil, err
+	}
+	team, err := getTeam(s.db, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if days <= 0 {
+		days = defaultStorageReportDays
+	}
+	if days > maxStorageReportDays {
+		days = maxStorageReportDays
+	}
+
+	report := &TeamStorageReport{
+		TeamID:       team.ID,
+		StorageUsed:  team.StorageUsed,
+		StorageLimit: team.StorageLimit,
+		UsagePercent: team.GetStorageUsagePercent(),
+		FileCount:    team.FileCount,
+	}
+	if err := s.fillStorageBreakdown(teamID, report); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	if err := s.db.Where("team_id = ? AND snapshot_date >= ?", teamID, since.Format("2006-01-02")).
+		Order("snapshot_date").Find(&report.Trend).Error; err != nil {
+		return nil, fmt.Errorf("查询存储快照失败: %w", err)
+	}
+	report.Forecast = forecastStorage(report.Trend, team.StorageLimit, time.Now())
+	return report, nil
+}
+
+// teamStorageFile 团队存储统计中的一个文件，FolderID为文件计入的文件夹
+type teamStorageFile struct {
+	SharedBy uint
+	FileType string
+	Size     int64
+	FolderID *uint
+}
+
+// fillStorageBreakdown 按成员、文件类型、文件夹统计团队存储
+// 共享文件夹中的文件计入共享该文件夹的成员，并汇总到最外层的共享文件夹；单独共享的文件计入其所在文件夹
+func (s *TeamService) fillStorageBreakdown(teamID uint, report *TeamStorageReport) error {
+	files, err := teamStorageFiles(s.db, teamID)
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]uint, 0, len(files))
+	folderIDs := make([]uint, 0, len(files))
+	for _, file := range files {
+		userIDs = append(userIDs, file.SharedBy)
+		if file.FolderID != nil {
+			folderIDs = append(folderIDs, *file.FolderID)
+		}
+	}
+	usernames, err := lookupNames(s.db.Model(&model.User{}).Select("id, username AS name"), userIDs)
+	if err != nil {
+		return fmt.Errorf("查询成员失败: %w", err)
+	}
+	folderNames, err := lookupNames(s.db.Model(&model.File{}).Select("id, name"), folderIDs)
+	if err != nil {
+		return fmt.Errorf("查询文件夹失败: %w", err)
+	}
+
+	report.ByMember, report.ByFileType, report.ByFolder = summarizeTeamStorage(files, usernames, folderNames)
+	return nil
+}
+
+// teamStorageFiles 列出团队可见的全部文件(不含文件夹本身)，共享文件夹展开为其中的所有文件，每个文件只计一次
+func teamStorageFiles(tx *gorm.DB, teamID uint) ([]teamStorageFile, error) {
+	var shared []struct {
+		FileID   uint
+		SharedBy uint
+		ParentID *uint
+		FileType string
+		Size     int64
+	}
+	if err := tx.Table("team_files AS tf").
+		Select("tf.file_id, tf.shared_by, f.parent_id, f.file_type, f.size").
+		Joins("JOIN files AS f ON f.id = tf.file_id").
+		Where("tf.team_id = ? AND tf.deleted_at IS NULL AND f.deleted_at IS NULL", teamID).
+		Order("tf.id").Scan(&shared).Error; err != nil {
+		return nil, fmt.Errorf("查询团队文件失败: %w", err)
+	}
+
+	// 从全部共享文件夹开始逐层向下展开，记录每个展开到的文件夹的上级
+	sharers := make(map[uint]uint)
+	parents := make(map[uint]*uint)
+	var frontier []uint
+	for _, tf := range shared {
+		if tf.FileType == string(model.FileTypeFolder) {
+			if _, ok := sharers[tf.FileID]; !ok {
+				sharers[tf.FileID] = tf.SharedBy
+				parents[tf.FileID] = tf.ParentID
+				frontier = append(frontier, tf.FileID)
+			}
+		}
+	}
+	type child struct {
+		ID       uint
+		ParentID uint
+		FileType string
+		Size     int64
+	}
+	var nested []child
+	seen := make(map[uint]bool)
+	for depth := 0; len(frontier) > 0 && depth < maxFolderDepth; depth++ {
+		var children []child
+		if err := tx.Model(&model.File{}).Select("id, parent_id, file_type, size").
+			Where("parent_id IN ? AND deleted_at IS NULL", frontier).Scan(&children).Error; err != nil {
+			return nil, fmt.Errorf("查询共享文件夹内容失败: %w", err)
+		}
+		frontier = frontier[:0]
+		for _, c := range children {
+			if c.FileType != string(model.FileTypeFolder) {
+				nested = append(nested, c)
+				continue
+			}
+			// 位于另一个共享文件夹中的共享文件夹已作为起点展开过
+			if _, ok := parents[c.ID]; !ok {
+				parentID := c.ParentID
+				parents[c.ID] = &parentID
+				frontier = append(frontier, c.ID)
+			}
+		}
+	}
+
+	files := make([]teamStorageFile, 0, len(shared)+len(nested))
+	for _, c := range nested {
+		if seen[c.ID] {
+			continue
+		}
+		seen[c.ID] = true
+		top := topSharedFolder(parents, c.ParentID)
+		files = append(files, teamStorageFile{SharedBy: sharers[top], FileType: c.FileType, Size: c.Size, FolderID: &top})
+	}
+	for _, tf := range shared {
+		if tf.FileType == string(model.FileTypeFolder) || seen[tf.FileID] {
+			continue
+		}
+		seen[tf.FileID] = true
+		files = append(files, teamStorageFile{SharedBy: tf.SharedBy, FileType: tf.FileType, Size: tf.Size, FolderID: tf.ParentID})
+	}
+	return files, nil
+}
+
+// topSharedFolder 从展开到的文件夹向上找到最外层的共享文件夹，parents只包含展开过的文件夹
+func topSharedFolder(parents map[uint]*uint, folderID uint) uint {
+	for depth := 0; depth < maxFolderDepth; depth++ {
+		parentID := parents[folderID]
+		if parentID == nil {
+			return folderID
+		}
+		if _, ok := parents[*parentID]; !ok {
+			return folderID
+		}
+		folderID = *parentID
+	}
+	return folderID
+}
+
+// summarizeTeamStorage 汇总团队文件的成员、文件类型和文件夹用量，按用量从大到小排列，文件夹只保留用量最大的若干个
+func summarizeTeamStorage(files []teamStorageFile, usernames, folderNames map[uint]string) ([]MemberStorageUsage, []FileTypeStorageUsage, []FolderStorageUsage) {
+	byMember := make(map[uint]*MemberStorageUsage)
+	byType := make(map[string]*FileTypeStorageUsage)
+	byFolder := make(map[uint]*FolderStorageUsage)
+	var root *FolderStorageUsage
+	for _, file := range files {
+		member, ok := byMember[file.SharedBy]
+		if !ok {
+			member = &MemberStorageUsage{UserID: file.SharedBy, Username: usernames[file.SharedBy]}
+			byMember[file.SharedBy] = member
+		}
+		member.Size += file.Size
+		member.FileCount++
+
+		fileType, ok := byType[file.FileType]
+		if !ok {
+			fileType = &FileTypeStorageUsage{FileType: file.FileType}
+			byType[file.FileType] = fileType
+		}
+		fileType.Size += file.Size
+		fileType.FileCount++
+
+		var folder *FolderStorageUsage
+		if file.FolderID == nil {
+			if root == nil {
+				root = &FolderStorageUsage{}
+			}
+			folder = root
+		} else if folder, ok = byFolder[*file.FolderID]; !ok {
+			id := *file.FolderID
+			folder = &FolderStorageUsage{FolderID: &id, FolderName: folderNames[id]}
+			byFolder[id] = folder
+		}
+		folder.Size += file.Size
+		folder.FileCount++
+	}
+
+	members := make([]MemberStorageUsage, 0, len(byMember))
+	for _, usage := range byMember {
+		members = append(members, *usage)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Size != members[j].Size {
+			return members[i].Size > members[j].Size
+		}
+		return members[i].UserID < members[j].UserID
+	})
+
+	types := make([]FileTypeStorageUsage, 0, len(byType))
+	for _, usage := range byType {
+		types = append(types, *usage)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if types[i].Size != types[j].Size {
+			return types[i].Size > types[j].Size
+		}
+		return types[i].FileType < types[j].FileType
+	})
+
+	folders := make([]FolderStorageUsage, 0, len(byFolder)+1)
+	if root != nil {
+		folders = append(folders, *root)
+	}
+	for _, usage := range byFolder {
+		folders = append(folders, *usage)
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		if folders[i].Size != folders[j].Size {
+			return folders[i].Size > folders[j].Size
+		}
+		return folders[i].FolderID == nil || (folders[j].FolderID != nil && *folders[i].FolderID < *folders[j].FolderID)
+	})
+	if len(folders) > storageReportTopFolders {
+		folders = folders[:storageReportTopFolders]
+	}
+	return members, types, folders
+}
+
+// lookupNames 按ID查询名称，query需选出id和name两列
+func lookupNames(query *gorm.DB, ids []uint) (map[uint]string, error) {
+	names := make(map[uint]string)
+	if len(ids) == 0 {
+		return names, nil
+	}
+	var rows []struct {
+		ID   uint
+		Name string
+	}
+	if err := query.Where("id IN ?", ids).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		names[row.ID] = row.Name
+	}
+	return names, nil
+}
+
+// SnapshotTeamStorage 记录所有活跃团队的当日存储快照并检查用量告警
+func (s *TeamService) SnapshotTeamStorage(ctx context.Context) error {
+	var teams []model.Team
+	if err := s.db.WithContext(ctx).Where("status = ?", model.TeamStatusActive).Find(&teams).Error; err != nil {
+		return fmt.Errorf("查询团队失败: %w", err)
+	}
+
+	thresholds := getConfigIntList(s.db, configKeyTeamStorageWarnings, defaultStorageWarningThresholds)
+	today := startOfDay(time.Now())
+	for i := range teams {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.snapshotTeam(&teams[i], today); err != nil {
+			return err
+		}
+		if err := s.checkStorageAlerts(ctx, &teams[i], thresholds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotTeam 写入单个团队的当日快照，同一天重复执行时覆盖为最新值
+func (s *TeamService) snapshotTeam(team *model.Team, day time.Time) error {
+	snapshot := model.TeamStorageSnapshot{
+		TeamID:       team.ID,
+		SnapshotDate: day,
+		StorageUsed:  team.StorageUsed,
+		StorageLimit: team.StorageLimit,
+		FileCount:    team.FileCount,
+		MemberCount:  team.MemberCount,
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "team_id"}, {Name: "snapshot_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"storage_used", "storage_limit", "file_count", "member_count"}),
+	}).Create(&snapshot).Error
+	if err != nil {
+		return fmt.Errorf("写入团队 %d 存储快照失败: %w", team.ID, err)
+	}
+	return nil
+}
+
+// checkStorageAlerts 检查团队用量是否越过告警阈值，越过新阈值时通知团队管理员
+func (s *TeamService) checkStorageAlerts(ctx context.Context, team *model.Team, thresholds []int) error {
+	percent := team.GetStorageUsagePercent()
+	var notices []NotifyRequest
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var openAlerts []model.TeamStorageAlert
+		if err := tx.Where("team_id = ? AND resolved_at IS NULL", team.ID).Find(&openAlerts).Error; err != nil {
+			return fmt.Errorf("查询存储告警失败: %w", err)
+		}
+		alerted := make(map[int]bool, len(openAlerts))
+		for _, alert := range openAlerts {
+			alerted[alert.Threshold] = true
+		}
+
+		highest := 0
+		for _, threshold := range thresholds {
+			if percent < float64(threshold) {
+				if err := tx.Model(&model.TeamStorageAlert{}).
+					Where("team_id = ? AND threshold = ? AND resolved_at IS NULL", team.ID, threshold).
+					Update("resolved_at", time.Now()).Error; err != nil {
+					return fmt.Errorf("解除存储告警失败: %w", err)
+				}
+				continue
+			}
+			if alerted[threshold] {
+				continue
+			}
+			alert := model.TeamStorageAlert{
+				TeamID: team.ID, Threshold: threshold, StorageUsed: team.StorageUsed, StorageLimit: team.StorageLimit,
+			}
+			if err := tx.Create(&alert).Error; err != nil {
+				return fmt.Errorf("创建存储告警失败: %w", err)
+			}
+			highest = max(highest, threshold)
+		}
+
+		if highest == 0 {
+			return nil
+		}
+		var err error
+		notices, err = teamManagerNotices(tx, team.ID, team.CreatorID, NoticeTeamStorageAlert, map[string]interface{}{
+			"TeamName":  team.Name,
+			"Percent":   fmt.Sprintf("%.1f", percent),
+			"Threshold": highest,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	deliverNotices(ctx, s.notifier, notices)
+	return nil
+}
+
+// forecastStorage 基于快照做线性回归，预测团队存储达到上限的时间
+func forecastStorage(snapshots []model.TeamStorageSnapshot, limit int64, now time.Time) StorageForecast {
+	var forecast StorageForecast
+	if len(snapshots) < 2 {
+		return forecast
+	}
+
+	origin := snapshots[0].SnapshotDate
+	var sumX, sumY, sumXY, sumXX float64
+	for _, snapshot := range snapshots {
+		x := snapshot.SnapshotDate.Sub(origin).Hours() / 24
+		y := float64(snapshot.StorageUsed)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(len(snapshots))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return forecast
+	}
+
+	forecast.DailyGrowth = (n*sumXY - sumX*sumY) / denominator
+	latest := snapshots[len(snapshots)-1].StorageUsed
+	if forecast.DailyGrowth <= 0 || limit <= 0 {
+		return forecast
+	}
+
+	days := 0
+	if remaining := float64(limit - latest); remaining > 0 {
+		days = int(math.Ceil(remaining / forecast.DailyGrowth))
+	}
+	fullDate := now.AddDate(0, 0, days)
+	forecast.DaysUntilFull = &days
+	forecast.EstimatedFullDate = &fullDate
+	return forecast
+}
+
+// startOfDay 获取当天零点
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"ycg_cloud/internal/model"
+)
+
+// TestForecastStorage 测试团队存储增长预测
+func TestForecastStorage(t *testing.T) {
+	origin := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	now := origin.AddDate(0, 0, 2)
+
+	// 每天增长100字节，剩余700字节，预计7天后达到上限
+	snapshots := []model.TeamStorageSnapshot{
+		{SnapshotDate: origin, StorageUsed: 100},
+		{SnapshotDate: origin.AddDate(0, 0, 1), StorageUsed: 200},
+		{SnapshotDate: origin.AddDate(0, 0, 2), StorageUsed: 300},
+	}
+	forecast := forecastStorage(snapshots, 1000, now)
+	if forecast.DailyGrowth != 100 {
+		t.Errorf("每日增长错误: 期望 100, 实际 %f", forecast.DailyGrowth)
+	}
+	if forecast.DaysUntilFull == nil || *forecast.DaysUntilFull != 7 {
+		t.Fatalf("预计天数错误: %v", forecast.DaysUntilFull)
+	}
+	if !forecast.EstimatedFullDate.Equal(now.AddDate(0, 0, 7)) {
+		t.Errorf("预计日期错误: %v", forecast.EstimatedFullDate)
+	}
+
+	// 用量未增长时无法预测
+	flat := []model.TeamStorageSnapshot{
+		{SnapshotDate: origin, StorageUsed: 300},
+		{SnapshotDate: origin.AddDate(0, 0, 1), StorageUsed: 300},
+	}
+	if forecast := forecastStorage(flat, 1000, now); forecast.DaysUntilFull != nil {
+		t.Errorf("用量未增长时不应给出预测")
+	}
+
+	// 快照不足两条时无法预测
+	if forecast := forecastStorage(snapshots[:1], 1000, now); forecast.DaysUntilFull != nil {
+		t.Errorf("快照不足时不应给出预测")
+	}
+}
+
+// TestTopSharedFolder 测试文件夹汇总到最外层的共享文件夹
+func TestTopSharedFolder(t *testing.T) {
+	home, outer, inner, leaf := uint(1), uint(2), uint(3), uint(4)
+	// outer和inner都被共享，inner位于outer中，home未被展开
+	parents := map[uint]*uint{outer: &home, inner: &outer, leaf: &inner}
+	for _, id := range []uint{outer, inner, leaf} {
+		if got := topSharedFolder(parents, id); got != outer {
+			t.Errorf("文件夹 %d 应汇总到 %d, 实际 %d", id, outer, got)
+		}
+	}
+	// 根目录下的共享文件夹
+	rootShared := uint(5)
+	parents[rootShared] = nil
+	if got := topSharedFolder(parents, rootShared); got != rootShared {
+		t.Errorf("根目录下的共享文件夹应汇总到自身, 实际 %d", got)
+	}
+}
+
+// TestSummarizeTeamStorage 测试按成员、文件类型、文件夹汇总的用量合计一致
+func TestSummarizeTeamStorage(t *testing.T) {
+	shared, other := uint(10), uint(20)
+	files := []teamStorageFile{
+		{SharedBy: 1, FileType: "image", Size: 300, FolderID: &shared},
+		{SharedBy: 1, FileType: "document", Size: 200, FolderID: &shared},
+		{SharedBy: 2, FileType: "image", Size: 100, FolderID: &other},
+		{SharedBy: 2, FileType: "video", Size: 50},
+	}
+	members, types, folders := summarizeTeamStorage(files,
+		map[uint]string{1: "alice", 2: "bob"}, map[uint]string{shared: "设计稿", other: "素材"})
+
+	if len(members) != 2 || members[0].Username != "alice" || members[0].Size != 500 || members[0].FileCount != 2 {
+		t.Errorf("按成员汇总错误: %+v", members)
+	}
+	if len(types) != 3 || types[0].FileType != "image" || types[0].Size != 400 {
+		t.Errorf("按文件类型汇总错误: %+v", types)
+	}
+	if len(folders) != 3 || folders[0].FolderName != "设计稿" || folders[0].Size != 500 || folders[2].FolderID != nil {
+		t.Errorf("按文件夹汇总错误: %+v", folders)
+	}
+
+	for name, total := range map[string]int64{
+		"成员":   members[0].Size + members[1].Size,
+		"文件类型": types[0].Size + types[1].Size + types[2].Size,
+		"文件夹":  folders[0].Size + folders[1].Size + folders[2].Size,
+	} {
+		if total != 650 {
+			t.Errorf("按%s汇总的合计 = %d, 期望 650", name, total)
+		}
+	}
+}
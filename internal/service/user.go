@@ -0,0 +1,22 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"ycg_cloud/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// getUser 获取用户
+func getUser(tx *gorm.DB, userID uint) (*model.User, error) {
+	var user model.User
+	if err := tx.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	return &user, nil
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims JWT声明
+type Claims struct {
+	UserID         uint   `json:"user_id"`
+	Username       string `json:"username"`
+	UserType       string `json:"user_type"`
+	Version        int    `json:"ver"`
+	SessionID      uint   `json:"sid"`
+	ImpersonatorID uint   `json:"imp,omitempty"` // 非零时表示管理员正以该用户身份操作
+	jwt.RegisteredClaims
+}
+
+// GenerateToken 生成访问令牌，version为用户当前的令牌版本，sessionID为令牌所属的登录会话
+func GenerateToken(userID uint, username, userType string, version int, sessionID uint) (string, error) {
+	if GlobalConfig == nil {
+		return "", errors.New("配置未初始化")
+	}
+	return SignToken(&Claims{
+		UserID:    userID,
+		Username:  username,
+		UserType:  userType,
+		Version:   version,
+		SessionID: sessionID,
+	}, GlobalConfig.JWT.ExpireTime)
+}
+
+// SignToken 签名令牌声明，ttl为令牌有效期
+func SignToken(claims *Claims, ttl time.Duration) (string, error) {
+	if GlobalConfig == nil {
+		return "", errors.New("配置未初始化")
+	}
+
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    GlobalConfig.JWT.Issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(GlobalConfig.JWT.Secret))
+	if err != nil {
+		return "", fmt.Errorf("签名令牌失败: %w", err)
+	}
+	return signed, nil
+}
+
+// ParseToken 解析并校验访问令牌
+func ParseToken(tokenString string) (*Claims, error) {
+	if GlobalConfig == nil {
+		return nil, errors.New("配置未初始化")
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("不支持的签名算法: %v", token.Header["alg"])
+		}
+		return []byte(GlobalConfig.JWT.Secret), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("解析令牌失败: %w", err)
+	}
+	if !token.Valid {
+		return nil, errors.New("令牌无效")
+	}
+	return claims, nil
+}
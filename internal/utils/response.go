@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PageResult 分页结果
+type PageResult struct {
+	List     interface{} `json:"list"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+}
+
+// Success 返回成功响应
+func Success(ctx *gin.Context, data interface{}) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "success",
+		"data":    data,
+	})
+}
+
+// Fail 返回失败响应
+func Fail(ctx *gin.Context, httpStatus int, message string) {
+	ctx.JSON(httpStatus, gin.H{
+		"code":    httpStatus,
+		"message": message,
+		"data":    nil,
+	})
+}
+
+// AbortWithError 中止请求并返回失败响应
+func AbortWithError(ctx *gin.Context, httpStatus int, message string) {
+	ctx.AbortWithStatusJSON(httpStatus, gin.H{
+		"code":    httpStatus,
+		"message": message,
+		"data":    nil,
+	})
+}
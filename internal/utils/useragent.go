@@ -0,0 +1,82 @@
+package utils
+
+import "strings"
+
+// UserAgentInfo 从User-Agent中解析出的设备信息
+type UserAgentInfo struct {
+	Device  string
+	OS      string
+	Browser string
+}
+
+// 设备类型
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceUnknown = "unknown"
+)
+
+// uaRule User-Agent匹配规则，按顺序匹配第一个包含关键字的规则
+type uaRule struct {
+	keyword string
+	name    string
+}
+
+// 操作系统规则，iPad和Android需要排在Mac和Linux之前
+var osRules = []uaRule{
+	{"windows", "Windows"},
+	{"iphone", "iOS"},
+	{"ipad", "iPadOS"},
+	{"android", "Android"},
+	{"harmonyos", "HarmonyOS"},
+	{"mac os x", "macOS"},
+	{"cros", "ChromeOS"},
+	{"linux", "Linux"},
+}
+
+// 浏览器规则，基于Chromium的浏览器需要排在Chrome之前
+var browserRules = []uaRule{
+	{"micromessenger", "WeChat"},
+	{"edg", "Edge"},
+	{"opr/", "Opera"},
+	{"firefox", "Firefox"},
+	{"chrome", "Chrome"},
+	{"safari", "Safari"},
+	{"curl", "curl"},
+	{"okhttp", "OkHttp"},
+}
+
+// ParseUserAgent 解析User-Agent中的设备类型、操作系统和浏览器，无法识别的部分为空
+func ParseUserAgent(userAgent string) UserAgentInfo {
+	ua := strings.ToLower(userAgent)
+	info := UserAgentInfo{
+		OS:      matchRule(ua, osRules),
+		Browser: matchRule(ua, browserRules),
+	}
+
+	switch {
+	case ua == "":
+		info.Device = DeviceUnknown
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet") ||
+		(strings.Contains(ua, "android") && !strings.Contains(ua, "mobile")):
+		info.Device = DeviceTablet
+	case strings.Contains(ua, "mobile") || strings.Contains(ua, "iphone"):
+		info.Device = DeviceMobile
+	case info.OS != "":
+		info.Device = DeviceDesktop
+	default:
+		info.Device = DeviceUnknown
+	}
+	return info
+}
+
+// matchRule 返回第一个匹配规则的名称
+func matchRule(ua string, rules []uaRule) string {
+	for _, rule := range rules {
+		if strings.Contains(ua, rule.keyword) {
+			return rule.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package utils
+
+import "testing"
+
+// TestParseUserAgent 测试User-Agent解析
+func TestParseUserAgent(t *testing.T) {
+	cases := []struct {
+		ua   string
+		want UserAgentInfo
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+			UserAgentInfo{Device: DeviceDesktop, OS: "Windows", Browser: "Edge"},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+			UserAgentInfo{Device: DeviceMobile, OS: "iOS", Browser: "Safari"},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			UserAgentInfo{Device: DeviceTablet, OS: "Android", Browser: "Chrome"},
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:121.0) Gecko/20100101 Firefox/121.0",
+			UserAgentInfo{Device: DeviceDesktop, OS: "macOS", Browser: "Firefox"},
+		},
+		{"", UserAgentInfo{Device: DeviceUnknown}},
+	}
+	for _, tc := range cases {
+		if got := ParseUserAgent(tc.ua); got != tc.want {
+			t.Errorf("解析 %q: 期望 %+v, 实际 %+v", tc.ua, tc.want, got)
+		}
+	}
+}
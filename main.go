@@ -1,17 +1,36 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"ycg_cloud/internal/database"
+	"ycg_cloud/internal/middleware"
+	"ycg_cloud/internal/realtime"
+	"ycg_cloud/internal/routes"
+	"ycg_cloud/internal/scheduler"
+	"ycg_cloud/internal/service"
 	"ycg_cloud/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-// main 程序入口点，启动Gin HTTP服务器
+// shutdownTimeout 收到退出信号后等待进行中的请求完成的最长时间
+const shutdownTimeout = 15 * time.Second
+
+// main 程序入口点，启动Gin HTTP服务器，收到退出信号后优雅关闭
 func main() {
+	// 收到SIGINT或SIGTERM时取消ctx
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// 初始化配置
 	if err := utils.InitConfig("", ""); err != nil {
 		log.Fatal("配置初始化失败:", err)
@@ -23,6 +42,22 @@ func main() {
 		log.Fatal("获取配置失败")
 	}
 
+	// 初始化数据库
+	db, err := database.InitDB(config)
+	if err != nil {
+		log.Fatal("数据库初始化失败:", err)
+	}
+
+	// 初始化Redis，不可用时即时通讯仅在本实例内分发
+	rdb, err := database.InitRedis(config)
+	if err != nil {
+		log.Printf("Redis初始化失败，即时通讯将以单实例模式运行: %v", err)
+	}
+
+	// 启动即时通讯网关
+	hub := realtime.NewHub(db, rdb)
+	go hub.Run(ctx)
+
 	// 设置Gin模式
 	gin.SetMode(config.Server.Mode)
 
@@ -33,11 +68,33 @@ func main() {
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
+	// 客户端IP用于操作日志和IP封禁，只采信可信代理转发的X-Forwarded-For
+	if err := router.SetTrustedProxies(config.Server.TrustedProxies); err != nil {
+		log.Fatal("可信代理配置错误:", err)
+	}
+
+	// 操作日志异步写入
+	operationLogger := service.NewOperationLogger(db, config.Log.Operation.BufferSize,
+		config.Log.Operation.BatchSize, config.Log.Operation.FlushInterval)
+	// 日志管道在HTTP服务关闭后才停止，保证进行中的请求产生的日志也能写出
+	loggerCtx, stopLogger := context.WithCancel(context.Background())
+	loggerDone := make(chan struct{})
+	go func() {
+		defer close(loggerDone)
+		operationLogger.Run(loggerCtx)
+	}()
+	router.Use(middleware.OperationLog(operationLogger))
+
+	// 拒绝被安全规则自动封禁的IP
+	router.Use(middleware.IPBlock(service.NewIPBlocklist(db).IsBlocked))
+
 	// 添加CORS中间件
 	router.Use(func(ctx *gin.Context) {
 		ctx.Header("Access-Control-Allow-Origin", "*")
 		ctx.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		ctx.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		// 前端据此显示代登录提示，跨域时需要显式暴露
+		ctx.Header("Access-Control-Expose-Headers", middleware.HeaderImpersonator)
 		if ctx.Request.Method == "OPTIONS" {
 			ctx.AbortWithStatus(http.StatusNoContent)
 			return
@@ -55,6 +112,14 @@ func main() {
 		})
 	})
 
+	// 业务路由
+	routes.Register(apiV1, db, hub, router.Routes, operationLogger)
+
+	// 启动后台定时任务
+	jobScheduler := scheduler.New()
+	scheduler.RegisterJobs(jobScheduler, db, config)
+	jobScheduler.Start(ctx)
+
 	// 启动服务器
 	log.Printf("%s 后端服务启动中...", config.App.Name)
 	log.Printf("版本: %s", config.App.Version)
@@ -64,7 +129,24 @@ func main() {
 	log.Printf("服务地址: http://%s", serverAddr)
 	log.Printf("健康检查: http://%s/api/v1/health", serverAddr)
 
-	if err := router.Run(fmt.Sprintf(":%d", config.Server.Port)); err != nil {
-		log.Fatal("服务启动失败:", err)
+	server := &http.Server{Addr: fmt.Sprintf(":%d", config.Server.Port), Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("服务启动失败:", err)
+		}
+	}()
+
+	// 等待退出信号，依次停止接收请求、等待定时任务退出、写出缓冲区中的操作日志
+	<-ctx.Done()
+	stop()
+	log.Println("收到退出信号，正在关闭服务...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("关闭HTTP服务失败: %v", err)
 	}
+	jobScheduler.Wait()
+	stopLogger()
+	<-loggerDone
+	log.Println("服务已退出")
 }